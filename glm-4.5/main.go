@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -401,7 +402,7 @@ func (t *Terminal) ParseCommand(input string) (string, []string, error) {
 // Pwd prints the current working directory
 func (t *Terminal) Pwd(args []string) {
 	if len(args) > 0 {
-		fmt.Println("pwd: too many arguments")
+		fmt.Println(usage("pwd"))
 		return
 	}
 
@@ -429,7 +430,7 @@ func (t *Terminal) Cd(args []string) {
 	}
 
 	if len(args) > 1 {
-		fmt.Println("cd: too many arguments")
+		fmt.Println(usage("cd"))
 		return
 	}
 
@@ -468,7 +469,7 @@ func (t *Terminal) Cd(args []string) {
 // Touch creates a new empty file
 func (t *Terminal) Touch(args []string) {
 	if len(args) == 0 {
-		fmt.Println("touch: missing file operand")
+		fmt.Println(usage("touch"))
 		return
 	}
 
@@ -478,25 +479,25 @@ func (t *Terminal) Touch(args []string) {
 		filename := arg
 
 		// If path contains a directory separator, split it
-		if strings.Contains(arg, "/") {
-			// Extract directory path
-			lastSlash := strings.LastIndex(arg, "/")
-			if lastSlash > 0 {
-				dirPath := arg[:lastSlash]
-				filename = arg[lastSlash+1:]
-
-				// Resolve the directory
-				var err error
-				dir, err = t.FS.ResolvePath(dirPath)
-				if err != nil {
-					fmt.Printf("touch: %v\n", err)
-					continue
-				}
+		if lastSlash := strings.LastIndex(arg, "/"); lastSlash >= 0 {
+			// Extract directory path, where a leading slash alone is the root
+			dirPath := arg[:lastSlash]
+			filename = arg[lastSlash+1:]
+			if dirPath == "" {
+				dirPath = "/"
+			}
 
-				if dir.Type != Directory {
-					fmt.Printf("touch: %s: Not a directory\n", dirPath)
-					continue
-				}
+			// Resolve the directory
+			var err error
+			dir, err = t.FS.ResolvePath(dirPath)
+			if err != nil {
+				fmt.Printf("touch: cannot touch '%s': %v\n", arg, err)
+				continue
+			}
+
+			if dir.Type != Directory {
+				fmt.Printf("touch: cannot touch '%s': Not a directory\n", arg)
+				continue
 			}
 		}
 
@@ -518,20 +519,22 @@ func (t *Terminal) Touch(args []string) {
 // Rm removes files or directories
 func (t *Terminal) Rm(args []string) {
 	if len(args) == 0 {
-		fmt.Println("rm: missing operand")
+		fmt.Println(usage("rm"))
 		return
 	}
 
 	recursive := false
+	emptyDirs := false
 
-	// Check for -r flag
-	if len(args) > 0 && args[0] == "-r" {
-		recursive = true
+	// Check for -r and -d flags
+	for len(args) > 0 && (args[0] == "-r" || args[0] == "-d") {
+		recursive = recursive || args[0] == "-r"
+		emptyDirs = emptyDirs || args[0] == "-d"
 		args = args[1:]
 	}
 
 	if len(args) == 0 {
-		fmt.Println("rm: missing operand after -r")
+		fmt.Println(usage("rm"))
 		return
 	}
 
@@ -542,10 +545,16 @@ func (t *Terminal) Rm(args []string) {
 			continue
 		}
 
-		// Check if it's a directory and if recursive flag is set
-		if target.Type == Directory && len(target.Children) > 0 && !recursive {
-			fmt.Printf("rm: cannot remove '%s': Is a directory\n", arg)
-			continue
+		// A directory needs the recursive flag, or -d when it is empty
+		if target.Type == Directory && !recursive {
+			if !emptyDirs {
+				fmt.Printf("rm: cannot remove '%s': Is a directory\n", arg)
+				continue
+			}
+			if len(target.Children) > 0 {
+				fmt.Printf("rm: cannot remove '%s': Directory not empty\n", arg)
+				continue
+			}
 		}
 
 		// Remove from parent directory
@@ -562,7 +571,7 @@ func (t *Terminal) Rm(args []string) {
 // Cp copies files or directories
 func (t *Terminal) Cp(args []string) {
 	if len(args) < 2 {
-		fmt.Println("cp: missing file operand")
+		fmt.Println(usage("cp"))
 		return
 	}
 
@@ -575,7 +584,7 @@ func (t *Terminal) Cp(args []string) {
 	}
 
 	if len(args) < 2 {
-		fmt.Println("cp: missing file operand after -r")
+		fmt.Println(usage("cp"))
 		return
 	}
 
@@ -668,7 +677,7 @@ func (t *Terminal) copyFileOrDirectory(source, destDir *VirtualFile, destName st
 // Mv moves or renames files or directories
 func (t *Terminal) Mv(args []string) {
 	if len(args) < 2 {
-		fmt.Println("mv: missing file operand")
+		fmt.Println(usage("mv"))
 		return
 	}
 
@@ -740,7 +749,7 @@ func (t *Terminal) Mv(args []string) {
 // Mkdir creates directories
 func (t *Terminal) Mkdir(args []string) {
 	if len(args) == 0 {
-		fmt.Println("mkdir: missing operand")
+		fmt.Println(usage("mkdir"))
 		return
 	}
 
@@ -753,7 +762,7 @@ func (t *Terminal) Mkdir(args []string) {
 	}
 
 	if len(args) == 0 {
-		fmt.Println("mkdir: missing operand after -p")
+		fmt.Println(usage("mkdir"))
 		return
 	}
 
@@ -852,7 +861,7 @@ func (t *Terminal) createDirectoryWithParents(path string) {
 // Rmdir removes empty directories
 func (t *Terminal) Rmdir(args []string) {
 	if len(args) == 0 {
-		fmt.Println("rmdir: missing operand")
+		fmt.Println(usage("rmdir"))
 		return
 	}
 
@@ -921,51 +930,23 @@ func (t *Terminal) Ls(args []string) {
 		}
 
 		if target.Type != Directory {
-			// If it's a file, just print the file name
-			fmt.Println(target.Name)
+			// A file lists as itself
+			if longFormat {
+				fmt.Println(longLine(target, target.Name))
+			} else {
+				fmt.Println(target.Name)
+			}
 			return
 		}
 	}
 
 	// List contents
-	var names []string
-	for name := range target.Children {
-		// Skip hidden files unless -a flag is set
-		if !showHidden && strings.HasPrefix(name, ".") {
-			continue
-		}
-		names = append(names, name)
-	}
-
-	// Sort names (simple alphabetical sort)
-	for i := 0; i < len(names); i++ {
-		for j := i + 1; j < len(names); j++ {
-			if names[i] > names[j] {
-				names[i], names[j] = names[j], names[i]
-			}
-		}
-	}
+	names := listNames(target, showHidden)
 
 	if longFormat {
 		// Long format listing
 		for _, name := range names {
-			file := target.Children[name]
-			var fileType string
-			if file.Type == Directory {
-				fileType = "d"
-			} else {
-				fileType = "-"
-			}
-
-			permissions := fmt.Sprintf("%o", file.Permissions)
-			if len(permissions) > 3 {
-				permissions = permissions[len(permissions)-3:]
-			}
-
-			size := file.Size
-			timeStr := file.ModTime.Format("Jan 02 15:04")
-
-			fmt.Printf("%s%s%s %8d %s %s\n", fileType, permissions, "rwxrwxrwx", size, timeStr, name)
+			fmt.Println(longLine(target.Children[name], name))
 		}
 	} else {
 		// Simple listing
@@ -975,10 +956,43 @@ func (t *Terminal) Ls(args []string) {
 	}
 }
 
+// longLine formats one ls -l entry for file under the given name
+func longLine(file *VirtualFile, name string) string {
+	var fileType string
+	if file.Type == Directory {
+		fileType = "d"
+	} else {
+		fileType = "-"
+	}
+
+	permissions := fmt.Sprintf("%o", file.Permissions)
+	if len(permissions) > 3 {
+		permissions = permissions[len(permissions)-3:]
+	}
+
+	size := file.Size
+	timeStr := file.ModTime.Format("Jan 02 15:04")
+
+	return fmt.Sprintf("%s%s%s %8d %s %s", fileType, permissions, "rwxrwxrwx", size, timeStr, name)
+}
+
+// listNames returns the sorted names of a directory's children, skipping hidden ones unless showHidden
+func listNames(dir *VirtualFile, showHidden bool) []string {
+	names := make([]string, 0, len(dir.Children))
+	for name := range dir.Children {
+		if !showHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Cat displays file contents
 func (t *Terminal) Cat(args []string) {
 	if len(args) == 0 {
-		fmt.Println("cat: missing file operand")
+		fmt.Println(usage("cat"))
 		return
 	}
 
@@ -1003,8 +1017,25 @@ func (t *Terminal) Cat(args []string) {
 
 // Echo displays text or writes it to a file with redirection
 func (t *Terminal) Echo(args []string) {
+	// Leading -n and -e options, alone or combined as in -ne
+	noNewline, escapes := false, false
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' && strings.Trim(args[0][1:], "ne") == "" {
+		noNewline = noNewline || strings.Contains(args[0], "n")
+		escapes = escapes || strings.Contains(args[0], "e")
+		args = args[1:]
+	}
+	render := func(text string) string {
+		if escapes {
+			text = expandEscapes(text)
+		}
+		if !noNewline {
+			text += "\n"
+		}
+		return text
+	}
+
 	if len(args) == 0 {
-		fmt.Println()
+		fmt.Print(render(""))
 		return
 	}
 
@@ -1028,7 +1059,7 @@ func (t *Terminal) Echo(args []string) {
 
 	if redirectOp == "" {
 		// No redirection, just print the text
-		fmt.Println(strings.Join(args, " "))
+		fmt.Print(render(strings.Join(args, " ")))
 		return
 	}
 
@@ -1079,24 +1110,49 @@ func (t *Terminal) Echo(args []string) {
 	var content []byte
 	if redirectOp == ">" {
 		// Overwrite
-		content = []byte(text)
+		content = []byte(render(text))
 	} else if redirectOp == ">>" {
 		// Append
-		content = append(file.Content, []byte(text)...)
+		content = append(file.Content, []byte(render(text))...)
 	}
 
 	file.UpdateContent(content)
 }
 
+// expandEscapes interprets \n, \t and \\ in text for echo -e, leaving any other backslash as it is
+func expandEscapes(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			switch text[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				sb.WriteByte('\t')
+				i++
+				continue
+			case '\\':
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(text[i])
+	}
+	return sb.String()
+}
+
 // Edit opens a simple text editor for a file
 func (t *Terminal) Edit(args []string) {
 	if len(args) == 0 {
-		fmt.Println("edit: missing file operand")
+		fmt.Println(usage("edit"))
 		return
 	}
 
 	if len(args) > 1 {
-		fmt.Println("edit: too many arguments")
+		fmt.Println(usage("edit"))
 		return
 	}
 
@@ -1159,7 +1215,7 @@ func (t *Terminal) runEditor(file *VirtualFile) {
 	// Editor loop
 	for {
 		// Display file contents with line numbers
-		fmt.Println("\n--- Editor: %s (Type :w to save, :q to quit, :wq to save and quit) ---", file.Name)
+		fmt.Printf("\n--- Editor: %s (Type :w to save, :q to quit, :wq to save and quit) ---\n", file.Name)
 		for i, line := range lines {
 			fmt.Printf("%3d | %s\n", i+1, line)
 		}
@@ -1259,7 +1315,7 @@ func (t *Terminal) runEditor(file *VirtualFile) {
 // Clear clears the terminal screen
 func (t *Terminal) Clear(args []string) {
 	if len(args) > 0 {
-		fmt.Println("clear: too many arguments")
+		fmt.Println(usage("clear"))
 		return
 	}
 
@@ -1270,17 +1326,39 @@ func (t *Terminal) Clear(args []string) {
 // Exit exits the terminal emulator
 func (t *Terminal) Exit(args []string) {
 	if len(args) > 0 {
-		fmt.Println("exit: too many arguments")
+		fmt.Println(usage("exit"))
 		return
 	}
 
 	t.Running = false
 }
 
+// usages holds the synopsis of each command, quoted when it is given the wrong arguments
+var usages = map[string]string{
+	"pwd":   "pwd",
+	"cd":    "cd [DIR]",
+	"touch": "touch FILE...",
+	"rm":    "rm [-r] [-d] PATH...",
+	"cp":    "cp [-r] SOURCE DEST",
+	"mv":    "mv SOURCE DEST",
+	"mkdir": "mkdir [-p] DIR...",
+	"rmdir": "rmdir DIR...",
+	"cat":   "cat FILE...",
+	"edit":  "edit FILE",
+	"clear": "clear",
+	"exit":  "exit",
+	"help":  "help",
+}
+
+// usage returns the message printed when cmd is given the wrong arguments
+func usage(cmd string) string {
+	return cmd + ": usage: " + usages[cmd]
+}
+
 // Help displays available commands
 func (t *Terminal) Help(args []string) {
 	if len(args) > 0 {
-		fmt.Println("help: too many arguments")
+		fmt.Println(usage("help"))
 		return
 	}
 
@@ -1289,12 +1367,14 @@ func (t *Terminal) Help(args []string) {
 	fmt.Println("  cd [path]        - Change directory")
 	fmt.Println("  touch [file]     - Create empty file")
 	fmt.Println("  rm [-r] [file]   - Remove file or directory")
+	fmt.Println("  rm -d [dir]      - Remove empty directory")
 	fmt.Println("  cp [-r] [src] [dest] - Copy file or directory")
 	fmt.Println("  mv [src] [dest]  - Move/rename file or directory")
 	fmt.Println("  mkdir [-p] [dir] - Create directory")
 	fmt.Println("  rmdir [dir]      - Remove empty directory")
 	fmt.Println("  ls [-l] [-a] [path] - List directory contents")
 	fmt.Println("  cat [file]       - Display file contents")
+	fmt.Println("  echo [-n] [-e] [text] - Display text; -n omits the newline, -e expands \\n \\t \\\\")
 	fmt.Println("  echo [text] > [file] - Write text to file")
 	fmt.Println("  echo [text] >> [file] - Append text to file")
 	fmt.Println("  edit [file]      - Edit file with simple text editor")
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -261,6 +265,19 @@ func TestFileSystemResolvePath(t *testing.T) {
 	}
 }
 
+func TestFileSystemResolveRelativePath(t *testing.T) {
+	fs := NewFileSystem()
+	fs.CurrentDir = fs.Root
+
+	user, err := fs.ResolvePath("home/user")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user.Name != "user" || user.Parent.Name != "home" {
+		t.Errorf("Should resolve home/user relative to the root, got %s", user.GetPath())
+	}
+}
+
 func TestTerminalParseCommand(t *testing.T) {
 	terminal := NewTerminal()
 
@@ -330,6 +347,35 @@ func TestTerminalPwd(t *testing.T) {
 	}
 }
 
+func TestUsageErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"pwd extra", "pwd: usage: pwd\n"},
+		{"cd a b", "cd: usage: cd [DIR]\n"},
+		{"touch", "touch: usage: touch FILE...\n"},
+		{"rm -r", "rm: usage: rm [-r] [-d] PATH...\n"},
+		{"cp a", "cp: usage: cp [-r] SOURCE DEST\n"},
+		{"mv a", "mv: usage: mv SOURCE DEST\n"},
+		{"mkdir -p", "mkdir: usage: mkdir [-p] DIR...\n"},
+		{"rmdir", "rmdir: usage: rmdir DIR...\n"},
+		{"cat", "cat: usage: cat FILE...\n"},
+		{"edit a b", "edit: usage: edit FILE\n"},
+		{"clear screen", "clear: usage: clear\n"},
+		{"help me", "help: usage: help\n"},
+	}
+	for _, tt := range tests {
+		terminal := NewTerminal()
+		output := captureStdout(func() {
+			terminal.ExecuteCommand(tt.input)
+		})
+		if output != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.want, output)
+		}
+	}
+}
+
 func TestTerminalCd(t *testing.T) {
 	terminal := NewTerminal()
 
@@ -379,6 +425,26 @@ func TestTerminalCd(t *testing.T) {
 	}
 }
 
+func TestTerminalMkdirMultiple(t *testing.T) {
+	terminal := NewTerminal()
+	terminal.Mkdir([]string{"exists"})
+
+	output := captureStdout(func() {
+		terminal.Mkdir([]string{"one", "exists", "missing/sub", "two"})
+	})
+	for _, name := range []string{"one", "two"} {
+		if dir, exists := terminal.FS.CurrentDir.Children[name]; !exists || dir.Type != Directory {
+			t.Errorf("mkdir should still create %s", name)
+		}
+	}
+	if !strings.Contains(output, "mkdir: cannot create directory 'exists': File exists") {
+		t.Errorf("Expected an error for the existing directory, got %q", output)
+	}
+	if strings.Count(output, "\n") != 2 {
+		t.Errorf("Expected one error for each failed path, got %q", output)
+	}
+}
+
 func TestTerminalTouch(t *testing.T) {
 	terminal := NewTerminal()
 
@@ -434,6 +500,80 @@ func TestTerminalMkdir(t *testing.T) {
 	}
 }
 
+func TestTerminalTouchMultiple(t *testing.T) {
+	terminal := NewTerminal()
+	terminal.Touch([]string{"plain.txt"})
+
+	output := captureStdout(func() {
+		terminal.Touch([]string{"a.txt", "missing/b.txt", "plain.txt/c.txt", "d.txt", "/top.txt"})
+	})
+	for _, path := range []string{"a.txt", "d.txt", "/top.txt"} {
+		if _, err := terminal.FS.ResolvePath(path); err != nil {
+			t.Errorf("touch should still create %s: %v", path, err)
+		}
+	}
+	if _, exists := terminal.FS.CurrentDir.Children["/top.txt"]; exists {
+		t.Error("touch /top.txt should create the file in the root, not the current directory")
+	}
+	if !strings.Contains(output, "touch: cannot touch 'missing/b.txt'") || !strings.Contains(output, "touch: cannot touch 'plain.txt/c.txt': Not a directory") {
+		t.Errorf("Expected an error for each failed path, got %q", output)
+	}
+	if strings.Count(output, "\n") != 2 {
+		t.Errorf("Expected exactly two errors, got %q", output)
+	}
+}
+
+func TestTerminalRmDirectory(t *testing.T) {
+	terminal := NewTerminal()
+	terminal.Mkdir([]string{"empty"})
+	terminal.Mkdir([]string{"full"})
+	terminal.Touch([]string{"full/file.txt"})
+
+	for _, name := range []string{"empty", "full"} {
+		output := captureStdout(func() {
+			terminal.Rm([]string{name})
+		})
+		if output != "rm: cannot remove '"+name+"': Is a directory\n" {
+			t.Errorf("rm %s without -r should error, got %q", name, output)
+		}
+		if _, exists := terminal.FS.CurrentDir.Children[name]; !exists {
+			t.Errorf("rm %s without -r should leave the directory", name)
+		}
+	}
+
+	terminal.Rm([]string{"-r", "empty", "full"})
+	if len(terminal.FS.CurrentDir.Children) != 0 {
+		t.Errorf("rm -r should remove both directories, left %d entries", len(terminal.FS.CurrentDir.Children))
+	}
+}
+
+func TestTerminalRmEmptyDirectory(t *testing.T) {
+	terminal := NewTerminal()
+	terminal.Mkdir([]string{"empty"})
+	terminal.Mkdir([]string{"full"})
+	terminal.Touch([]string{"full/file.txt"})
+
+	output := captureStdout(func() {
+		terminal.Rm([]string{"-d", "empty"})
+	})
+	if output != "" {
+		t.Errorf("rm -d on an empty directory should succeed, got %q", output)
+	}
+	if _, exists := terminal.FS.CurrentDir.Children["empty"]; exists {
+		t.Error("rm -d should remove the empty directory")
+	}
+
+	output = captureStdout(func() {
+		terminal.Rm([]string{"-d", "full"})
+	})
+	if output != "rm: cannot remove 'full': Directory not empty\n" {
+		t.Errorf("rm -d on a non-empty directory should error, got %q", output)
+	}
+	if _, exists := terminal.FS.CurrentDir.Children["full"]; !exists {
+		t.Error("rm -d should leave the non-empty directory")
+	}
+}
+
 func TestTerminalLs(t *testing.T) {
 	terminal := NewTerminal()
 
@@ -479,6 +619,84 @@ func TestTerminalLs(t *testing.T) {
 	}
 }
 
+func TestTerminalLsFileLong(t *testing.T) {
+	terminal := NewTerminal()
+	terminal.Echo([]string{"hello", ">", "notes.txt"})
+	file, _ := terminal.FS.ResolvePath("notes.txt")
+
+	output := captureStdout(func() {
+		terminal.Ls([]string{"notes.txt"})
+	})
+	if output != "notes.txt\n" {
+		t.Errorf("ls FILE should print the file name, got %q", output)
+	}
+
+	output = captureStdout(func() {
+		terminal.Ls([]string{"-l", "notes.txt"})
+	})
+	expected := fmt.Sprintf("-644rwxrwxrwx %8d %s notes.txt\n", file.Size, file.ModTime.Format("Jan 02 15:04"))
+	if output != expected {
+		t.Errorf("ls -l FILE should show permissions, size and time, got %q, want %q", output, expected)
+	}
+	if file.Size == 0 {
+		t.Error("Expected echo to give the file a size")
+	}
+}
+
+func TestListNamesSorted(t *testing.T) {
+	dir := NewVirtualFile("dir", Directory)
+	for _, name := range []string{"zeta", "Alpha", "beta", ".hidden", "alpha", "10", "2"} {
+		dir.AddChild(NewVirtualFile(name, RegularFile))
+	}
+
+	expected := []string{"10", "2", "Alpha", "alpha", "beta", "zeta"}
+	names := listNames(dir, false)
+	if strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	names = listNames(dir, true)
+	if len(names) != 7 || names[0] != ".hidden" {
+		t.Errorf("Expected .hidden first with -a, got %v", names)
+	}
+}
+
+// largeDirectory returns a directory with n children added in reverse order
+func largeDirectory(n int) *VirtualFile {
+	dir := NewVirtualFile("dir", Directory)
+	for i := n; i > 0; i-- {
+		dir.AddChild(NewVirtualFile(fmt.Sprintf("file%05d", i), RegularFile))
+	}
+	return dir
+}
+
+func BenchmarkListNames(b *testing.B) {
+	dir := largeDirectory(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		listNames(dir, false)
+	}
+}
+
+// BenchmarkListNamesBubbleSort measures the nested-loop sort listNames replaced, for comparison
+func BenchmarkListNamesBubbleSort(b *testing.B) {
+	dir := largeDirectory(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var names []string
+		for name := range dir.Children {
+			names = append(names, name)
+		}
+		for i := 0; i < len(names); i++ {
+			for j := i + 1; j < len(names); j++ {
+				if names[i] > names[j] {
+					names[i], names[j] = names[j], names[i]
+				}
+			}
+		}
+	}
+}
+
 func TestTerminalCat(t *testing.T) {
 	terminal := NewTerminal()
 
@@ -515,6 +733,38 @@ func TestTerminalCat(t *testing.T) {
 	}
 }
 
+func TestTerminalCatConcatenation(t *testing.T) {
+	terminal := NewTerminal()
+	for name, content := range map[string]string{
+		"a.txt": "first\n",
+		"b.txt": "no newline",
+		"c.txt": "last\n\n",
+		"e.txt": "",
+	} {
+		file := NewVirtualFile(name, RegularFile)
+		file.UpdateContent([]byte(content))
+		terminal.FS.CurrentDir.AddChild(file)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"a.txt", "b.txt"}, "first\nno newline"},
+		{[]string{"b.txt", "a.txt"}, "no newlinefirst\n"},
+		{[]string{"b.txt", "e.txt", "c.txt"}, "no newlinelast\n\n"},
+		{[]string{"a.txt", "a.txt"}, "first\nfirst\n"},
+	}
+	for _, tt := range tests {
+		output := captureStdout(func() {
+			terminal.Cat(tt.args)
+		})
+		if output != tt.expected {
+			t.Errorf("cat %v: expected %q, got %q", tt.args, tt.expected, output)
+		}
+	}
+}
+
 func TestTerminalEcho(t *testing.T) {
 	terminal := NewTerminal()
 
@@ -530,14 +780,50 @@ func TestTerminalEcho(t *testing.T) {
 	// Test echo with redirection
 	terminal.Echo([]string{"Hello", "World", ">", "test.txt"})
 	file := terminal.FS.CurrentDir.Children["test.txt"]
-	if string(file.Content) != "Hello World" {
-		t.Errorf("Expected file content 'Hello World', got '%s'", string(file.Content))
+	if string(file.Content) != "Hello World\n" {
+		t.Errorf("Expected file content 'Hello World\\n', got '%s'", string(file.Content))
 	}
 
 	// Test echo with append
 	terminal.Echo([]string{"Appended", ">>", "test.txt"})
-	if string(file.Content) != "Hello WorldAppended" {
-		t.Errorf("Expected file content 'Hello WorldAppended', got '%s'", string(file.Content))
+	if string(file.Content) != "Hello World\nAppended\n" {
+		t.Errorf("Expected file content 'Hello World\\nAppended\\n', got '%s'", string(file.Content))
+	}
+}
+
+func TestTerminalEchoFlags(t *testing.T) {
+	terminal := NewTerminal()
+
+	// -n writes exactly the text
+	terminal.Echo([]string{"-n", "hi", ">", "n.txt"})
+	terminal.Echo([]string{"-n", "there", ">>", "n.txt"})
+	if content := string(terminal.FS.CurrentDir.Children["n.txt"].Content); content != "hithere" {
+		t.Errorf("Expected 'hithere' without newlines, got %q", content)
+	}
+
+	terminal.Echo([]string{"-e", `a\tb\nc\\d`, ">", "e.txt"})
+	if content := string(terminal.FS.CurrentDir.Children["e.txt"].Content); content != "a\tb\nc\\d\n" {
+		t.Errorf("Expected expanded escapes, got %q", content)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-n", "prompt"}, "prompt"},
+		{[]string{"-e", `tab\there`}, "tab\there\n"},
+		{[]string{`tab\there`}, "tab\\there\n"},
+		{[]string{"-ne", `x\n`}, "x\n"},
+		{[]string{"-e", `keep \q`}, "keep \\q\n"},
+		{[]string{"-x", "-n"}, "-x -n\n"},
+	}
+	for _, tt := range tests {
+		output := captureStdout(func() {
+			terminal.Echo(tt.args)
+		})
+		if output != tt.expected {
+			t.Errorf("echo %v: expected %q, got %q", tt.args, tt.expected, output)
+		}
 	}
 }
 
@@ -586,3 +872,25 @@ func captureOutput(f func()) string {
 	f()
 	return ""
 }
+
+// captureStdout runs f with stdout redirected to a pipe and returns what it printed
+func captureStdout(f func()) string {
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+
+	f()
+	w.Close()
+	return <-done
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -47,10 +48,29 @@ func (t *Terminal) ExecuteCommand(cmd *ParsedCommand) *CommandResult {
 	}
 }
 
+// usages holds the synopsis of each command, quoted when it is given the wrong arguments
+var usages = map[string]string{
+	"pwd":   "pwd",
+	"cd":    "cd [DIR]",
+	"mkdir": "mkdir [-p] DIR...",
+	"rmdir": "rmdir DIR...",
+	"touch": "touch FILE...",
+	"rm":    "rm [-r] [-d] PATH...",
+	"cp":    "cp [-r] SOURCE DEST",
+	"mv":    "mv SOURCE DEST",
+	"cat":   "cat FILE...",
+	"edit":  "edit FILE",
+}
+
+// usageError reports that cmd was given the wrong arguments, quoting its synopsis
+func usageError(cmd string) error {
+	return fmt.Errorf("%s: usage: %s", cmd, usages[cmd])
+}
+
 // cmdPwd implements the pwd command
 func (t *Terminal) cmdPwd(args []string) *CommandResult {
 	if len(args) > 0 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("pwd: too many arguments"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("pwd"), Exit: false}
 	}
 	return &CommandResult{Output: t.FS.GetPath(t.FS.CurrentDir), Error: nil, Exit: false}
 }
@@ -63,7 +83,7 @@ func (t *Terminal) cmdCd(args []string) *CommandResult {
 	} else if len(args) == 1 {
 		path = args[0]
 	} else {
-		return &CommandResult{Output: "", Error: fmt.Errorf("cd: too many arguments"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("cd"), Exit: false}
 	}
 
 	var target *VirtualFile
@@ -180,7 +200,7 @@ func (t *Terminal) formatFileLong(file *VirtualFile) string {
 // cmdMkdir implements the mkdir command
 func (t *Terminal) cmdMkdir(args []string) *CommandResult {
 	if len(args) == 0 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("mkdir: missing operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("mkdir"), Exit: false}
 	}
 
 	createParents := false
@@ -194,15 +214,19 @@ func (t *Terminal) cmdMkdir(args []string) *CommandResult {
 			paths = append(paths, arg)
 		}
 	}
+	if len(paths) == 0 {
+		return &CommandResult{Output: "", Error: usageError("mkdir"), Exit: false}
+	}
 
+	// A path that fails is reported without stopping the others
+	var errs []error
 	for _, path := range paths {
-		err := t.createDirectory(path, createParents)
-		if err != nil {
-			return &CommandResult{Output: "", Error: err, Exit: false}
+		if err := t.createDirectory(path, createParents); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
-	return &CommandResult{Output: "", Error: nil, Exit: false}
+	return &CommandResult{Output: "", Error: errors.Join(errs...), Exit: false}
 }
 
 // createDirectory creates a directory at the given path
@@ -262,7 +286,7 @@ func (t *Terminal) getBaseName(path string) string {
 // cmdRmdir implements the rmdir command
 func (t *Terminal) cmdRmdir(args []string) *CommandResult {
 	if len(args) == 0 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("rmdir: missing operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("rmdir"), Exit: false}
 	}
 
 	for _, path := range args {
@@ -289,9 +313,11 @@ func (t *Terminal) cmdRmdir(args []string) *CommandResult {
 // cmdTouch implements the touch command
 func (t *Terminal) cmdTouch(args []string) *CommandResult {
 	if len(args) == 0 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("touch: missing file operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("touch"), Exit: false}
 	}
 
+	// A path that fails is reported without stopping the others
+	var errs []error
 	for _, path := range args {
 		// Check if file already exists
 		if file, err := t.FS.ResolvePath(path); err == nil {
@@ -304,10 +330,12 @@ func (t *Terminal) cmdTouch(args []string) *CommandResult {
 		parentPath := t.getParentPath(path)
 		parent, err := t.FS.ResolvePath(parentPath)
 		if err != nil {
-			return &CommandResult{Output: "", Error: err, Exit: false}
+			errs = append(errs, fmt.Errorf("touch: cannot touch '%s': %v", path, err))
+			continue
 		}
 		if parent.Type != Directory {
-			return &CommandResult{Output: "", Error: fmt.Errorf("touch: cannot touch '%s': Not a directory", path), Exit: false}
+			errs = append(errs, fmt.Errorf("touch: cannot touch '%s': Not a directory", path))
+			continue
 		}
 
 		fileName := t.getBaseName(path)
@@ -323,26 +351,32 @@ func (t *Terminal) cmdTouch(args []string) *CommandResult {
 		parent.Children[fileName] = newFile
 	}
 
-	return &CommandResult{Output: "", Error: nil, Exit: false}
+	return &CommandResult{Output: "", Error: errors.Join(errs...), Exit: false}
 }
 
 // cmdRm implements the rm command
 func (t *Terminal) cmdRm(args []string) *CommandResult {
 	if len(args) == 0 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("rm: missing operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("rm"), Exit: false}
 	}
 
 	recursive := false
+	emptyDirs := false
 	var paths []string
 
 	// Parse arguments
 	for _, arg := range args {
 		if arg == "-r" || arg == "-rf" {
 			recursive = true
+		} else if arg == "-d" {
+			emptyDirs = true
 		} else {
 			paths = append(paths, arg)
 		}
 	}
+	if len(paths) == 0 {
+		return &CommandResult{Output: "", Error: usageError("rm"), Exit: false}
+	}
 
 	for _, path := range paths {
 		target, err := t.FS.ResolvePath(path)
@@ -351,7 +385,12 @@ func (t *Terminal) cmdRm(args []string) *CommandResult {
 		}
 
 		if target.Type == Directory && !recursive {
-			return &CommandResult{Output: "", Error: fmt.Errorf("rm: cannot remove '%s': Is a directory", path), Exit: false}
+			if !emptyDirs {
+				return &CommandResult{Output: "", Error: fmt.Errorf("rm: cannot remove '%s': Is a directory", path), Exit: false}
+			}
+			if len(target.Children) > 0 {
+				return &CommandResult{Output: "", Error: fmt.Errorf("rm: cannot remove '%s': Directory not empty", path), Exit: false}
+			}
 		}
 
 		// Remove from parent
@@ -364,7 +403,7 @@ func (t *Terminal) cmdRm(args []string) *CommandResult {
 // cmdCp implements the cp command
 func (t *Terminal) cmdCp(args []string) *CommandResult {
 	if len(args) < 2 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("cp: missing file operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("cp"), Exit: false}
 	}
 
 	recursive := false
@@ -414,7 +453,7 @@ func (t *Terminal) cmdCp(args []string) *CommandResult {
 // cmdMv implements the mv command
 func (t *Terminal) cmdMv(args []string) *CommandResult {
 	if len(args) != 2 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("mv: missing file operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("mv"), Exit: false}
 	}
 
 	source := args[0]
@@ -447,7 +486,7 @@ func (t *Terminal) cmdMv(args []string) *CommandResult {
 // cmdCat implements the cat command
 func (t *Terminal) cmdCat(args []string) *CommandResult {
 	if len(args) == 0 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("cat: missing file operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("cat"), Exit: false}
 	}
 
 	var output strings.Builder
@@ -461,10 +500,8 @@ func (t *Terminal) cmdCat(args []string) *CommandResult {
 			return &CommandResult{Output: "", Error: fmt.Errorf("cat: %s: Is a directory", path), Exit: false}
 		}
 
+		// Each file exactly as stored, so a missing final newline stays missing
 		output.Write(file.Content)
-		if len(args) > 1 {
-			output.WriteString("\n")
-		}
 	}
 
 	return &CommandResult{Output: output.String(), Error: nil, Exit: false}
@@ -472,21 +509,105 @@ func (t *Terminal) cmdCat(args []string) *CommandResult {
 
 // cmdEcho implements the echo command
 func (t *Terminal) cmdEcho(args []string) *CommandResult {
-	if len(args) == 0 {
-		return &CommandResult{Output: "\n", Error: nil, Exit: false}
+	// Leading -n and -e options, alone or combined as in -ne
+	noNewline, escapes := false, false
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' && strings.Trim(args[0][1:], "ne") == "" {
+		noNewline = noNewline || strings.Contains(args[0], "n")
+		escapes = escapes || strings.Contains(args[0], "e")
+		args = args[1:]
 	}
 
 	// Check for redirection
-	output := strings.Join(args, " ") + "\n"
+	var words []string
+	redirectOp, redirectFile := "", ""
+	for i := 0; i < len(args); i++ {
+		if args[i] != ">" && args[i] != ">>" {
+			words = append(words, args[i])
+			continue
+		}
+		if i+1 == len(args) {
+			return &CommandResult{Output: "", Error: fmt.Errorf("echo: syntax error near unexpected token 'newline'"), Exit: false}
+		}
+		redirectOp, redirectFile = args[i], args[i+1]
+		i++
+	}
 
-	// Simple implementation - no redirection yet
-	return &CommandResult{Output: output, Error: nil, Exit: false}
+	output := strings.Join(words, " ")
+	if escapes {
+		output = expandEscapes(output)
+	}
+	if !noNewline {
+		output += "\n"
+	}
+	if redirectOp == "" {
+		return &CommandResult{Output: output, Error: nil, Exit: false}
+	}
+	return &CommandResult{Output: "", Error: t.writeFile(redirectFile, []byte(output), redirectOp == ">>"), Exit: false}
+}
+
+// expandEscapes interprets \n, \t and \\ in text for echo -e, leaving any other backslash as it is
+func expandEscapes(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			switch text[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				sb.WriteByte('\t')
+				i++
+				continue
+			case '\\':
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(text[i])
+	}
+	return sb.String()
+}
+
+// writeFile writes content to the file at path, or appends it when appendMode, creating the file if needed
+func (t *Terminal) writeFile(path string, content []byte, appendMode bool) error {
+	file, err := t.FS.ResolvePath(path)
+	if err != nil {
+		parent, err := t.FS.ResolvePath(t.getParentPath(path))
+		if err != nil {
+			return err
+		}
+		if parent.Type != Directory {
+			return fmt.Errorf("echo: %s: Not a directory", path)
+		}
+
+		fileName := t.getBaseName(path)
+		file = &VirtualFile{
+			Name:        fileName,
+			Type:        RegularFile,
+			Content:     []byte{},
+			Parent:      parent,
+			Permissions: 0644,
+		}
+		parent.Children[fileName] = file
+	} else if file.Type != RegularFile {
+		return fmt.Errorf("echo: %s: Is a directory", path)
+	}
+
+	if appendMode {
+		content = append(file.Content[:len(file.Content):len(file.Content)], content...)
+	}
+	file.Content = content
+	file.Size = int64(len(content))
+	file.ModTime = time.Now()
+	return nil
 }
 
 // cmdEdit implements the edit command
 func (t *Terminal) cmdEdit(args []string) *CommandResult {
 	if len(args) != 1 {
-		return &CommandResult{Output: "", Error: fmt.Errorf("edit: missing file operand"), Exit: false}
+		return &CommandResult{Output: "", Error: usageError("edit"), Exit: false}
 	}
 
 	path := args[0]
@@ -551,10 +672,13 @@ mkdir [-p] dir   - Create directory
 rmdir dir        - Remove empty directory
 touch file       - Create empty file or update timestamp
 rm [-r] file     - Remove file or directory
+rm -d dir        - Remove empty directory
 cp [-r] src dst  - Copy file or directory
 mv src dst       - Move/rename file or directory
 cat file         - Display file contents
-echo [text]      - Display text
+echo [-n] [-e] [text] [> file | >> file]
+                 - Display text, or write or append it to a file;
+                   -n omits the newline, -e expands \\n \\t \\\\
 edit file        - Simple text editor
 clear            - Clear terminal screen
 exit/quit        - Exit terminal
@@ -205,6 +205,9 @@ func (fs *FileSystem) MkDir(path string, parents bool) error {
 			if !child.IsDir() {
 				return fmt.Errorf("%s: Not a directory", part)
 			}
+			if i == len(parts)-1 && !parents {
+				return fmt.Errorf("cannot create directory %s: File exists", part)
+			}
 			current = child
 		}
 	}
@@ -223,6 +226,11 @@ func (fs *FileSystem) Cat(path string) ([]byte, error) {
 }
 
 func (fs *FileSystem) Echo(text, path string, appendMode bool) error {
+	return fs.WriteFile(path, []byte(text+"\n"), appendMode)
+}
+
+// WriteFile writes content to the file at path, or appends it when appendMode, creating the file if needed
+func (fs *FileSystem) WriteFile(path string, content []byte, appendMode bool) error {
 	file, err := fs.resolvePath(path)
 	if err != nil {
 		// Create new file
@@ -250,16 +258,16 @@ func (fs *FileSystem) Echo(text, path string, appendMode bool) error {
 	}
 
 	if appendMode {
-		file.Content = append(file.Content, []byte(text+"\n")...)
+		file.Content = append(file.Content, content...)
 	} else {
-		file.Content = []byte(text + "\n")
+		file.Content = content
 	}
 	file.ModTime = time.Now()
 	file.Size = int64(len(file.Content))
 	return nil
 }
 
-func (fs *FileSystem) Rm(path string, recursive bool) error {
+func (fs *FileSystem) Rm(path string, recursive, emptyDir bool) error {
 	target, err := fs.resolvePath(path)
 	if err != nil {
 		return err
@@ -273,15 +281,17 @@ func (fs *FileSystem) Rm(path string, recursive bool) error {
 	filename := target.Name
 
 	if target.IsDir() {
-		if !recursive {
-			if len(target.Children) > 0 {
-				return fmt.Errorf("%s: is a directory and not empty", path)
-			}
+		// A directory needs the recursive flag, or emptyDir when it has no children
+		if !recursive && !emptyDir {
+			return fmt.Errorf("%s: Is a directory", path)
+		}
+		if !recursive && len(target.Children) > 0 {
+			return fmt.Errorf("%s: Directory not empty", path)
 		}
 		// Remove directory recursively
 		for _, child := range target.Children {
 			if child.IsDir() {
-				fs.Rm(child.Name, true)
+				fs.Rm(child.Name, true, false)
 			} else {
 				delete(target.Children, child.Name)
 			}
@@ -293,7 +303,14 @@ func (fs *FileSystem) Rm(path string, recursive bool) error {
 }
 
 func (fs *FileSystem) RmDir(path string) error {
-	return fs.Rm(path, false)
+	target, err := fs.resolvePath(path)
+	if err != nil {
+		return err
+	}
+	if !target.IsDir() {
+		return fmt.Errorf("%s: Not a directory", path)
+	}
+	return fs.Rm(path, false, true)
 }
 
 func (fs *FileSystem) Copy(src, dest string, recursive bool) error {
@@ -408,7 +425,11 @@ func (fs *FileSystem) Ls(path string, flags map[string]bool) (string, error) {
 		return "", err
 	}
 	if !dir.IsDir() {
-		return "", fmt.Errorf("%s is not a directory", path)
+		// A file lists as itself, under the name it was given
+		if flags["l"] {
+			return longEntry(dir, path), nil
+		}
+		return path + "\n", nil
 	}
 
 	var output strings.Builder
@@ -423,20 +444,23 @@ func (fs *FileSystem) Ls(path string, flags map[string]bool) (string, error) {
 
 	showLong := flags["l"]
 	for _, name := range names {
-		file := dir.Children[name]
 		if showLong {
-			// Simple long format
-			perm := "-rw-r--r--"
-			if file.IsDir() {
-				perm = "drwxr-xr-x"
-			}
-			sizeStr := strconv.Itoa(int(file.Size))
-			timeStr := file.ModTime.Format("Jan 02 15:04")
-			output.WriteString(fmt.Sprintf("%s 1 user user %s %s %s\n",
-				perm, sizeStr, timeStr, name))
+			output.WriteString(longEntry(dir.Children[name], name))
 		} else {
 			output.WriteString(name + "\n")
 		}
 	}
 	return output.String(), nil
 }
+
+// longEntry formats one line of ls -l output for file under the given name
+func longEntry(file *VirtualFile, name string) string {
+	// Simple long format
+	perm := "-rw-r--r--"
+	if file.IsDir() {
+		perm = "drwxr-xr-x"
+	}
+	sizeStr := strconv.Itoa(int(file.Size))
+	timeStr := file.ModTime.Format("Jan 02 15:04")
+	return fmt.Sprintf("%s 1 user user %s %s %s\n", perm, sizeStr, timeStr, name)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -83,6 +84,24 @@ func (t *Terminal) prompt() string {
 	return fmt.Sprintf("%s$ ", path)
 }
 
+// usages holds the synopsis of each command, quoted when it is given the wrong arguments
+var usages = map[string]string{
+	"cd":    "cd [DIR]",
+	"touch": "touch FILE...",
+	"mkdir": "mkdir [-p] DIR...",
+	"cat":   "cat FILE...",
+	"rm":    "rm [-r] [-d] PATH",
+	"rmdir": "rmdir DIR",
+	"cp":    "cp [-r] SOURCE DEST",
+	"mv":    "mv SOURCE DEST",
+	"edit":  "edit FILE",
+}
+
+// usageError reports that cmd was given the wrong arguments, quoting its synopsis
+func usageError(cmd string) error {
+	return fmt.Errorf("%s: usage: %s", cmd, usages[cmd])
+}
+
 func executeCommand(fs *fs.FileSystem, cmd string) (string, error) {
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
@@ -95,101 +114,127 @@ func executeCommand(fs *fs.FileSystem, cmd string) (string, error) {
 	case "pwd":
 		return fs.CurrentPath() + "\n", nil
 	case "cd":
+		if len(args) > 1 {
+			return "", usageError("cd")
+		}
 		if len(args) == 0 {
-			return "", fmt.Errorf("cd: missing path")
+			return "", fs.ChangeDir("~")
 		}
 		return "", fs.ChangeDir(args[0])
 	case "ls":
 		return lsCommand(fs, args)
 	case "touch":
 		if len(args) == 0 {
-			return "", fmt.Errorf("touch: missing file name")
+			return "", usageError("touch")
+		}
+		// A path that fails is reported without stopping the others
+		var errs []error
+		for _, path := range args {
+			if err := fs.Touch(path); err != nil {
+				errs = append(errs, fmt.Errorf("touch: %s: %v", path, err))
+			}
 		}
-		return "", fs.Touch(args[0])
+		return "", errors.Join(errs...)
 	case "mkdir":
+		parents := len(args) > 0 && args[0] == "-p"
+		if parents {
+			args = args[1:]
+		}
 		if len(args) == 0 {
-			return "", fmt.Errorf("mkdir: missing directory name")
+			return "", usageError("mkdir")
 		}
-		parents := false
-		path := args[0]
-		if len(args) > 1 && args[0] == "-p" {
-			parents = true
-			path = args[1]
+		// A path that fails is reported without stopping the others
+		var errs []error
+		for _, path := range args {
+			if err := fs.MkDir(path, parents); err != nil {
+				errs = append(errs, fmt.Errorf("mkdir: %s: %v", path, err))
+			}
 		}
-		return "", fs.MkDir(path, parents)
+		return "", errors.Join(errs...)
 	case "cat":
 		if len(args) == 0 {
-			return "", fmt.Errorf("cat: missing file name")
+			return "", usageError("cat")
 		}
-		content, err := fs.Cat(args[0])
-		if err != nil {
-			return "", err
+		// Each file exactly as stored, so a missing final newline stays missing
+		var output []byte
+		for _, path := range args {
+			content, err := fs.Cat(path)
+			if err != nil {
+				return "", err
+			}
+			output = append(output, content...)
 		}
-		return string(content) + "\n", nil
+		return string(output), nil
 	case "echo":
-		if len(args) < 2 {
-			return "", fmt.Errorf("echo: invalid syntax")
-		}
-		// Simple echo with redirection
-		text := strings.Join(args[:len(args)-1], " ")
-		filename := args[len(args)-1]
-		if strings.Contains(text, ">") {
-			parts := strings.SplitN(text, ">", 2)
-			if len(parts) == 2 {
-				text = strings.TrimSpace(parts[0])
-				filename = strings.TrimSpace(parts[1])
-				return "", fs.Echo(text, filename, false)
+		// Leading -n and -e options, alone or combined as in -ne
+		noNewline, escapes := false, false
+		for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' && strings.Trim(args[0][1:], "ne") == "" {
+			noNewline = noNewline || strings.Contains(args[0], "n")
+			escapes = escapes || strings.Contains(args[0], "e")
+			args = args[1:]
+		}
+		// Text up to a > or >> redirection, which takes the next argument as the file
+		var words []string
+		redirect, filename := "", ""
+		for i := 0; i < len(args); i++ {
+			if args[i] != ">" && args[i] != ">>" {
+				words = append(words, args[i])
+				continue
 			}
-		} else if strings.Contains(text, ">>") {
-			parts := strings.SplitN(text, ">>", 2)
-			if len(parts) == 2 {
-				text = strings.TrimSpace(parts[0])
-				filename = strings.TrimSpace(parts[1])
-				return "", fs.Echo(text, filename, true)
+			if i+1 == len(args) {
+				return "", fmt.Errorf("echo: invalid syntax")
 			}
+			redirect, filename = args[i], args[i+1]
+			i++
+		}
+		text := strings.Join(words, " ")
+		if escapes {
+			text = expandEscapes(text)
+		}
+		if !noNewline {
+			text += "\n"
 		}
-		return text + "\n", nil
+		if redirect == "" {
+			return text, nil
+		}
+		return "", fs.WriteFile(filename, []byte(text), redirect == ">>")
 	case "clear":
 		return "\033[2J\033[H", nil
 	case "exit", "quit":
 		return "", nil
 	case "rm":
-		if len(args) == 0 {
-			return "", fmt.Errorf("rm: missing operand")
+		recursive, emptyDir := false, false
+		for len(args) > 0 && (args[0] == "-r" || args[0] == "-d") {
+			recursive = recursive || args[0] == "-r"
+			emptyDir = emptyDir || args[0] == "-d"
+			args = args[1:]
 		}
-		recursive := false
-		path := args[0]
-		if len(args) > 1 && args[0] == "-r" {
-			recursive = true
-			path = args[1]
+		if len(args) != 1 {
+			return "", usageError("rm")
 		}
-		return "", fs.Rm(path, recursive)
+		return "", fs.Rm(args[0], recursive, emptyDir)
 	case "rmdir":
-		if len(args) == 0 {
-			return "", fmt.Errorf("rmdir: missing operand")
+		if len(args) != 1 {
+			return "", usageError("rmdir")
 		}
 		return "", fs.RmDir(args[0])
 	case "cp":
-		if len(args) < 2 {
-			return "", fmt.Errorf("cp: missing destination")
-		}
-		recursive := false
-		src := args[0]
-		dest := args[1]
-		if len(args) > 2 && args[0] == "-r" {
-			recursive = true
-			src = args[1]
-			dest = args[2]
-		}
-		return "", fs.Copy(src, dest, recursive)
+		recursive := len(args) > 0 && args[0] == "-r"
+		if recursive {
+			args = args[1:]
+		}
+		if len(args) != 2 {
+			return "", usageError("cp")
+		}
+		return "", fs.Copy(args[0], args[1], recursive)
 	case "mv":
-		if len(args) < 2 {
-			return "", fmt.Errorf("mv: missing destination")
+		if len(args) != 2 {
+			return "", usageError("mv")
 		}
 		return "", fs.Move(args[0], args[1])
 	case "edit":
-		if len(args) == 0 {
-			return "", fmt.Errorf("edit: missing filename")
+		if len(args) != 1 {
+			return "", usageError("edit")
 		}
 		return editor(fs, args[0])
 	case "help":
@@ -201,9 +246,11 @@ func executeCommand(fs *fs.FileSystem, cmd string) (string, error) {
 - mkdir [-p] [dirname]: Create directory
 - rmdir [dirname]: Remove empty directory
 - rm [-r] [filename]: Remove file or directory
+- rm -d [dirname]: Remove empty directory
 - cp [-r] [source] [dest]: Copy file or directory
 - mv [source] [dest]: Move/rename file or directory
 - cat [filename]: Display file contents
+- echo [-n] [-e] [text]: Print text; -n omits the newline, -e expands \\n \\t \\\\
 - echo [text] > [filename]: Write to file
 - echo [text] >> [filename]: Append to file
 - edit [filename]: Edit file
@@ -216,6 +263,31 @@ func executeCommand(fs *fs.FileSystem, cmd string) (string, error) {
 	}
 }
 
+// expandEscapes interprets \n, \t and \\ in text for echo -e, leaving any other backslash as it is
+func expandEscapes(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			switch text[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				sb.WriteByte('\t')
+				i++
+				continue
+			case '\\':
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(text[i])
+	}
+	return sb.String()
+}
+
 func lsCommand(fs *fs.FileSystem, args []string) (string, error) {
 	path := "."
 	flags := map[string]bool{}
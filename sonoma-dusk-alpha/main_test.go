@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"terminal-emulator/fs"
+)
+
+func TestCatFiles(t *testing.T) {
+	files := fs.NewFileSystem()
+	files.WriteFile("a.txt", []byte("one\n"), false)
+	files.WriteFile("b.txt", []byte("two\n"), false)
+	files.WriteFile("c.txt", []byte("no newline"), false)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"cat a.txt", "one\n"},
+		{"cat a.txt b.txt", "one\ntwo\n"},
+		{"cat c.txt a.txt", "no newlineone\n"},
+		{"cat a.txt c.txt", "one\nno newline"},
+	}
+	for _, tt := range tests {
+		output, err := executeCommand(files, tt.input)
+		if err != nil || output != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.input, tt.expected, output, err)
+		}
+	}
+	if _, err := executeCommand(files, "cat"); err == nil || err.Error() != "cat: usage: cat FILE..." {
+		t.Errorf("cat without a file should print its usage, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package fs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetAlias makes name stand for value at the start of a command
+func (t *Terminal) SetAlias(name, value string) error {
+	if name == "" || strings.ContainsAny(name, " \t'\"\\|&<>=/") {
+		return fmt.Errorf("'%s': invalid alias name", name)
+	}
+	t.shellMu.Lock()
+	defer t.shellMu.Unlock()
+	if t.Aliases == nil {
+		t.Aliases = map[string]string{}
+	}
+	t.Aliases[name] = value
+	return nil
+}
+
+// Alias returns the value of the alias name
+func (t *Terminal) Alias(name string) (string, bool) {
+	t.shellMu.Lock()
+	defer t.shellMu.Unlock()
+	value, ok := t.Aliases[name]
+	return value, ok
+}
+
+// UnsetAlias removes the alias name
+func (t *Terminal) UnsetAlias(name string) error {
+	t.shellMu.Lock()
+	defer t.shellMu.Unlock()
+	if _, ok := t.Aliases[name]; !ok {
+		return fmt.Errorf("%s: not found", name)
+	}
+	delete(t.Aliases, name)
+	return nil
+}
+
+// FormatAlias returns the definition of an alias as alias prints it, quoted so it can be read back
+func FormatAlias(name, value string) string {
+	return fmt.Sprintf("alias %s='%s'", name, strings.ReplaceAll(value, "'", `'\''`))
+}
+
+// ListAliases returns the definitions of every alias, sorted by name
+func (t *Terminal) ListAliases() string {
+	t.shellMu.Lock()
+	defer t.shellMu.Unlock()
+	names := make([]string, 0, len(t.Aliases))
+	for name := range t.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = FormatAlias(name, t.Aliases[name])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// expandAliases replaces the first word of a command with the alias it names, then the first
+// word of the result, and so on. As in a shell an alias is not expanded within itself, so
+// alias ls='ls -a' works.
+func (t *Terminal) expandAliases(text string) string {
+	t.shellMu.Lock()
+	defer t.shellMu.Unlock()
+	seen := map[string]bool{}
+	for {
+		text = strings.TrimLeft(text, " \t")
+		end := strings.IndexAny(text, " \t")
+		if end < 0 {
+			end = len(text)
+		}
+		value, ok := t.Aliases[text[:end]]
+		if !ok || seen[text[:end]] {
+			return text
+		}
+		seen[text[:end]] = true
+		text = value + text[end:]
+	}
+}
+
+// Type describes what name runs at the start of a command: an alias and its value, or a
+// built-in command
+func (t *Terminal) Type(name string) (string, error) {
+	if value, ok := t.Alias(name); ok {
+		return fmt.Sprintf("%s is aliased to `%s'", name, value), nil
+	}
+	if _, ok := commands[name]; ok {
+		return fmt.Sprintf("%s is a shell builtin", name), nil
+	}
+	return "", fmt.Errorf("type: %s: not found", name)
+}
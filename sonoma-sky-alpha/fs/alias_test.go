@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Touch("a.txt")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"alias ll='ls -i'", ""},
+		{"alias greet='echo hi'", ""},
+		{"greet there", "hi there"},
+		{"echo x | greet", "hi"},
+		// An alias is not expanded within itself
+		{"alias pwd='pwd'", ""},
+		{"pwd", "/home/user"},
+		{"alias greet", "alias greet='echo hi'"},
+		{"alias", "alias greet='echo hi'\nalias ll='ls -i'\nalias pwd='pwd'"},
+		{"alias quote=\"echo it's\"", ""},
+		{"alias quote", `alias quote='echo it'\''s'`},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if output != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, output)
+		}
+	}
+
+	if _, err := term.Execute("unalias greet"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := term.Execute("greet"); err == nil {
+		t.Error("a removed alias should no longer run")
+	}
+	for _, input := range []string{"alias missing", "unalias missing", "alias 'a b=ls'"} {
+		if _, err := term.Execute(input); err == nil {
+			t.Errorf("%s should error", input)
+		}
+	}
+}
+
+func TestCommandBypassesAlias(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Touch("a.txt")
+	term.Execute("alias ls='echo shadowed'")
+
+	if output, _ := term.Execute("ls"); output != "shadowed" {
+		t.Fatalf("Expected the alias to run, got %q", output)
+	}
+	for _, input := range []string{"command ls", "builtin ls"} {
+		if output, err := term.Execute(input); err != nil || output != "a.txt" {
+			t.Errorf("%s should run the real ls, got %q (%v)", input, output, err)
+		}
+	}
+	if output, _ := term.Execute("command echo a b"); output != "a b" {
+		t.Errorf("command should pass the arguments on, got %q", output)
+	}
+	if _, err := term.Execute("command nosuch"); err == nil {
+		t.Error("command of an unknown name should error")
+	}
+}
+
+func TestType(t *testing.T) {
+	term := NewTerminal()
+	term.Execute("alias ll='ls -l'")
+	term.Execute("alias ls='ls -a'")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"type ll", "ll is aliased to `ls -l'"},
+		{"type cd", "cd is a shell builtin"},
+		{"type ls", "ls is aliased to `ls -a'"},
+		{"type cd ll", "cd is a shell builtin\nll is aliased to `ls -l'"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil || output != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.input, tt.expected, output, err)
+		}
+	}
+
+	output, err := term.Execute("type cd nosuch")
+	if err == nil || err.Error() != "type: nosuch: not found" {
+		t.Errorf("Expected an unknown name to be not found, got %v", err)
+	}
+	if output != "cd is a shell builtin" {
+		t.Errorf("The names found should still be described, got %q", output)
+	}
+}
+
+// Run with -race: a background job defines aliases while the foreground expands them
+func TestAliasInBackground(t *testing.T) {
+	term := NewTerminal()
+	term.Out = io.Discard
+	for i := 0; i < 10; i++ {
+		if _, err := term.Execute(fmt.Sprintf("sleep 0.001 | alias a%d=pwd here=pwd &", i)); err != nil {
+			t.Fatal(err)
+		}
+		for len(term.Jobs()) > 0 {
+			term.Execute("here")
+		}
+	}
+	term.WaitJobs()
+	if output, err := term.Execute("here"); err != nil || output != "/home/user" {
+		t.Errorf("Expected the alias set in the background to run, got %q (%v)", output, err)
+	}
+}
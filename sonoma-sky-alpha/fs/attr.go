@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetAttr sets the extended attribute key of the file at path to value.
+// Changing attributes needs write access to the file.
+func (fs *FileSystem) SetAttr(path, key, value string) error {
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return fmt.Errorf("setattr: %v", err)
+	}
+	if key == "" {
+		return fmt.Errorf("setattr: empty attribute name")
+	}
+	if err := fs.checkAccess(file, AccessWrite, path); err != nil {
+		return fmt.Errorf("setattr: %v", err)
+	}
+	if file.Attrs == nil {
+		file.Attrs = make(map[string]string)
+	}
+	file.Attrs[key] = value
+	return nil
+}
+
+// GetAttr returns the value of the extended attribute key of the file at path
+func (fs *FileSystem) GetAttr(path, key string) (string, error) {
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("getattr: %v", err)
+	}
+	if err := fs.checkAccess(file, AccessRead, path); err != nil {
+		return "", fmt.Errorf("getattr: %v", err)
+	}
+	value, ok := file.Attrs[key]
+	if !ok {
+		return "", fmt.Errorf("getattr: %s: no attribute '%s'", path, key)
+	}
+	return value, nil
+}
+
+// ListAttrs returns the extended attributes of the file at path as key=value lines sorted by key
+func (fs *FileSystem) ListAttrs(path string) (string, error) {
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("lsattr: %v", err)
+	}
+	if err := fs.checkAccess(file, AccessRead, path); err != nil {
+		return "", fmt.Errorf("lsattr: %v", err)
+	}
+	keys := make([]string, 0, len(file.Attrs))
+	for key := range file.Attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + file.Attrs[key]
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// copyAttrs returns a copy of attrs, so a copied file can change its attributes on its own
+func copyAttrs(attrs map[string]string) map[string]string {
+	if attrs == nil {
+		return nil
+	}
+	dup := make(map[string]string, len(attrs))
+	for key, value := range attrs {
+		dup[key] = value
+	}
+	return dup
+}
@@ -0,0 +1,70 @@
+package fs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttrs(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Touch("report.txt")
+
+	if err := fs.SetAttr("report.txt", "status", "draft"); err != nil {
+		t.Fatal(err)
+	}
+	fs.SetAttr("report.txt", "owner", "alice")
+	fs.SetAttr("report.txt", "status", "final")
+
+	if value, err := fs.GetAttr("report.txt", "status"); err != nil || value != "final" {
+		t.Errorf("getattr should return the latest value, got %q (%v)", value, err)
+	}
+	if _, err := fs.GetAttr("report.txt", "missing"); err == nil {
+		t.Error("getattr of an unset key should fail")
+	}
+	if output, err := fs.ListAttrs("report.txt"); err != nil || output != "owner=alice\nstatus=final" {
+		t.Errorf("lsattr should list attributes sorted by key, got %q (%v)", output, err)
+	}
+	if err := fs.SetAttr("missing.txt", "k", "v"); err == nil {
+		t.Error("setattr on a missing file should fail")
+	}
+}
+
+func TestCpPreserveAttrs(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("src", false)
+	fs.Touch("src/a.txt")
+	fs.SetAttr("src/a.txt", "tag", "keep")
+	fs.SetAttr("src", "kind", "project")
+	fs.Chmod("600", "src/a.txt")
+	file, _ := fs.ResolvePath("src/a.txt")
+	file.ModTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// A plain copy starts fresh
+	fs.Cp("src/a.txt", "plain.txt", false)
+	if output, _ := fs.ListAttrs("plain.txt"); output != "" {
+		t.Errorf("cp without -p should not copy attributes, got %q", output)
+	}
+
+	if err := fs.CpWith("src/a.txt", "kept.txt", CopyOptions{Preserve: true}); err != nil {
+		t.Fatal(err)
+	}
+	kept, _ := fs.ResolvePath("kept.txt")
+	if kept.Attrs["tag"] != "keep" || kept.Permissions != 0600 || !kept.ModTime.Equal(file.ModTime) {
+		t.Errorf("cp -p should keep attributes, mode and time, got %v %04o %v", kept.Attrs, kept.Permissions, kept.ModTime)
+	}
+	// The copy's attributes are its own
+	fs.SetAttr("kept.txt", "tag", "changed")
+	if value, _ := fs.GetAttr("src/a.txt", "tag"); value != "keep" {
+		t.Errorf("changing the copy should leave the source alone, got %q", value)
+	}
+
+	if err := fs.CpWith("src", "dup", CopyOptions{Recursive: true, Preserve: true}); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := fs.GetAttr("dup", "kind"); value != "project" {
+		t.Errorf("cp -rp should keep directory attributes, got %q", value)
+	}
+	if value, _ := fs.GetAttr("dup/a.txt", "tag"); value != "keep" {
+		t.Errorf("cp -rp should keep attributes inside the tree, got %q", value)
+	}
+}
@@ -0,0 +1,104 @@
+package fs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LastField stands for $NF, the last field of a line, in an AwkProgram
+const LastField = -1
+
+// AwkProgram is a parsed awk '{print ...}' program: the fields it prints, in order
+type AwkProgram struct {
+	Fields []int // Field numbers; 0 is the whole line and LastField the last field
+}
+
+// ParseAwk parses a program of the form {print $N, ...}. A bare print prints the whole line.
+func ParseAwk(program string) (*AwkProgram, error) {
+	body := strings.TrimSpace(program)
+	if !strings.HasPrefix(body, "{") || !strings.HasSuffix(body, "}") {
+		return nil, fmt.Errorf("syntax error in '%s': expected {print ...}", program)
+	}
+	body = strings.TrimSpace(body[1 : len(body)-1])
+	args, ok := strings.CutPrefix(body, "print")
+	if !ok {
+		return nil, fmt.Errorf("syntax error in '%s': only print is supported", program)
+	}
+	if strings.TrimSpace(args) == "" {
+		return &AwkProgram{Fields: []int{0}}, nil
+	}
+
+	var fields []int
+	for _, arg := range strings.Split(args, ",") {
+		arg = strings.TrimSpace(arg)
+		ref, ok := strings.CutPrefix(arg, "$")
+		if !ok {
+			return nil, fmt.Errorf("syntax error in '%s': expected a field such as $1, got '%s'", program, arg)
+		}
+		if ref == "NF" {
+			fields = append(fields, LastField)
+			continue
+		}
+		n, err := strconv.Atoi(ref)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("syntax error in '%s': invalid field '%s'", program, arg)
+		}
+		fields = append(fields, n)
+	}
+	return &AwkProgram{Fields: fields}, nil
+}
+
+// Run returns the fields the program prints for line, joined by a space. Fields are split
+// on runs of blanks when sep is empty, otherwise on each occurrence of sep.
+func (p *AwkProgram) Run(line, sep string) string {
+	var fields []string
+	if sep == "" || sep == " " {
+		fields = strings.Fields(line)
+	} else {
+		fields = strings.Split(line, sep)
+	}
+
+	out := make([]string, len(p.Fields))
+	for i, n := range p.Fields {
+		switch {
+		case n == 0:
+			out[i] = line
+		case n == LastField:
+			if len(fields) > 0 {
+				out[i] = fields[len(fields)-1]
+			}
+		case n <= len(fields):
+			out[i] = fields[n-1]
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// Awk runs program on each line of the file at path, splitting fields on sep
+func (fs *FileSystem) Awk(program, sep, path string) (string, error) {
+	prog, err := ParseAwk(program)
+	if err != nil {
+		return "", fmt.Errorf("awk: %v", err)
+	}
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("awk: %s: %v", path, err)
+	}
+	if file.Type == Directory {
+		return "", fmt.Errorf("awk: %s: is a directory", path)
+	}
+	if err := fs.checkAccess(file, AccessRead, path); err != nil {
+		return "", fmt.Errorf("awk: %v", err)
+	}
+
+	text := strings.TrimSuffix(string(file.Content), "\n")
+	if text == "" {
+		return "", nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = prog.Run(line, sep)
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,58 @@
+package fs
+
+import "testing"
+
+func TestAwk(t *testing.T) {
+	fs := NewFileSystem()
+	fs.EchoWrite("alice  30 paris\nbob 25   rome\n\ncarol 41 oslo", "people.txt", false)
+	fs.EchoWrite("root:x:0:/root\nuser:x:1000:/home/user", "passwd", false)
+
+	tests := []struct {
+		program, sep, path string
+		want               string
+	}{
+		{"{print $1}", "", "people.txt", "alice\nbob\n\ncarol"},
+		{"{print $3, $1}", "", "people.txt", "paris alice\nrome bob\n \noslo carol"},
+		{"{ print $NF }", "", "people.txt", "paris\nrome\n\noslo"},
+		{"{print $0}", "", "passwd", "root:x:0:/root\nuser:x:1000:/home/user"},
+		{"{print}", "", "passwd", "root:x:0:/root\nuser:x:1000:/home/user"},
+		{"{print $1, $3}", ":", "passwd", "root 0\nuser 1000"},
+		{"{print $9}", ":", "passwd", "\n"},
+	}
+	for _, tt := range tests {
+		got, err := fs.Awk(tt.program, tt.sep, tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.program, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s -F %q: expected %q, got %q", tt.program, tt.sep, tt.want, got)
+		}
+	}
+
+	for _, program := range []string{"print $1", "{print 1}", "{printf $1}", "{print $x}"} {
+		if _, err := fs.Awk(program, "", "passwd"); err == nil {
+			t.Errorf("%s should be rejected", program)
+		}
+	}
+}
+
+func TestAwkCommand(t *testing.T) {
+	term := NewTerminal()
+	term.FS.EchoWrite("a,b,c\nd,e,f", "data.csv", false)
+	term.FS.EchoWrite("x\ty z", "tabs.txt", false)
+
+	tests := []struct {
+		input, stdout string
+	}{
+		{"awk -F , '{print $2}' data.csv", "b\ne\n"},
+		{"awk -F, '{print $3, $1}' data.csv", "c a\nf d\n"},
+		{`awk -F '\t' '{print $2}' tabs.txt`, "y z\n"},
+	}
+	for _, tt := range tests {
+		stdout, _, err := term.RunCommand(tt.input)
+		if err != nil || stdout != tt.stdout {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.input, tt.stdout, stdout, err)
+		}
+	}
+}
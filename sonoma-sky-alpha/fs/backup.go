@@ -0,0 +1,57 @@
+package fs
+
+import (
+	"fmt"
+)
+
+// BackupMode selects how cp and mv preserve a destination they overwrite
+type BackupMode int
+
+const (
+	BackupNone     BackupMode = iota
+	BackupSimple              // dest~
+	BackupNumbered            // dest.~N~
+)
+
+// ParseBackupMode parses the value of a --backup flag; an empty value means simple backups
+func ParseBackupMode(value string) (BackupMode, error) {
+	switch value {
+	case "", "simple", "never":
+		return BackupSimple, nil
+	case "numbered", "t":
+		return BackupNumbered, nil
+	case "none", "off":
+		return BackupNone, nil
+	default:
+		return BackupNone, fmt.Errorf("invalid backup type '%s'", value)
+	}
+}
+
+// backup renames the node at dir/name out of the way before it is overwritten by src
+func (fs *FileSystem) backup(dir *VirtualFile, name string, src *VirtualFile, mode BackupMode) error {
+	existing, exists := dir.Children[name]
+	if mode == BackupNone || !exists || existing == src {
+		return nil
+	}
+	if existing.Type == Directory {
+		return fmt.Errorf("cannot back up directory '%s'", name)
+	}
+
+	backupName := name + "~"
+	if mode == BackupNumbered {
+		for n := 1; ; n++ {
+			backupName = fmt.Sprintf("%s.~%d~", name, n)
+			if _, taken := dir.Children[backupName]; !taken {
+				break
+			}
+		}
+	}
+
+	if old, taken := dir.Children[backupName]; taken {
+		fs.record(removeOp{fs: fs, node: old, parent: dir, name: backupName})
+	}
+	delete(dir.Children, name)
+	fs.record(moveOp{fs: fs, node: existing, from: dir, fromName: name})
+	fs.link(dir, backupName, existing)
+	return nil
+}
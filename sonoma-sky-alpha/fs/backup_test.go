@@ -0,0 +1,82 @@
+package fs
+
+import (
+	"testing"
+)
+
+func TestCpBackup(t *testing.T) {
+	fs := NewFileSystem()
+	fs.EchoWrite("old", "dest.txt", false)
+	fs.EchoWrite("new", "src.txt", false)
+
+	err := fs.CpWith("src.txt", "dest.txt", CopyOptions{Backup: BackupSimple})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, _ := fs.Cat("dest.txt")
+	if output != "new\n" {
+		t.Errorf("Destination should have the new content, got %q", output)
+	}
+	output, err = fs.Cat("dest.txt~")
+	if err != nil {
+		t.Fatal("Backup file dest.txt~ should exist")
+	}
+	if output != "old\n" {
+		t.Errorf("Backup should have the old content, got %q", output)
+	}
+}
+
+func TestCpBackupNumbered(t *testing.T) {
+	fs := NewFileSystem()
+	fs.EchoWrite("v1", "dest.txt", false)
+	for _, content := range []string{"v2", "v3"} {
+		fs.EchoWrite(content, "src.txt", false)
+		err := fs.CpWith("src.txt", "dest.txt", CopyOptions{Backup: BackupNumbered})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{"dest.txt.~1~": "v1\n", "dest.txt.~2~": "v2\n", "dest.txt": "v3\n"} {
+		output, err := fs.Cat(name)
+		if err != nil {
+			t.Errorf("%s should exist: %v", name, err)
+		}
+		if output != want {
+			t.Errorf("%s: expected %q, got %q", name, want, output)
+		}
+	}
+}
+
+func TestMvBackup(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("dir", false)
+	fs.EchoWrite("old", "dir/file.txt", false)
+	fs.EchoWrite("new", "file.txt", false)
+
+	err := fs.MvWith("file.txt", "dir", CopyOptions{Backup: BackupSimple})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _ := fs.Cat("dir/file.txt")
+	if output != "new\n" {
+		t.Errorf("Destination should have the new content, got %q", output)
+	}
+	output, _ = fs.Cat("dir/file.txt~")
+	if output != "old\n" {
+		t.Errorf("Backup should have the old content, got %q", output)
+	}
+}
+
+func TestParseBackupMode(t *testing.T) {
+	for value, want := range map[string]BackupMode{"": BackupSimple, "numbered": BackupNumbered, "none": BackupNone} {
+		mode, err := ParseBackupMode(value)
+		if err != nil || mode != want {
+			t.Errorf("ParseBackupMode(%q) = %v, %v", value, mode, err)
+		}
+	}
+	if _, err := ParseBackupMode("bogus"); err == nil {
+		t.Error("Invalid backup type should error")
+	}
+}
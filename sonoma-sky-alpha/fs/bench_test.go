@@ -0,0 +1,96 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const bulkEntries = 10000
+
+// bulkNames returns the file names used by the bulk benchmarks
+func bulkNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("bulk/file%05d.txt", i)
+	}
+	return names
+}
+
+func TestBulkCreateListRemove(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("bulk", false)
+	for _, name := range bulkNames(bulkEntries) {
+		if err := fs.Touch(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts, _ := fs.Count("bulk")
+	if counts.Files != bulkEntries {
+		t.Errorf("Expected %d files, got %d", bulkEntries, counts.Files)
+	}
+	output, err := fs.LsWith("bulk", LsOptions{Porcelain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := len(strings.Split(output, "\n")); lines != bulkEntries {
+		t.Errorf("Expected %d ls lines, got %d", bulkEntries, lines)
+	}
+	if err := fs.Rm("bulk", true); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := fs.Exists("bulk"); exists {
+		t.Error("rm -r should remove the bulk directory")
+	}
+}
+
+func BenchmarkBulkCreate(b *testing.B) {
+	names := bulkNames(bulkEntries)
+	for i := 0; i < b.N; i++ {
+		fs := NewFileSystem()
+		fs.Mkdir("bulk", false)
+		for _, name := range names {
+			fs.Touch(name)
+		}
+	}
+}
+
+func BenchmarkBulkList(b *testing.B) {
+	fs := NewFileSystem()
+	fs.Mkdir("bulk", false)
+	for _, name := range bulkNames(bulkEntries) {
+		fs.Touch(name)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fs.Ls("bulk", true, false)
+	}
+}
+
+func BenchmarkBulkRemove(b *testing.B) {
+	names := bulkNames(bulkEntries)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		fs := NewFileSystem()
+		fs.Mkdir("bulk", false)
+		for _, name := range names {
+			fs.Touch(name)
+		}
+		b.StartTimer()
+		fs.Rm("bulk", true)
+	}
+}
+
+func BenchmarkCpLargeFiles(b *testing.B) {
+	fs := NewFileSystem()
+	fs.Mkdir("large", false)
+	content := strings.Repeat("x", 1<<20)
+	for i := 0; i < 16; i++ {
+		fs.EchoWrite(content, fmt.Sprintf("large/file%02d", i), false)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fs.Cp("large", fmt.Sprintf("copy%d", i), true)
+	}
+}
@@ -0,0 +1,1300 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The built-in commands, in the order help lists them
+func init() {
+	register(&Command{
+		Name:    "pwd",
+		Usage:   "pwd",
+		Summary: "Print working directory",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 0 {
+				return "", usageError("pwd")
+			}
+			return t.FS.Pwd(), nil
+		},
+	})
+	register(&Command{
+		Name:    "cd",
+		Usage:   "cd [DIR]",
+		Summary: "Change directory",
+		Details: `  ~ is the home directory and - the previous directory.
+
+Examples:
+  cd /home
+  cd ..`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", usageError("cd")
+			}
+			path := "" // home
+			if len(args) == 1 {
+				path = args[0]
+			}
+			return "", t.FS.Cd(path)
+		},
+	})
+	register(&Command{
+		Name:    "mkdir",
+		Usage:   "mkdir [-p] DIR...",
+		Summary: "Create directories",
+		Details: `  -p  create missing parent directories, and succeed if the directory exists
+
+  A DIR that cannot be created is reported without stopping the others.
+
+Examples:
+  mkdir projects
+  mkdir -p projects/src/main
+  mkdir docs tests`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			parents := len(args) > 0 && args[0] == "-p"
+			if parents {
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				return "", usageError("mkdir")
+			}
+			var errs []error
+			for _, path := range args {
+				if err := t.FS.Mkdir(path, parents); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return "", errors.Join(errs...)
+		},
+	})
+	register(&Command{
+		Name:    "touch",
+		Usage:   "touch FILE...",
+		Summary: "Create empty files",
+		Details: `  An existing file keeps its content and gets a new modification time. A FILE that
+  cannot be created is reported without stopping the others.`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", usageError("touch")
+			}
+			var errs []error
+			for _, path := range args {
+				if err := t.FS.Touch(path); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return "", errors.Join(errs...)
+		},
+	})
+	register(&Command{
+		Name:    "ls",
+		Usage:   "ls [-l] [-a] [-A] [-d] [-i] [--porcelain] [--total] [--time-style=STYLE] [PATH...]",
+		Summary: "List directory contents",
+		Details: `  -l                  long format: permissions, owner, group, size and modification time
+  -a                  include entries whose names start with a dot, and . and ..
+  -A                  like -a, without . and ..
+  -d                  list a directory itself rather than its contents
+  -i                  print each entry's inode number before it
+  --porcelain         one tab-separated line per entry, sorted, for scripts
+  --total             end with the bytes in the entries listed, including everything
+                      below the directories, as du would count them
+  --time-style=STYLE  time format for -l: default (Jan 02 15:04), iso (2006-01-02 15:04),
+                      full-iso (2006-01-02 15:04:05.000000000 -0700) or +LAYOUT, a Go layout
+
+  Several paths list the files first, then each directory under a "PATH:" header.
+  --porcelain lists only real entries, never . and ..
+  Wildcards *, ? and [...] expand to the matching paths.
+
+Examples:
+  ls -l
+  ls -a /home/user
+  ls -l --time-style=+2006-01-02
+  ls --total project
+  ls *.txt docs
+  ls -d */`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			var paths []string
+			var opts LsOptions
+			// Simple flag parsing, assume flags are separate args
+			for _, arg := range args {
+				switch {
+				case arg == "-l":
+					opts.Long = true
+				case arg == "-a":
+					opts.All, opts.AlmostAll = true, false
+				case arg == "-A":
+					opts.All, opts.AlmostAll = false, true
+				case arg == "-d":
+					opts.Directory = true
+				case arg == "-i":
+					opts.Inode = true
+				case arg == "--porcelain":
+					opts.Porcelain = true
+				case arg == "--total":
+					opts.Total = true
+				case strings.HasPrefix(arg, "--time-style="):
+					opts.TimeStyle = strings.TrimPrefix(arg, "--time-style=")
+				default:
+					paths = append(paths, arg)
+				}
+			}
+			paths, err := t.FS.expandGlobs(paths)
+			if err != nil {
+				return "", fmt.Errorf("ls: %v", err)
+			}
+			return t.FS.LsAll(paths, opts)
+		},
+	})
+	register(&Command{
+		Name:    "tree",
+		Usage:   "tree [-a] [-J] [PATH]",
+		Summary: "List a directory and everything below it",
+		Details: `  -a  include entries whose names start with a dot
+  -J  print the tree as JSON: objects with a name, a type (file or directory)
+      and, for a directory with entries, their objects in a children array
+
+  PATH defaults to the working directory. The drawn tree ends with the number of
+  directories and files below PATH.
+
+Examples:
+  tree
+  tree -a project
+  tree -J project`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			var opts TreeOptions
+			var paths []string
+			for _, arg := range args {
+				switch arg {
+				case "-a":
+					opts.All = true
+				case "-J":
+					opts.JSON = true
+				default:
+					paths = append(paths, arg)
+				}
+			}
+			if len(paths) > 1 {
+				return "", usageError("tree")
+			}
+			path := "."
+			if len(paths) == 1 {
+				path = paths[0]
+			}
+			return t.FS.Tree(path, opts)
+		},
+	})
+	register(&Command{
+		Name:    "stat",
+		Usage:   "stat [--porcelain] PATH...",
+		Summary: "Show file status",
+		Details: `  --porcelain  print each path as one tab-separated line for scripts`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			porcelain := false
+			var paths []string
+			for _, arg := range args {
+				if arg == "--porcelain" {
+					porcelain = true
+				} else {
+					paths = append(paths, arg)
+				}
+			}
+			if len(paths) == 0 {
+				return "", usageError("stat")
+			}
+			var out []string
+			for _, path := range paths {
+				info, err := t.FS.Stat(path, porcelain)
+				if err != nil {
+					return strings.Join(out, "\n"), err
+				}
+				out = append(out, info)
+			}
+			return strings.Join(out, "\n"), nil
+		},
+	})
+	register(&Command{
+		Name:    "rm",
+		Usage:   "rm [-r] [-d] PATH...",
+		Summary: "Delete files or directories",
+		Details: `  -r  remove a directory and everything in it
+  -d  remove an empty directory
+
+  Removed entries can be brought back with restore. A PATH that cannot be
+  removed is reported without stopping the others.
+
+Examples:
+  rm notes.txt
+  rm -r build
+  rm -d empty`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			var opts RmOptions
+			for len(args) > 0 && (args[0] == "-r" || args[0] == "-d") {
+				opts.Recursive = opts.Recursive || args[0] == "-r"
+				opts.Dir = opts.Dir || args[0] == "-d"
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				return "", usageError("rm")
+			}
+			var errs []error
+			for _, path := range args {
+				if err := t.FS.RmWith(path, opts); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return "", errors.Join(errs...)
+		},
+	})
+	register(&Command{
+		Name:    "rmdir",
+		Usage:   "rmdir DIR",
+		Summary: "Remove empty directory",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", usageError("rmdir")
+			}
+			return "", t.FS.Rmdir(args[0])
+		},
+	})
+	register(&Command{
+		Name:    "undo",
+		Usage:   "undo",
+		Summary: "Revert the last file system change",
+		Details: `  Each command that changed the tree is undone as a whole, most recent first.`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 0 {
+				return "", usageError("undo")
+			}
+			return "", t.Undo()
+		},
+	})
+	register(&Command{
+		Name:    "restore",
+		Usage:   "restore [PATH]",
+		Summary: "Restore the last (or given) path removed by rm",
+		Details: `  Missing parent directories are recreated.
+
+Examples:
+  restore
+  restore /home/user/notes.txt`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", usageError("restore")
+			}
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			restored, err := t.FS.Restore(path)
+			if err != nil {
+				return "", err
+			}
+			return "restored " + restored, nil
+		},
+	})
+	register(&Command{
+		Name:    "cp",
+		Usage:   "cp [-r] [-p] [--backup[=numbered]] SOURCE... DEST",
+		Summary: "Copy files or directories",
+		Details: `  -r                  copy directories recursively
+  -p                  keep mode, ownership, modification time and attributes
+  --backup[=numbered] keep an overwritten dest as dest~ (or dest.~N~)
+
+  With several sources DEST must be a directory, and a summary of what was
+  copied, skipped and overwritten is printed.
+
+Examples:
+  cp notes.txt notes.bak
+  cp -r src backup
+  cp a.txt b.txt docs`,
+		Run: copyOrMove("cp"),
+	})
+	register(&Command{
+		Name:    "mv",
+		Usage:   "mv [--backup[=numbered]] SOURCE... DEST",
+		Summary: "Move/rename files or directories",
+		Details: `  --backup[=numbered] keep an overwritten dest as dest~ (or dest.~N~)
+
+  With several sources DEST must be a directory, and a summary of what was
+  moved, skipped and overwritten is printed.
+
+Examples:
+  mv draft.txt final.txt
+  mv final.txt docs
+  mv a.txt b.txt docs`,
+		Run: copyOrMove("mv"),
+	})
+	register(&Command{
+		Name:    "cat",
+		Usage:   "cat [-f] [FILE...]",
+		Summary: "Display file contents",
+		Details: `  -f  print the file even if it holds binary data
+
+  Output past the configured limit (--max-output) is truncated. A file with NUL
+  bytes is refused unless -f is given, since it would garble the terminal.
+  Several files are printed one after the other, exactly as stored, with nothing added
+  between them. Without FILE cat prints its input from a pipe or a here-string.
+
+Examples:
+  cat notes.txt
+  cat header.txt body.txt
+  cat <<< "hello"`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			var opts CatOptions
+			if len(args) > 0 && args[0] == "-f" {
+				opts.Force = true
+				args = args[1:]
+			}
+			if len(args) == 0 && t.hasStdin {
+				return strings.TrimSuffix(t.Stdin, "\n"), nil
+			}
+			if len(args) == 0 {
+				return "", usageError("cat")
+			}
+			return t.FS.CatAll(args, opts)
+		},
+	})
+	register(&Command{
+		Name:    "wc",
+		Usage:   "wc [-l] [-w] [-c] [-L] [FILE...]",
+		Summary: "Count lines, words and bytes in files",
+		Details: `  -l  print the number of lines
+  -w  print the number of words
+  -c  print the number of bytes
+  -L  print the length in characters of the longest line
+
+  Without flags wc prints lines, words and bytes. Several files end with a total.
+  Without FILE wc counts its input from a pipe or a here-string.
+
+Examples:
+  wc -l notes.txt
+  ls | wc -l
+  wc -w <<< "one two three"`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			var opts WcOptions
+			var paths []string
+			for _, arg := range args {
+				switch arg {
+				case "-l":
+					opts.Lines = true
+				case "-w":
+					opts.Words = true
+				case "-c":
+					opts.Bytes = true
+				case "-L":
+					opts.MaxLine = true
+				default:
+					paths = append(paths, arg)
+				}
+			}
+			if len(paths) == 0 && t.hasStdin {
+				return countText([]byte(t.Stdin)).Format(opts, ""), nil
+			}
+			if len(paths) == 0 {
+				return "", usageError("wc")
+			}
+
+			var lines []string
+			var total WcCounts
+			var errs []error
+			for _, path := range paths {
+				counts, err := t.FS.Wc(path)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				lines = append(lines, counts.Format(opts, path))
+				total = total.Add(counts)
+			}
+			if len(paths) > 1 {
+				lines = append(lines, total.Format(opts, "total"))
+			}
+			return strings.Join(lines, "\n"), errors.Join(errs...)
+		},
+	})
+	register(&Command{
+		Name:    "grep",
+		Usage:   "grep [-i] [-n] [-A NUM] [-B NUM] [-C NUM] PATTERN FILE...",
+		Summary: "Print lines matching a pattern",
+		Details: `  -i      ignore case
+  -n      prefix each line with its line number
+  -A NUM  print NUM lines of context after each match
+  -B NUM  print NUM lines of context before each match
+  -C NUM  print NUM lines of context before and after each match
+
+  PATTERN is a regular expression. Context lines are marked with - instead of :,
+  and groups of lines that are not next to each other are separated by --.
+
+Examples:
+  grep error app.log
+  grep -n -C 2 panic app.log
+  grep -i todo *.txt`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			var opts GrepOptions
+			var operands []string
+			for i := 0; i < len(args); i++ {
+				switch arg := args[i]; arg {
+				case "-i":
+					opts.IgnoreCase = true
+				case "-n":
+					opts.LineNumbers = true
+				case "-A", "-B", "-C":
+					if i+1 == len(args) {
+						return "", usageError("grep")
+					}
+					i++
+					n, err := strconv.Atoi(args[i])
+					if err != nil || n < 0 {
+						return "", fmt.Errorf("grep: %s: invalid context length argument", args[i])
+					}
+					if arg != "-B" {
+						opts.After = n
+					}
+					if arg != "-A" {
+						opts.Before = n
+					}
+				default:
+					operands = append(operands, arg)
+				}
+			}
+			if len(operands) < 2 {
+				return "", usageError("grep")
+			}
+			paths, err := t.FS.expandGlobs(operands[1:])
+			if err != nil {
+				return "", fmt.Errorf("grep: %v", err)
+			}
+			return t.FS.Grep(operands[0], paths, opts)
+		},
+	})
+	register(&Command{
+		Name:    "sed",
+		Usage:   "sed [-i] s/PATTERN/REPLACEMENT/[g] FILE",
+		Summary: "Substitute text in each line of a file",
+		Details: `  -i  edit the file in place instead of printing the result
+
+  PATTERN is a regular expression and only the s command is supported. Without g
+  only the first match on each line is replaced. In REPLACEMENT & is the whole
+  match and \1 to \9 are groups.
+
+Examples:
+  sed 's/colour/color/g' notes.txt
+  sed -i 's/v1/v2/' version.txt`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			inPlace := len(args) > 0 && args[0] == "-i"
+			if inPlace {
+				args = args[1:]
+			}
+			if len(args) != 2 {
+				return "", usageError("sed")
+			}
+			return t.FS.Sed(args[0], args[1], inPlace)
+		},
+	})
+	register(&Command{
+		Name:    "awk",
+		Usage:   "awk [-F SEP] '{print $N, ...}' FILE",
+		Summary: "Print fields of each line of a file",
+		Details: `  -F SEP  split fields on SEP instead of runs of blanks; \t is a tab
+
+  Only print is supported. $0 is the whole line, $NF the last field, and the
+  fields listed are joined by a space.
+
+Examples:
+  awk '{print $1}' access.log
+  awk -F : '{print $1, $NF}' passwd`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			sep := ""
+			switch {
+			case len(args) > 0 && args[0] == "-F":
+				if len(args) < 2 {
+					return "", usageError("awk")
+				}
+				sep, args = args[1], args[2:]
+			case len(args) > 0 && strings.HasPrefix(args[0], "-F"):
+				sep, args = args[0][2:], args[1:]
+			}
+			if len(args) != 2 {
+				return "", usageError("awk")
+			}
+			return t.FS.Awk(args[0], strings.ReplaceAll(sep, `\t`, "\t"), args[1])
+		},
+	})
+	register(&Command{
+		Name:    "alias",
+		Usage:   "alias [NAME[=VALUE]...]",
+		Summary: "Define or list aliases",
+		Details: `  NAME=VALUE makes NAME, at the start of a command, stand for VALUE. NAME alone
+  prints its definition, and no arguments print them all. An alias is not expanded
+  within its own value, and command NAME runs the built-in NAME whatever the aliases.
+
+Examples:
+  alias ll='ls -l'
+  alias ls='ls -a'
+  alias`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) == 0 {
+				return t.ListAliases(), nil
+			}
+			var out []string
+			var errs []error
+			for _, arg := range args {
+				name, value, ok := strings.Cut(arg, "=")
+				if ok {
+					if err := t.SetAlias(name, value); err != nil {
+						errs = append(errs, fmt.Errorf("alias: %v", err))
+					}
+					continue
+				}
+				if value, ok := t.Alias(name); ok {
+					out = append(out, FormatAlias(name, value))
+				} else {
+					errs = append(errs, fmt.Errorf("alias: %s: not found", name))
+				}
+			}
+			return strings.Join(out, "\n"), errors.Join(errs...)
+		},
+	})
+	register(&Command{
+		Name:    "unalias",
+		Usage:   "unalias NAME...",
+		Summary: "Remove aliases",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", usageError("unalias")
+			}
+			var errs []error
+			for _, name := range args {
+				if err := t.UnsetAlias(name); err != nil {
+					errs = append(errs, fmt.Errorf("unalias: %v", err))
+				}
+			}
+			return "", errors.Join(errs...)
+		},
+	})
+	register(&Command{
+		Name:    "type",
+		Usage:   "type NAME...",
+		Summary: "Show whether a name is an alias or a built-in command",
+		Details: `  For each NAME type prints the alias it is and its value, or that it is a
+  built-in command. An alias shadows the built-in of the same name.
+
+Examples:
+  type ls
+  alias ll='ls -l'
+  type ll`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", usageError("type")
+			}
+			var out []string
+			var errs []error
+			for _, name := range args {
+				line, err := t.Type(name)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				out = append(out, line)
+			}
+			return strings.Join(out, "\n"), errors.Join(errs...)
+		},
+	})
+	for _, name := range []string{"command", "builtin"} {
+		register(&Command{
+			Name:    name,
+			Usage:   name + " NAME [ARG...]",
+			Summary: "Run a built-in command, bypassing aliases",
+			Details: `  Runs the built-in NAME with ARGs even when an alias of the same name exists.
+  Every command of the terminal is built in, so command and builtin are the same.
+
+Examples:
+  alias ls='ls -l'
+  ` + name + ` ls`,
+			Run: func(t *Terminal, args []string) (string, error) {
+				if len(args) == 0 {
+					return "", usageError(name)
+				}
+				return t.ExecuteCommand(args[0], args[1:])
+			},
+		})
+	}
+	register(&Command{
+		Name:    "xargs",
+		Usage:   "xargs [-n N] COMMAND [ARG...]",
+		Summary: "Run a command with arguments read from a pipe",
+		Details: `  -n N  pass at most N arguments to each run of COMMAND
+
+  The words of the piped input are appended to COMMAND's own arguments. Nothing is
+  run when the input is empty, and a run that fails does not stop the others.
+
+Examples:
+  ls *.tmp | xargs rm
+  ls | xargs -n 1 stat`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			batch := 0
+			if len(args) > 0 && args[0] == "-n" {
+				if len(args) < 2 {
+					return "", usageError("xargs")
+				}
+				n, err := strconv.Atoi(args[1])
+				if err != nil || n < 1 {
+					return "", fmt.Errorf("xargs: invalid number for -n: '%s'", args[1])
+				}
+				batch, args = n, args[2:]
+			}
+			if len(args) == 0 {
+				return "", usageError("xargs")
+			}
+			words := strings.Fields(t.Stdin)
+			if batch == 0 {
+				batch = len(words)
+			}
+
+			var out []string
+			var errs []error
+			for len(words) > 0 {
+				n := min(batch, len(words))
+				cmdArgs := append(append([]string{}, args[1:]...), words[:n]...)
+				words = words[n:]
+				output, err := t.ExecuteCommand(args[0], cmdArgs)
+				if output != "" {
+					out = append(out, output)
+				}
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return strings.Join(out, "\n"), errors.Join(errs...)
+		},
+	})
+	register(&Command{
+		Name:    "count",
+		Usage:   "count [PATH]",
+		Summary: "Count files, directories and bytes in a tree",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", usageError("count")
+			}
+			path := "."
+			if len(args) == 1 {
+				path = args[0]
+			}
+			counts, err := t.FS.Count(path)
+			if err != nil {
+				return "", err
+			}
+			return counts.String(), nil
+		},
+	})
+	register(&Command{
+		Name:    "fsck",
+		Usage:   "fsck [-r]",
+		Summary: "Check the tree for inconsistencies, repairing them with -r",
+		Details: `  -r  repair problems in place
+
+  Without -r, fsck fails if it finds any problem.`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			repair := false
+			for _, arg := range args {
+				if arg != "-r" {
+					return "", usageError("fsck")
+				}
+				repair = true
+			}
+			problems := t.FS.Fsck(repair)
+			if len(problems) == 0 {
+				return "fsck: no problems found", nil
+			}
+			lines := make([]string, 0, len(problems)+1)
+			for _, problem := range problems {
+				lines = append(lines, problem.String())
+			}
+			if !repair {
+				// Unrepaired problems fail the command so scripts can check the status
+				return strings.Join(lines, "\n"), fmt.Errorf("fsck: %d problems found", len(problems))
+			}
+			lines = append(lines, fmt.Sprintf("fsck: %d problems repaired", len(problems)))
+			return strings.Join(lines, "\n"), nil
+		},
+	})
+	register(&Command{
+		Name:    "updatedb",
+		Usage:   "updatedb",
+		Summary: "Rebuild the locate index",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 0 {
+				return "", usageError("updatedb")
+			}
+			t.FS.UpdateDB()
+			return "", nil
+		},
+	})
+	register(&Command{
+		Name:    "locate",
+		Usage:   "locate PATTERN",
+		Summary: "Search the index for paths containing pattern",
+		Details: `  Only paths recorded by the last updatedb are searched.
+
+Examples:
+  updatedb
+  locate notes`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", usageError("locate")
+			}
+			matches, err := t.FS.Locate(args[0])
+			if err != nil {
+				return "", err
+			}
+			return strings.Join(matches, "\n"), nil
+		},
+	})
+	register(&Command{
+		Name:    "chmod",
+		Usage:   "chmod [-R] [-v] MODE FILE...",
+		Summary: "Change file permissions (octal)",
+		Details: `  -R  change directories and everything under them
+  -v  report each file whose mode changed
+  Only the owner of a file, or root, may change its mode.
+
+Examples:
+  chmod 600 secret.txt
+  chmod 755 bin tools
+  chmod -R 700 private`,
+		Run: change("chmod", (*FileSystem).ChmodWith),
+	})
+	register(&Command{
+		Name:    "chown",
+		Usage:   "chown [-R] [-v] USER[:GROUP] FILE...",
+		Summary: "Change file owner and group",
+		Details: `  -R  change directories and everything under them
+  -v  report each file whose ownership changed
+  Only root may change ownership; use sudo.
+
+Examples:
+  sudo chown alice notes.txt
+  sudo chown alice:staff notes.txt
+  sudo chown -R alice:alice project`,
+		Run: change("chown", (*FileSystem).ChownWith),
+	})
+	register(&Command{
+		Name:    "setattr",
+		Usage:   "setattr FILE KEY VALUE",
+		Summary: "Set an extended attribute of a file",
+		Details: `  Attributes are free-form tags kept with the file and carried over by cp -p.
+
+Examples:
+  setattr report.txt status draft`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) != 3 {
+				return "", usageError("setattr")
+			}
+			return "", t.FS.SetAttr(args[0], args[1], args[2])
+		},
+	})
+	register(&Command{
+		Name:    "getattr",
+		Usage:   "getattr FILE KEY",
+		Summary: "Print an extended attribute of a file",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) != 2 {
+				return "", usageError("getattr")
+			}
+			return t.FS.GetAttr(args[0], args[1])
+		},
+	})
+	register(&Command{
+		Name:    "lsattr",
+		Usage:   "lsattr FILE",
+		Summary: "List the extended attributes of a file",
+		Details: `  Each attribute prints as KEY=VALUE, sorted by key.`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", usageError("lsattr")
+			}
+			return t.FS.ListAttrs(args[0])
+		},
+	})
+	register(&Command{
+		Name:    "umask",
+		Usage:   "umask [MODE]",
+		Summary: "Show or set the mask for new file permissions",
+		Details: `  New directories start from 0777 and new files from 0666, less the bits set in the mask.
+
+Examples:
+  umask
+  umask 077`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			switch len(args) {
+			case 0:
+				return fmt.Sprintf("%04o", t.FS.Umask), nil
+			case 1:
+				return "", t.FS.SetUmask(args[0])
+			default:
+				return "", usageError("umask")
+			}
+		},
+	})
+	register(&Command{
+		Name:    "echo",
+		Usage:   "echo [-n] [-e] [TEXT...] [> FILE | >> FILE]",
+		Summary: "Print text, or write or append it to a file",
+		Details: `  -n  do not end the text with a newline
+  -e  interpret the escapes \n (newline), \t (tab) and \\ (backslash)
+
+Examples:
+  echo hello
+  echo hello > greeting.txt
+  echo again >> greeting.txt
+  echo -n -e 'a\tb' > tab.txt`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			var opts EchoOptions
+			for len(args) > 0 && parseEchoFlag(args[0], &opts) {
+				args = args[1:]
+			}
+
+			// Handle redirection
+			var words []string
+			filename, appendMode := "", false
+			for i := 0; i < len(args); i++ {
+				if args[i] != ">" && args[i] != ">>" {
+					words = append(words, args[i])
+					continue
+				}
+				if i+1 == len(args) {
+					return "", fmt.Errorf("echo: missing filename")
+				}
+				filename, appendMode = args[i+1], args[i] == ">>"
+				i++
+			}
+			text := strings.Join(words, " ")
+			if filename != "" {
+				return "", t.FS.EchoWriteWith(text, filename, appendMode, opts)
+			}
+
+			// Text without a newline is returned as it is, marked as not ending its line
+			if opts.NoNewline {
+				t.partial = true
+				return opts.Render(text), nil
+			}
+			return strings.TrimSuffix(opts.Render(text), "\n"), nil
+		},
+	})
+	register(&Command{
+		Name:    "edit",
+		Usage:   "edit [-s SCRIPT] [+N] FILE",
+		Summary: "Edit file",
+		Details: `  -s SCRIPT run the lines of SCRIPT as editor input, showing nothing, then save; the
+            edit fails at the first line that does
+  +N        open at line N, marked with > in place of :; + alone opens at the last line
+  Lines typed are appended to the buffer. Files holding binary data are refused.
+  :w        save
+  :w FILE   save to FILE, still editing the same file
+  :saveas FILE  save to FILE and edit it from then on
+  :q        quit, refused while there are unsaved changes
+  :q!       quit without saving
+  :wq       save and quit
+  :d M,N    delete lines M to N
+  :m M,N P  move lines M to N after line P, 0 for the top
+  :y M,N    yank lines M to N
+  :p P      paste the lines last yanked or deleted after line P
+  :u        undo the last change to the buffer; repeat to undo more
+  :set number, :set nonumber  show or hide line numbers; :set alone shows the setting
+  /PATTERN  jump to the next line containing PATTERN, wrapping around; / alone repeats it
+  n         right after a search, find the next match
+  Ctrl-C quits without saving as well.`,
+		Run: editCommand("edit", EditOptions{}),
+	})
+	register(&Command{
+		Name:    "view",
+		Usage:   "view [+N] FILE",
+		Summary: "View file in the editor, read-only",
+		Details: `  +N        open at line N; + alone opens at the last line
+  Searching, :y, :set and quitting work as in edit. Adding lines, changing them and saving are
+  refused with "read-only", so the file cannot be changed by accident.`,
+		Run: editCommand("view", EditOptions{ReadOnly: true}),
+	})
+	register(&Command{
+		Name:    "clear",
+		Usage:   "clear",
+		Summary: "Clear screen",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 0 {
+				return "", usageError("clear")
+			}
+			t.Clear()
+			return "", nil
+		},
+	})
+	register(&Command{
+		Name:    "reset",
+		Usage:   "reset",
+		Summary: "Reset the terminal and clear the screen",
+		Details: `  Unlike clear, reset also restores the terminal's modes and erases the scrollback,
+  recovering a terminal whose display has been garbled.`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 0 {
+				return "", usageError("reset")
+			}
+			t.ResetScreen()
+			return "", nil
+		},
+	})
+	register(&Command{
+		Name:    "exit",
+		Usage:   "exit [STATUS]",
+		Summary: "Exit emulator (or the current su session)",
+		Details: `  The status defaults to that of the last command.`,
+		Run:     exit("exit"),
+	})
+	register(&Command{
+		Name:    "quit",
+		Usage:   "quit [STATUS]",
+		Summary: "Exit emulator, ending any su sessions",
+		Details: `  The status defaults to that of the last command.`,
+		Run:     exit("quit"),
+	})
+	register(&Command{
+		Name:    "su",
+		Usage:   "su [USER]",
+		Summary: "Switch user (root by default); exit returns",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", usageError("su")
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return "", t.Su(name)
+		},
+	})
+	register(&Command{
+		Name:    "sudo",
+		Usage:   "sudo COMMAND [ARG...]",
+		Summary: "Run a command as root",
+		Details: `Examples:
+  sudo mkdir /opt`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", usageError("sudo")
+			}
+			return t.Sudo(func() (string, error) {
+				return t.ExecuteCommand(args[0], args[1:])
+			})
+		},
+	})
+	register(&Command{
+		Name:    "whoami",
+		Usage:   "whoami",
+		Summary: "Print the current user",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 0 {
+				return "", usageError("whoami")
+			}
+			return t.Whoami(), nil
+		},
+	})
+	register(&Command{
+		Name:    "id",
+		Usage:   "id",
+		Summary: "Print user and group ids",
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 0 {
+				return "", usageError("id")
+			}
+			return t.Id(), nil
+		},
+	})
+	register(&Command{
+		Name:    "ps",
+		Usage:   "ps",
+		Summary: "List background jobs",
+		Details: `  Each job started with a trailing & is listed with its id, the time since it
+  started and its command line until it finishes.
+
+Examples:
+  cp -r big backup &
+  ps`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) != 0 {
+				return "", usageError("ps")
+			}
+			return t.Ps(), nil
+		},
+	})
+	register(&Command{
+		Name:    "fg",
+		Usage:   "fg [JOB]",
+		Summary: "Wait for a background job in the foreground",
+		Details: `  JOB is a job id from ps, as N or %N, and defaults to the most recent job. A
+  stopped job is resumed. fg prints the job's command line, then waits for the job
+  to finish and print its output. Ctrl-C stops the wait; the job keeps running.
+
+Examples:
+  sleep 10 &
+  fg 1`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", usageError("fg")
+			}
+			job, err := t.findJob(strings.Join(args, ""))
+			if err != nil {
+				return "", fmt.Errorf("fg: %v", err)
+			}
+			return "", t.Fg(job)
+		},
+	})
+	register(&Command{
+		Name:    "bg",
+		Usage:   "bg [JOB]",
+		Summary: "Resume a stopped job in the background",
+		Details: `  JOB is a job id from ps, as N or %N, and defaults to the most recent job.
+
+Examples:
+  stop 1
+  bg 1`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", usageError("bg")
+			}
+			job, err := t.findJob(strings.Join(args, ""))
+			if err != nil {
+				return "", fmt.Errorf("bg: %v", err)
+			}
+			if err := t.ResumeJob(job); err != nil {
+				return "", fmt.Errorf("bg: %v", err)
+			}
+			return fmt.Sprintf("[%d] %s &", job.ID, job.Command), nil
+		},
+	})
+	register(&Command{
+		Name:    "stop",
+		Usage:   "stop [JOB]",
+		Summary: "Stop a background job until bg or fg resumes it",
+		Details: `  JOB is a job id from ps, as N or %N, and defaults to the most recent job. The
+  job stops as Ctrl-Z would stop it in a shell, between commands: a job in the middle
+  of sleep stops once the sleep is over. ps marks it stopped.
+
+Examples:
+  sleep 5 | echo done &
+  stop 1
+  bg 1`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", usageError("stop")
+			}
+			job, err := t.findJob(strings.Join(args, ""))
+			if err != nil {
+				return "", fmt.Errorf("stop: %v", err)
+			}
+			if err := t.StopJob(job); err != nil {
+				return "", fmt.Errorf("stop: %v", err)
+			}
+			return "", nil
+		},
+	})
+	register(&Command{
+		Name:    "sleep",
+		Usage:   "sleep DURATION",
+		Summary: "Wait for a while",
+		Details: `  DURATION is a number of seconds, or a number with a unit: ms, s, m or h.
+  Background jobs and other commands can run while sleep waits. Ctrl-C stops it.
+
+Examples:
+  sleep 2
+  sleep 500ms`,
+		Run: func(t *Terminal, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", usageError("sleep")
+			}
+			d, err := ParseSleep(args[0])
+			if err != nil {
+				return "", fmt.Errorf("sleep: %v", err)
+			}
+			// The context is taken first, since a command running meanwhile may replace it
+			ctx := t.context()
+			t.yield(func() { err = sleep(ctx, d) })
+			return "", err
+		},
+	})
+	register(&Command{
+		Name:    "help",
+		Usage:   "help [COMMAND]",
+		Summary: "Show this help, or detailed help for a command",
+		Run:     help("help"),
+	})
+	register(&Command{
+		Name:    "man",
+		Usage:   "man COMMAND",
+		Summary: "Show detailed help for a command",
+		Run:     help("man"),
+	})
+}
+
+// copyOrMove returns the handler shared by cp and mv
+func copyOrMove(cmd string) func(t *Terminal, args []string) (string, error) {
+	return func(t *Terminal, args []string) (string, error) {
+		var opts CopyOptions
+		var operands []string
+		for _, arg := range args {
+			switch {
+			case arg == "-r" && cmd == "cp":
+				opts.Recursive = true
+			case arg == "-p" && cmd == "cp":
+				opts.Preserve = true
+			case arg == "--backup" || strings.HasPrefix(arg, "--backup="):
+				mode, err := ParseBackupMode(strings.TrimPrefix(strings.TrimPrefix(arg, "--backup"), "="))
+				if err != nil {
+					return "", fmt.Errorf("%s: %v", cmd, err)
+				}
+				opts.Backup = mode
+			default:
+				operands = append(operands, arg)
+			}
+		}
+		if len(operands) < 2 {
+			return "", usageError(cmd)
+		}
+		sources, dest := operands[:len(operands)-1], operands[len(operands)-1]
+		if len(sources) == 1 {
+			if cmd == "cp" {
+				return "", t.FS.CpWith(sources[0], dest, opts)
+			}
+			return "", t.FS.MvWith(sources[0], dest, opts)
+		}
+
+		// Several sources report what happened to them as a whole
+		if cmd == "cp" {
+			summary, err := t.FS.CpAll(sources, dest, opts)
+			return summary.Format("copied"), err
+		}
+		summary, err := t.FS.MvAll(sources, dest, opts)
+		return summary.Format("moved"), err
+	}
+}
+
+// change returns the handler shared by chmod and chown, which apply run to each FILE
+func change(cmd string, run func(fs *FileSystem, arg, path string, opts ChangeOptions) (string, error)) func(t *Terminal, args []string) (string, error) {
+	return func(t *Terminal, args []string) (string, error) {
+		var opts ChangeOptions
+		var operands []string
+		for _, arg := range args {
+			switch arg {
+			case "-R":
+				opts.Recursive = true
+			case "-v":
+				opts.Verbose = true
+			case "-Rv", "-vR":
+				opts.Recursive, opts.Verbose = true, true
+			default:
+				operands = append(operands, arg)
+			}
+		}
+		if len(operands) < 2 {
+			return "", usageError(cmd)
+		}
+		var report []string
+		for _, path := range operands[1:] {
+			output, err := run(t.FS, operands[0], path, opts)
+			if output != "" {
+				report = append(report, output)
+			}
+			if err != nil {
+				return strings.Join(report, "\n"), err
+			}
+		}
+		return strings.Join(report, "\n"), nil
+	}
+}
+
+// parseEchoFlag sets the echo options in arg, such as -n, -e or -ne, and reports whether arg
+// was one; anything else is text to print
+func parseEchoFlag(arg string, opts *EchoOptions) bool {
+	if len(arg) < 2 || arg[0] != '-' || strings.Trim(arg[1:], "ne") != "" {
+		return false
+	}
+	for _, flag := range arg[1:] {
+		if flag == 'n' {
+			opts.NoNewline = true
+		} else {
+			opts.Escapes = true
+		}
+	}
+	return true
+}
+
+// exit returns the handler shared by exit and quit
+func exit(cmd string) func(t *Terminal, args []string) (string, error) {
+	return func(t *Terminal, args []string) (string, error) {
+		if len(args) > 1 {
+			return "", usageError(cmd)
+		}
+		if len(args) == 1 {
+			code, err := strconv.Atoi(args[0])
+			if err != nil {
+				return "", fmt.Errorf("%s: %s: numeric argument required", cmd, args[0])
+			}
+			t.Status = code
+		}
+		if cmd == "quit" {
+			t.Quit()
+		} else {
+			t.Exit()
+		}
+		return "", nil
+	}
+}
+
+// help returns the handler shared by help and man; man requires a command name
+func help(cmd string) func(t *Terminal, args []string) (string, error) {
+	return func(t *Terminal, args []string) (string, error) {
+		if len(args) > 1 {
+			return "", usageError(cmd)
+		}
+		if len(args) == 0 {
+			if cmd == "man" {
+				return "", usageError(cmd)
+			}
+			return t.Help(), nil
+		}
+		c, ok := commands[args[0]]
+		if !ok {
+			return "", fmt.Errorf("%s: no help for '%s'", cmd, args[0])
+		}
+		return c.Help(), nil
+	}
+}
+
+// editCommand returns the Run of edit or view, opening the editor with opts, the line given
+// as +N and, for edit, the script given with -s
+func editCommand(name string, opts EditOptions) func(t *Terminal, args []string) (string, error) {
+	return func(t *Terminal, args []string) (string, error) {
+		opts := opts
+		for len(args) > 1 {
+			if args[0] == "-s" && !opts.ReadOnly {
+				opts.Script, args = args[1], args[2:]
+				continue
+			}
+			if !strings.HasPrefix(args[0], "+") {
+				break
+			}
+			opts.Line = -1
+			if args[0] != "+" {
+				n, err := strconv.Atoi(args[0][1:])
+				if err != nil || n < 1 {
+					return "", fmt.Errorf("%s: invalid line number '%s'", name, args[0][1:])
+				}
+				opts.Line = n
+			}
+			args = args[1:]
+		}
+		if len(args) != 1 || strings.HasPrefix(args[0], "+") || (args[0] == "-s" && !opts.ReadOnly) {
+			return "", usageError(name)
+		}
+		return "", t.EditWith(args[0], opts)
+	}
+}
@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"container/list"
+)
+
+// DefaultResolveCacheSize is the number of resolved paths kept when the cache is enabled
+const DefaultResolveCacheSize = 256
+
+// resolveCache is a fixed-size LRU of absolute paths to the nodes they resolved to
+type resolveCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used at the front
+}
+
+type cacheEntry struct {
+	key  string
+	node *VirtualFile
+}
+
+func newResolveCache(capacity int) *resolveCache {
+	return &resolveCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+func (c *resolveCache) get(key string) (*VirtualFile, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).node, true
+}
+
+func (c *resolveCache) put(key string, node *VirtualFile) {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).node = node
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, node: node})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *resolveCache) clear() {
+	clear(c.entries)
+	c.order.Init()
+}
+
+// SetResolveCache enables an LRU cache of size resolved paths for ResolvePath, or disables it when size <= 0
+func (fs *FileSystem) SetResolveCache(size int) {
+	if size <= 0 {
+		fs.cache = nil
+		return
+	}
+	fs.cache = newResolveCache(size)
+}
+
+// invalidate drops every cached resolution; called on any change to the tree or to permissions
+func (fs *FileSystem) invalidate() {
+	if fs.cache != nil {
+		fs.cache.clear()
+	}
+}
@@ -0,0 +1,93 @@
+package fs
+
+import (
+	"testing"
+)
+
+func TestResolveCacheInvalidation(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("a/b", true)
+	fs.Touch("a/b/c")
+
+	first, err := fs.ResolvePath("a/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, _ := fs.ResolvePath("a/b/c")
+	if cached != first {
+		t.Fatal("repeated lookups should return the same node")
+	}
+
+	// A deleted node must not be served from the cache
+	if err := fs.Rm("a/b/c", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.ResolvePath("a/b/c"); err == nil {
+		t.Error("resolving a removed file should fail")
+	}
+
+	// Recreating the path resolves to the new node
+	fs.Touch("a/b/c")
+	second, err := fs.ResolvePath("a/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Error("cache returned the stale node after recreation")
+	}
+
+	// Moves invalidate both the old and new locations
+	fs.ResolvePath("/home/user/a/b")
+	fs.Mv("a/b", "a/moved")
+	if _, err := fs.ResolvePath("/home/user/a/b"); err == nil {
+		t.Error("old path should not resolve after mv")
+	}
+	if node, _ := fs.ResolvePath("a/moved/c"); node != second {
+		t.Error("new path should resolve to the moved file")
+	}
+
+	// Permission changes affect traversal, so they invalidate too
+	fs.User = "alice"
+	if _, err := fs.ResolvePath("a/moved/c"); err != nil {
+		t.Fatal(err)
+	}
+	fs.User = "user" // Only the owner may change the mode
+	fs.Chmod("700", "a/moved")
+	fs.User = "alice"
+	if _, err := fs.ResolvePath("a/moved/c"); err == nil {
+		t.Error("cached entry should not bypass a permission change")
+	}
+}
+
+func TestResolveCacheEviction(t *testing.T) {
+	cache := newResolveCache(2)
+	a, b, c := NewFile("a", nil, nil), NewFile("b", nil, nil), NewFile("c", nil, nil)
+	cache.put("a", a)
+	cache.put("b", b)
+	cache.get("a")
+	cache.put("c", c)
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("least recently used entry should be evicted")
+	}
+	if node, ok := cache.get("a"); !ok || node != a {
+		t.Error("recently used entry should be kept")
+	}
+}
+
+func BenchmarkResolveDeepPath(b *testing.B) {
+	fs, dir := deepFileSystem(50)
+	path := fs.GetPath(dir)
+	for i := 0; i < b.N; i++ {
+		fs.ResolvePath(path)
+	}
+}
+
+func BenchmarkResolveDeepPathUncached(b *testing.B) {
+	fs, dir := deepFileSystem(50)
+	path := fs.GetPath(dir)
+	fs.SetResolveCache(0)
+	for i := 0; i < b.N; i++ {
+		fs.ResolvePath(path)
+	}
+}
@@ -0,0 +1,179 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Execute parses and runs one line of input as a single undoable step, recording it in History.
+// A command starting with an alias starts with its value instead. Commands joined by | form a pipeline, each receiving the output of the one before in Stdin;
+// a here-string, <<< WORD, gives a command WORD and a newline instead. A command's error
+// message can be written to a file with 2> FILE, printed as output with 2>&1, or written to a
+// file along with its output with &> FILE.
+// A trailing & runs the line as a background job and returns its id in brackets. A line run in
+// the foreground stops early with ErrInterrupted when Interrupt is called.
+func (t *Terminal) Execute(input string) (string, error) {
+	output, _, err := t.execute(input)
+	return output, err
+}
+
+// execute is Execute, also reporting whether the output leaves its last line unended, as the
+// output of echo -n does
+func (t *Terminal) execute(input string) (string, bool, error) {
+	line, background := backgroundCommand(input)
+	stages := SplitPipeline(line)
+	type stage struct {
+		cmd      string
+		args     []string
+		input    string // Here-string input, used when hasIn
+		hasIn    bool
+		redirect redirection
+	}
+	pipeline := make([]stage, len(stages))
+	for i, text := range stages {
+		// Operators are found in the words as typed, so quoting one makes it an argument
+		words, raw, err := splitWords(t.expandAliases(text))
+		if err != nil {
+			return "", false, err
+		}
+		if len(words) == 0 {
+			if len(stages) == 1 {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("syntax error near unexpected token '|'")
+		}
+		cmd := words[0]
+		args, raw, input, hasIn, err := hereString(words[1:], raw[1:])
+		if err != nil {
+			return "", false, err
+		}
+		args, redirect, err := redirections(args, raw)
+		if err != nil {
+			return "", false, err
+		}
+		pipeline[i] = stage{cmd, args, input, hasIn, redirect}
+	}
+	cmd := pipeline[len(pipeline)-1].cmd
+
+	t.shellMu.Lock()
+	t.History = append(t.History, input)
+	t.shellMu.Unlock()
+	failed := false // Whether the last command failed with its error redirected
+	run := func(ctx context.Context) (string, bool, error) {
+		// Jobs and the foreground share the file system, so they take turns running commands
+		t.exec.Lock()
+		t.locked, t.ctx = true, ctx
+		defer func() {
+			t.locked, t.ctx = false, nil
+			t.exec.Unlock()
+		}()
+		partial := false // Whether output leaves its last line unended
+		output, err := t.Track(func() (string, error) {
+			defer func() { t.Stdin, t.hasStdin = "", false }()
+			var output string
+			for i, s := range pipeline {
+				if ctx.Err() != nil {
+					return output, ErrInterrupted
+				}
+				// A command's output is read as the lines it would print
+				t.Stdin, t.hasStdin = output, i > 0
+				if output != "" && !partial {
+					t.Stdin += "\n"
+				}
+				if s.hasIn {
+					t.Stdin, t.hasStdin = s.input, true
+				}
+				out, err := t.ExecuteCommand(s.cmd, s.args)
+				out, failed, err = s.redirect.apply(t, out, err)
+				partial = t.partial
+				if err != nil {
+					return out, err
+				}
+				output = out
+			}
+			return output, nil
+		})
+		return output, partial, err
+	}
+	if background {
+		job := t.startJob(line, run)
+		t.Status = 0
+		return fmt.Sprintf("[%d]", job.ID), false, nil
+	}
+	ctx, done := t.foreground()
+	output, partial, err := run(ctx)
+	done()
+
+	// exit keeps the status of the command before it unless it was given one
+	if errors.Is(err, ErrInterrupted) {
+		t.Status = InterruptStatus
+	} else if err != nil || failed {
+		t.Status = 1
+	} else if cmd != "exit" && cmd != "quit" {
+		t.Status = 0
+	}
+	return output, partial, err
+}
+
+// RunCommand runs one line of input like Execute and returns what the interactive loop
+// prints for it: the output on stdout and any error message on stderr, each ending in a
+// newline when not empty, unless the output was left without one, as by echo -n
+func (t *Terminal) RunCommand(input string) (stdout string, stderr string, err error) {
+	output, partial, err := t.execute(input)
+	stdout = output
+	if output != "" && !partial {
+		stdout += "\n"
+	}
+	if err != nil {
+		stderr = errorText(err)
+	}
+	return stdout, stderr, err
+}
+
+// ExecuteCommand runs a parsed command and returns its output
+func (t *Terminal) ExecuteCommand(cmd string, args []string) (string, error) {
+	t.partial = false
+	c, ok := commands[cmd]
+	if !ok {
+		if match := closestCommand(cmd); match != "" {
+			return "", fmt.Errorf("command not found: %s (did you mean '%s'?)", cmd, match)
+		}
+		return "", fmt.Errorf("command not found: %s", cmd)
+	}
+	return c.Run(t, args)
+}
+
+// Command is a built-in command: the handler that runs it and the help that documents it
+type Command struct {
+	Name    string
+	Usage   string // Synopsis, e.g. "ls [path] [-l]"
+	Summary string // One line for the help overview
+	Details string // Flags and examples shown by help NAME, may be empty
+	Run     func(t *Terminal, args []string) (string, error)
+}
+
+// Help returns the detailed help for the command
+func (c *Command) Help() string {
+	text := "Usage: " + c.Usage + "\n" + c.Summary
+	if c.Details != "" {
+		text += "\n\n" + c.Details
+	}
+	return text
+}
+
+var (
+	commands     = map[string]*Command{} // Built-in commands by name
+	commandOrder []*Command              // Built-in commands in the order help lists them
+)
+
+// usageError reports that cmd was given the wrong arguments, quoting its synopsis
+func usageError(cmd string) error {
+	return fmt.Errorf("%s: usage: %s", cmd, commands[cmd].Usage)
+}
+
+// register adds a built-in command
+func register(c *Command) {
+	commands[c.Name] = c
+	commandOrder = append(commandOrder, c)
+}
@@ -0,0 +1,420 @@
+package fs
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExecuteStatus(t *testing.T) {
+	term := NewTerminal()
+
+	steps := []struct {
+		input  string
+		status int
+	}{
+		{"pwd", 0},
+		{"cat missing.txt", 1},
+		{"touch a.txt", 0},
+		{"rm nope.txt", 1},
+	}
+	for _, step := range steps {
+		term.Execute(step.input)
+		if term.Status != step.status {
+			t.Errorf("%s: expected status %d, got %d", step.input, step.status, term.Status)
+		}
+	}
+
+	// exit keeps the status of the previous command
+	term.Execute("exit")
+	if term.Running || term.Status != 1 {
+		t.Errorf("Expected exit to stop with status 1, got running=%v status=%d", term.Running, term.Status)
+	}
+}
+
+func TestExecuteExitWithStatus(t *testing.T) {
+	term := NewTerminal()
+	if _, err := term.Execute("exit abc"); err == nil || !term.Running {
+		t.Fatal("Expected a non-numeric status to be rejected without exiting")
+	}
+
+	term.Execute("exit 3")
+	if term.Running || term.Status != 3 {
+		t.Errorf("Expected exit with status 3, got running=%v status=%d", term.Running, term.Status)
+	}
+
+	term.Reset()
+	if term.Status != 0 {
+		t.Errorf("Expected Reset to clear the status, got %d", term.Status)
+	}
+}
+
+func TestRunCommandCapturesOutput(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Touch("a.txt")
+
+	tests := []struct {
+		input  string
+		stdout string
+		stderr string
+	}{
+		{"pwd", "/home/user\n", ""},
+		{"ls", "a.txt\n", ""},
+		{"touch c.txt", "", ""},
+		{"cat missing.txt", "", "Error: cat: missing.txt: no such file or directory: missing.txt\n"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, err := term.RunCommand(tt.input)
+		if stdout != tt.stdout || stderr != tt.stderr {
+			t.Errorf("%s: expected %q / %q, got %q / %q", tt.input, tt.stdout, tt.stderr, stdout, stderr)
+		}
+		if (err != nil) != (tt.stderr != "") {
+			t.Errorf("%s: error %v does not match stderr %q", tt.input, err, stderr)
+		}
+	}
+}
+
+func TestTerminalOutWriter(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+
+	term.Execute("clear")
+	if out.String() != "\033[2J\033[H" {
+		t.Errorf("Expected clear to write to Out, got %q", out.String())
+	}
+}
+
+func TestResetCommand(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+
+	term.Execute("reset")
+	if out.String() != "\033c\033[3J\033[2J\033[H" {
+		t.Errorf("Expected reset to write the full reset sequence, got %q", out.String())
+	}
+
+	out.Reset()
+	term.NoANSI = true
+	term.Execute("reset")
+	term.Execute("clear")
+	if out.String() != "" {
+		t.Errorf("Expected no escape sequences with NoANSI, got %q", out.String())
+	}
+}
+
+func TestEditReadsFromIn(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.In = strings.NewReader("hello\nworld\n:wq\n")
+	term.Out = &out
+
+	if _, err := term.Execute("edit notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := term.FS.Cat("notes.txt"); content != "hello\nworld\n" {
+		t.Errorf("Expected the edited lines to be saved, got %q", content)
+	}
+	if !strings.HasSuffix(out.String(), "Saved and quit\n") {
+		t.Errorf("Expected the editor to print to Out, got %q", out.String())
+	}
+}
+
+func TestEditSetNumber(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.In = strings.NewReader(":set nonumber\n:set\n:set number\n:set\n:set wrap\n:q\n")
+	term.Out = &out
+	term.FS.EchoWrite("hello", "notes.txt", false)
+
+	if _, err := term.Execute("edit notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	screens := strings.Split(out.String(), "--- Editor ---\n")[1:]
+	want := []string{
+		"1: hello\n> ",
+		"hello\n> nonumber\n",
+		"hello\n> ",
+		"1: hello\n> number\n",
+		"1: hello\n> Unknown option: wrap\n",
+		"1: hello\n> ",
+	}
+	if len(screens) != len(want) {
+		t.Fatalf("Expected %d screens, got %q", len(want), screens)
+	}
+	for i := range want {
+		if screens[i] != want[i] {
+			t.Errorf("Screen %d: expected %q, got %q", i, want[i], screens[i])
+		}
+	}
+}
+
+func TestEditAtLine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int // Current line marked on the first screen, 0 for none
+	}{
+		{"edit +3 notes.txt", 3},
+		{"edit + notes.txt", 4},
+		{"edit +9 notes.txt", 4},
+		{"edit notes.txt", 0},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		term := NewTerminal()
+		term.In = strings.NewReader("/a\n:q\n")
+		term.Out = &out
+		term.NoANSI = true
+		term.FS.EchoWrite("a\nb\nc\nd", "notes.txt", false)
+
+		if _, err := term.Execute(tt.input); err != nil {
+			t.Fatal(err)
+		}
+		screens := strings.Split(out.String(), "--- Editor ---\n")
+		current := 0
+		for _, line := range strings.Split(screens[1], "\n") {
+			if n, _, found := strings.Cut(line, "> "); found && n != "" {
+				current, _ = strconv.Atoi(n)
+			}
+		}
+		if current != tt.want {
+			t.Errorf("%s: expected the current line to be %d, got %q", tt.input, tt.want, screens[1])
+		}
+		// The search goes on from the current line, wrapping around to the match on line 1
+		if !strings.Contains(screens[2], "1> a\n") {
+			t.Errorf("%s: expected the search to move the marker to line 1, got %q", tt.input, screens[2])
+		}
+	}
+
+	term := NewTerminal()
+	if _, err := term.Execute("edit +x notes.txt"); err == nil || err.Error() != "edit: invalid line number 'x'" {
+		t.Errorf("Expected an invalid line number to be refused, got %v", err)
+	}
+}
+
+func TestEditRefusesBinary(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.In = strings.NewReader(":wq\n")
+	term.Out = &out
+	term.FS.EchoWrite("a\x00b", "blob", false)
+
+	_, err := term.Execute("edit blob")
+	if err == nil || err.Error() != "edit: blob: binary file" {
+		t.Errorf("edit of a binary file should refuse, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("edit should not open the editor on a binary file, got %q", out.String())
+	}
+	if content, _ := term.FS.CatWith("blob", CatOptions{Force: true}); content != "a\x00b\n" {
+		t.Errorf("the binary file should be left alone, got %q", content)
+	}
+}
+
+func TestReadLineSharedWithEdit(t *testing.T) {
+	term := NewTerminal()
+	term.Out = &bytes.Buffer{}
+	term.In = strings.NewReader("edit f.txt\r\ntext\n:wq\npwd")
+
+	// The loop's read buffers everything; edit must still see its lines, and the loop the rest
+	var lines []string
+	for {
+		line, err := term.ReadLine()
+		if err != nil {
+			break
+		}
+		lines = append(lines, line)
+		term.Execute(line)
+	}
+	if len(lines) != 2 || lines[0] != "edit f.txt" || lines[1] != "pwd" {
+		t.Errorf("Expected the loop to read edit and pwd, got %q", lines)
+	}
+	if content, _ := term.FS.Cat("f.txt"); content != "text\n" {
+		t.Errorf("Expected edit to read its line from the shared input, got %q", content)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	term := NewTerminal()
+
+	output, err := term.Execute("help ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Usage: ls", "-l", "-a"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected help ls to mention %q, got %q", want, output)
+		}
+	}
+	if man, _ := term.Execute("man ls"); man != output {
+		t.Errorf("Expected man ls to match help ls, got %q", man)
+	}
+
+	if _, err := term.Execute("help bogus"); err == nil {
+		t.Error("Expected help for an unknown command to fail")
+	}
+	if _, err := term.Execute("man"); err == nil {
+		t.Error("Expected man without a command to fail")
+	}
+
+	// Every listed command must have detailed help
+	overview, _ := term.Execute("help")
+	for name := range commands {
+		if !strings.Contains(overview, "\t"+name) {
+			t.Errorf("Expected the overview to list %s", name)
+		}
+		if _, err := term.Execute("help " + name); err != nil {
+			t.Errorf("help %s: %v", name, err)
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"pwd extra", "pwd: usage: pwd"},
+		{"cd a b", "cd: usage: cd [DIR]"},
+		{"mkdir", "mkdir: usage: mkdir [-p] DIR..."},
+		{"mkdir -p", "mkdir: usage: mkdir [-p] DIR..."},
+		{"touch", "touch: usage: touch FILE..."},
+		{"stat --porcelain", "stat: usage: stat [--porcelain] PATH..."},
+		{"rm", "rm: usage: rm [-r] [-d] PATH..."},
+		{"rm -r", "rm: usage: rm [-r] [-d] PATH..."},
+		{"rmdir", "rmdir: usage: rmdir DIR"},
+		{"undo now", "undo: usage: undo"},
+		{"restore a b", "restore: usage: restore [PATH]"},
+		{"cp a", "cp: usage: cp [-r] [-p] [--backup[=numbered]] SOURCE... DEST"},
+		{"mv a", "mv: usage: mv [--backup[=numbered]] SOURCE... DEST"},
+		{"cat", "cat: usage: cat [-f] [FILE...]"},
+		{"count a b", "count: usage: count [PATH]"},
+		{"fsck -x", "fsck: usage: fsck [-r]"},
+		{"updatedb now", "updatedb: usage: updatedb"},
+		{"locate", "locate: usage: locate PATTERN"},
+		{"chmod 644", "chmod: usage: chmod [-R] [-v] MODE FILE..."},
+		{"chown alice", "chown: usage: chown [-R] [-v] USER[:GROUP] FILE..."},
+		{"edit", "edit: usage: edit [-s SCRIPT] [+N] FILE"},
+		{"edit +3", "edit: usage: edit [-s SCRIPT] [+N] FILE"},
+		{"view", "view: usage: view [+N] FILE"},
+		{"edit -s script", "edit: usage: edit [-s SCRIPT] [+N] FILE"},
+		{"edit f -s", "edit: usage: edit [-s SCRIPT] [+N] FILE"},
+		{"clear screen", "clear: usage: clear"},
+		{"reset screen", "reset: usage: reset"},
+		{"command", "command: usage: command NAME [ARG...]"},
+		{"unalias", "unalias: usage: unalias NAME..."},
+		{"type", "type: usage: type NAME..."},
+		{"exit 1 2", "exit: usage: exit [STATUS]"},
+		{"su a b", "su: usage: su [USER]"},
+		{"sudo", "sudo: usage: sudo COMMAND [ARG...]"},
+		{"whoami me", "whoami: usage: whoami"},
+		{"id me", "id: usage: id"},
+		{"help ls cd", "help: usage: help [COMMAND]"},
+		{"man", "man: usage: man COMMAND"},
+	}
+	for _, tt := range tests {
+		term := NewTerminal()
+		_, err := term.Execute(tt.input)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: expected %q, got %v", tt.input, tt.want, err)
+		}
+	}
+}
+
+func TestCdWithoutArgumentsGoesHome(t *testing.T) {
+	term := NewTerminal()
+	term.Execute("cd /")
+	if _, err := term.Execute("cd"); err != nil || term.FS.Pwd() != "/home/user" {
+		t.Errorf("Expected cd to go home, got %s (%v)", term.FS.Pwd(), err)
+	}
+}
+
+func TestUnknownCommandSuggestion(t *testing.T) {
+	term := NewTerminal()
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sl", "command not found: sl (did you mean 'ls'?)"},
+		{"mkdri new", "command not found: mkdri (did you mean 'mkdir'?)"},
+		{"ehco hi", "command not found: ehco (did you mean 'echo'?)"},
+		{"xyzzyplugh", "command not found: xyzzyplugh"},
+		{"qq", "command not found: qq"},
+	}
+	for _, tt := range tests {
+		_, err := term.Execute(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEchoNoNewline(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+
+	if stdout, _, _ := term.RunCommand("echo -n hi"); stdout != "hi" {
+		t.Errorf("echo -n should print hi without a newline, got %q", stdout)
+	}
+	if stdout, _, _ := term.RunCommand("echo -n hi | wc -c"); stdout != "2\n" {
+		t.Errorf("echo -n should pipe exactly its text, got %q", stdout)
+	}
+	if stdout, _, _ := term.RunCommand("echo -n hi &> f.txt"); stdout != "" {
+		t.Errorf("&> should leave nothing on the terminal, got %q", stdout)
+	}
+	if content, _ := term.FS.Cat("f.txt"); content != "hi" {
+		t.Errorf("&> should write exactly the text, got %q", content)
+	}
+
+	term.Execute("echo -n later &")
+	term.WaitJobs()
+	if out.String() != "later" {
+		t.Errorf("A job should print echo -n output without a newline when done, got %q", out.String())
+	}
+}
+
+func TestEchoFlags(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+
+	files := []struct {
+		input    string
+		path     string
+		expected string
+	}{
+		{"echo hello world > plain.txt", "plain.txt", "hello world\n"},
+		{"echo -n hi > n.txt", "n.txt", "hi"},
+		{"echo -n more >> n.txt", "n.txt", "himore"},
+		{`echo -e 'a\tb\nc\\d' > e.txt`, "e.txt", "a\tb\nc\\d\n"},
+		{`echo 'a\tb' > raw.txt`, "raw.txt", "a\\tb\n"},
+		{`echo -ne 'x\n' > ne.txt`, "ne.txt", "x\n"},
+		{`echo -e 'keep \q' > other.txt`, "other.txt", "keep \\q\n"},
+	}
+	for _, tt := range files {
+		if _, err := term.Execute(tt.input); err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if content, _ := term.FS.Cat(tt.path); content != tt.expected {
+			t.Errorf("%s: expected %q in %s, got %q", tt.input, tt.expected, tt.path, content)
+		}
+	}
+
+	if output, _ := term.Execute(`echo -e 'a\tb'`); output != "a\tb" {
+		t.Errorf("echo -e should expand escapes, got %q", output)
+	}
+	if output, _ := term.Execute("echo -x -n"); output != "-x -n" {
+		t.Errorf("Only leading flags should be options, got %q", output)
+	}
+	output, _ := term.Execute("echo -n prompt")
+	if output != "prompt" || out.String() != "" {
+		t.Errorf("echo -n should return its text as output, got %q and %q", output, out.String())
+	}
+	if _, err := term.Execute("echo hi >"); err == nil {
+		t.Error("A redirection without a file should error")
+	}
+}
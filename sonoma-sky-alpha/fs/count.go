@@ -0,0 +1,43 @@
+package fs
+
+import (
+	"fmt"
+)
+
+// Counts summarizes the contents of a subtree
+type Counts struct {
+	Files int
+	Dirs  int
+	Bytes int64
+}
+
+// String formats the counts as a one-line inventory
+func (c Counts) String() string {
+	return fmt.Sprintf("%d files, %d directories, %d bytes", c.Files, c.Dirs, c.Bytes)
+}
+
+// Count returns the number of files and directories below path (excluding path itself)
+// and the total bytes of file content
+func (fs *FileSystem) Count(path string) (Counts, error) {
+	if path == "" {
+		path = "."
+	}
+
+	top, err := fs.ResolvePath(path)
+	if err != nil {
+		return Counts{}, fmt.Errorf("count: %s: %v", path, err)
+	}
+
+	var counts Counts
+	walk(top, fs.GetPath(top), func(_ string, file *VirtualFile) {
+		if file.Type == Directory {
+			if file != top {
+				counts.Dirs++
+			}
+			return
+		}
+		counts.Files++
+		counts.Bytes += int64(len(file.Content))
+	})
+	return counts, nil
+}
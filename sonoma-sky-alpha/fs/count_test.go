@@ -0,0 +1,39 @@
+package fs
+
+import (
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("project/src/util", true)
+	fs.EchoWrite("hello", "project/README", false)
+	fs.EchoWrite("package main", "project/src/main.go", false)
+	fs.Touch("project/src/util/empty.go")
+
+	counts, err := fs.Count("project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.Files != 3 {
+		t.Errorf("Expected 3 files, got %d", counts.Files)
+	}
+	if counts.Dirs != 2 {
+		t.Errorf("Expected 2 directories, got %d", counts.Dirs)
+	}
+	if counts.Bytes != 19 {
+		t.Errorf("Expected 19 bytes, got %d", counts.Bytes)
+	}
+	if counts.String() != "3 files, 2 directories, 19 bytes" {
+		t.Errorf("Unexpected summary %q", counts.String())
+	}
+
+	counts, _ = fs.Count("project/README")
+	if counts.Files != 1 || counts.Dirs != 0 || counts.Bytes != 6 {
+		t.Errorf("Counting a file should report just that file, got %+v", counts)
+	}
+
+	if _, err := fs.Count("missing"); err == nil {
+		t.Error("count of a missing path should error")
+	}
+}
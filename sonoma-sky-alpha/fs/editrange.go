@@ -0,0 +1,91 @@
+package fs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseRange parses an editor line range, M,N or a single line M, against a buffer of n lines.
+// The lines are numbered from 1 and the range returned is the slice bounds of the lines.
+func parseRange(spec string, n int) (int, int, error) {
+	first, last, found := strings.Cut(spec, ",")
+	if !found {
+		last = first
+	}
+	from, err1 := strconv.Atoi(first)
+	to, err2 := strconv.Atoi(last)
+	if err1 != nil || err2 != nil || from < 1 || to < from || to > n {
+		return 0, 0, fmt.Errorf("invalid range: %s", spec)
+	}
+	return from - 1, to, nil
+}
+
+// parseLine parses the line after which an editor command puts lines, 0 for the top
+func parseLine(spec string, n int) (int, error) {
+	line, err := strconv.Atoi(spec)
+	if err != nil || line < 0 || line > n {
+		return 0, fmt.Errorf("invalid line: %s", spec)
+	}
+	return line, nil
+}
+
+// insertLines returns lines with more inserted after the first after lines
+func insertLines(lines []string, after int, more []string) []string {
+	result := append(append([]string(nil), lines[:after]...), more...)
+	return append(result, lines[after:]...)
+}
+
+// plural returns "1 line" or "N lines"
+func plural(n int) string {
+	if n == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", n)
+}
+
+// rangeCommand runs an editor range command on lines: d M,N deletes the lines, m M,N P moves
+// them after line P, y M,N copies them to the clipboard and p P pastes the clipboard after line
+// P. It returns the new lines and a message reporting what was done.
+func rangeCommand(lines []string, clipboard *[]string, name string, args []string) ([]string, string, error) {
+	switch {
+	case (name == "d" || name == "y") && len(args) == 1:
+		from, to, err := parseRange(args[0], len(lines))
+		if err != nil {
+			return lines, "", err
+		}
+		*clipboard = append([]string(nil), lines[from:to]...)
+		if name == "y" {
+			return lines, "Yanked " + plural(to-from), nil
+		}
+		return insertLines(lines[:from], from, lines[to:]), "Deleted " + plural(to-from), nil
+	case name == "m" && len(args) == 2:
+		from, to, err := parseRange(args[0], len(lines))
+		if err != nil {
+			return lines, "", err
+		}
+		after, err := parseLine(args[1], len(lines))
+		if err != nil {
+			return lines, "", err
+		}
+		if after > from && after < to {
+			return lines, "", fmt.Errorf("cannot move lines %s after a line inside them", args[0])
+		}
+		moved := append([]string(nil), lines[from:to]...)
+		rest := insertLines(lines[:from], from, lines[to:])
+		if after >= to {
+			after -= to - from
+		}
+		return insertLines(rest, after, moved), "Moved " + plural(len(moved)), nil
+	case name == "p" && len(args) == 1:
+		after, err := parseLine(args[0], len(lines))
+		if err != nil {
+			return lines, "", err
+		}
+		if len(*clipboard) == 0 {
+			return lines, "", fmt.Errorf("nothing yanked")
+		}
+		return insertLines(lines, after, *clipboard), "Pasted " + plural(len(*clipboard)), nil
+	}
+	return lines, "", fmt.Errorf("usage: d M,N | m M,N P | y M,N | p P")
+}
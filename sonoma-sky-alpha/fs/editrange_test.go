@@ -0,0 +1,84 @@
+package fs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// editSession edits notes.txt holding content with the editor input script and returns the
+// saved content and what the editor printed
+func editSession(t *testing.T, content, script string) (string, string) {
+	t.Helper()
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+	term.FS.EchoWrite(content, "notes.txt", false)
+	term.In = strings.NewReader(script)
+	if _, err := term.Execute("edit notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := term.FS.Cat("notes.txt")
+	return saved, out.String()
+}
+
+func TestEditDeleteRange(t *testing.T) {
+	saved, out := editSession(t, "1\n2\n3\n4\n5", ":d 2,4\n:wq\n")
+	if saved != "1\n5\n" {
+		t.Errorf("Expected lines 2 to 4 to be deleted, got %q", saved)
+	}
+	if !strings.Contains(out, "Deleted 3 lines\n") {
+		t.Errorf("Expected the delete to be reported, got %q", out)
+	}
+}
+
+func TestEditMoveRange(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{":m 1,2 4\n", "3\n4\n1\n2\n5\n"},
+		{":m 4,5 0\n", "4\n5\n1\n2\n3\n"},
+		{":m 2 2\n", "1\n2\n3\n4\n5\n"},
+		{":m 1,3 2\n", "1\n2\n3\n4\n5\n"}, // Into itself: refused
+	}
+	for _, tt := range tests {
+		if saved, _ := editSession(t, "1\n2\n3\n4\n5", tt.script+":wq\n"); saved != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.script, tt.want, saved)
+		}
+	}
+}
+
+func TestEditYankPaste(t *testing.T) {
+	saved, _ := editSession(t, "a\nb\nc", ":y 1,2\n:p 3\n:d 1\n:p 0\n:wq\n")
+	if saved != "a\nb\nc\na\nb\n" {
+		t.Errorf("Expected the yanked lines pasted at the end, then the deleted line back on top, got %q", saved)
+	}
+}
+
+func TestEditRangeErrors(t *testing.T) {
+	saved, out := editSession(t, "a\nb", ":d 2,5\n:d x\n:p 1\n:m 1 9\n:wq\n")
+	if saved != "a\nb\n" {
+		t.Errorf("Invalid range commands should leave the buffer alone, got %q", saved)
+	}
+	for _, want := range []string{"invalid range: 2,5", "invalid range: x", "nothing yanked", "invalid line: 9"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q to be reported, got %q", want, out)
+		}
+	}
+}
+
+func TestEditUndo(t *testing.T) {
+	saved, out := editSession(t, "a\nb\nc", "d\n:d 2\n:u\n:u\n:u\n:wq\n")
+	if saved != "a\nb\nc\n" {
+		t.Errorf("Expected undoing the insert and the delete to restore the buffer, got %q", saved)
+	}
+	if strings.Count(out, "Undone\n") != 2 || !strings.Contains(out, "Nothing to undo\n") {
+		t.Errorf("Expected two undos, then nothing left to undo, got %q", out)
+	}
+
+	saved, _ = editSession(t, "a\nb\nc", "d\n:d 2\n:u\n:wq\n")
+	if saved != "a\nb\nc\nd\n" {
+		t.Errorf("Expected a single undo to only restore the deleted line, got %q", saved)
+	}
+}
@@ -0,0 +1,22 @@
+package fs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// saveBufferAs writes the editor's lines to the file at path, creating it and any missing
+// directories on the way, and returns the file
+func (t *Terminal) saveBufferAs(lines []string, path string) (*VirtualFile, error) {
+	if dirPath, _ := filepath.Split(path); dirPath != "" {
+		if err := t.FS.Mkdir(dirPath, true); err != nil {
+			return nil, fmt.Errorf("cannot save %s: %s", path, strings.TrimPrefix(err.Error(), "mkdir: "))
+		}
+	}
+	content := strings.Join(lines, "\n") + "\n"
+	if err := t.FS.EchoWriteWith(content, path, false, EchoOptions{NoNewline: true}); err != nil {
+		return nil, fmt.Errorf("cannot save %s: %s", path, strings.TrimPrefix(err.Error(), "echo: "))
+	}
+	return t.FS.ResolvePath(path)
+}
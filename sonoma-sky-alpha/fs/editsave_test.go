@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEditSaveTo(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+	term.FS.EchoWrite("one", "a.txt", false)
+	term.In = strings.NewReader("two\n:w copies/b.txt\nthree\n:wq\n")
+
+	if _, err := term.Execute("edit a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := term.FS.Cat("copies/b.txt"); content != "one\ntwo\n" {
+		t.Errorf("Expected the buffer as it was to be saved to b.txt, got %q", content)
+	}
+	if content, _ := term.FS.Cat("a.txt"); content != "one\ntwo\nthree\n" {
+		t.Errorf("Expected :wq to still save to a.txt, got %q", content)
+	}
+	if !strings.Contains(out.String(), "Saved to copies/b.txt\n") {
+		t.Errorf("Expected the save to be reported, got %q", out.String())
+	}
+}
+
+func TestEditSaveAs(t *testing.T) {
+	term := NewTerminal()
+	term.Out = &bytes.Buffer{}
+	term.FS.EchoWrite("one", "a.txt", false)
+	term.In = strings.NewReader(":saveas b.txt\ntwo\n:wq\n")
+
+	if _, err := term.Execute("edit a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := term.FS.Cat("a.txt"); content != "one\n" {
+		t.Errorf("Expected a.txt to be left alone after :saveas, got %q", content)
+	}
+	if content, _ := term.FS.Cat("b.txt"); content != "one\ntwo\n" {
+		t.Errorf("Expected :wq to save to b.txt after :saveas, got %q", content)
+	}
+}
+
+func TestEditSaveToError(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+	term.FS.EchoWrite("one", "a.txt", false)
+	term.In = strings.NewReader(":w a.txt/b.txt\n:q\n")
+
+	if _, err := term.Execute("edit a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "cannot save a.txt/b.txt: ") {
+		t.Errorf("Expected the failed save to be reported, got %q", out.String())
+	}
+}
+
+func TestEditQuit(t *testing.T) {
+	const warning = "unsaved changes (use :q! to discard)\n"
+	tests := []struct {
+		name   string
+		script string
+		want   string // Content of a.txt afterwards
+		warned bool
+	}{
+		{"clean", ":q\n", "one\n", false},
+		{"dirty", "two\n:q\n", "one\n", true},
+		{"force", "two\n:q!\n", "one\n", false},
+		{"saved", "two\n:w\n:q\n", "one\ntwo\n", false},
+		{"saved by name", "two\n:w a.txt\n:q\n", "one\ntwo\n", false},
+		{"saved by path", "two\n:w /home/user/a.txt\n:q\n", "one\ntwo\n", false},
+		{"saved elsewhere", "two\n:w b.txt\n:q\n", "one\n", true},
+		{"undone", "two\n:u\n:q\n", "one\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			term := NewTerminal()
+			term.Out = &out
+			term.FS.EchoWrite("one", "a.txt", false)
+			// A blocked :q leaves the editor reading on; the final :q! ends it either way
+			term.In = strings.NewReader(tt.script + "three\n:q!\n")
+
+			if _, err := term.Execute("edit a.txt"); err != nil {
+				t.Fatal(err)
+			}
+			if content, _ := term.FS.Cat("a.txt"); content != tt.want {
+				t.Errorf("Expected a.txt to hold %q, got %q", tt.want, content)
+			}
+			if strings.Contains(out.String(), warning) != tt.warned {
+				t.Errorf("Expected warned=%v, got %q", tt.warned, out.String())
+			}
+			// Once :q is allowed the editor is done, so the line after it is never added
+			if strings.Contains(out.String(), ": three\n") != tt.warned {
+				t.Errorf("Expected the editor to go on reading=%v, got %q", tt.warned, out.String())
+			}
+		})
+	}
+}
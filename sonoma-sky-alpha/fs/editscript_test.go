@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEditScript(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+	term.In = strings.NewReader("left for the command loop\n")
+	term.FS.EchoWrite("one\ntwo\nthree", "notes.txt", false)
+	term.FS.EchoWrite("zero\n:m 4 0\n:d 3\n:y 1,2\n:p 3\nfour", "fix.ed", false)
+
+	if _, err := term.Execute("edit -s fix.ed notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := term.FS.Cat("notes.txt"); content != "zero\none\nthree\nzero\none\nfour\n" {
+		t.Errorf("Expected the script's edits to be saved, got %q", content)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected a script to run without showing the editor, got %q", out.String())
+	}
+	if line, _ := term.ReadLine(); line != "left for the command loop" {
+		t.Errorf("Expected a script not to read from In, got %q left", line)
+	}
+}
+
+func TestEditScriptFails(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"two\n:d 5\nthree", "edit: fix.ed: line 2: invalid range: 5"},
+		{"/missing", "edit: fix.ed: line 1: Pattern not found: missing"},
+		{"two\n:q", "edit: fix.ed: line 2: unsaved changes (use :q! to discard)"},
+	}
+	for _, tt := range tests {
+		term := NewTerminal()
+		term.Out = &bytes.Buffer{}
+		term.FS.EchoWrite("one", "notes.txt", false)
+		term.FS.EchoWrite(tt.script, "fix.ed", false)
+
+		_, err := term.Execute("edit -s fix.ed notes.txt")
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: expected %q, got %v", tt.script, tt.want, err)
+		}
+		if content, _ := term.FS.Cat("notes.txt"); content != "one\n" {
+			t.Errorf("%q: expected a failed script to leave the file alone, got %q", tt.script, content)
+		}
+	}
+
+	term := NewTerminal()
+	_, err := term.Execute("edit -s missing.ed notes.txt")
+	if err == nil || !strings.HasPrefix(err.Error(), "edit: missing.ed: ") {
+		t.Errorf("Expected a missing script to be reported, got %v", err)
+	}
+	if _, err := term.FS.ResolvePath("notes.txt"); err == nil {
+		t.Error("Expected a missing script not to create the file")
+	}
+}
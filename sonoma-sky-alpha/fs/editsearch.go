@@ -0,0 +1,34 @@
+package fs
+
+import "strings"
+
+// Highlighting of the editor's search match
+const (
+	highlightOn  = "\033[7m" // Reverse video
+	highlightOff = "\033[27m"
+)
+
+// editorSearch is the editor's /PATTERN search
+type editorSearch struct {
+	pattern string // The last pattern searched for, repeated by n
+}
+
+// next finds the first line after line current that contains the pattern, wrapping around
+// to the top. current is -1 to start from the top.
+func (s *editorSearch) next(lines []string, current int) (int, bool) {
+	for i := 1; i <= len(lines); i++ {
+		n := (current + i) % len(lines)
+		if strings.Contains(lines[n], s.pattern) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// highlight returns line with the pattern shown in reverse video
+func (s *editorSearch) highlight(line string) string {
+	if s.pattern == "" {
+		return line
+	}
+	return strings.Replace(line, s.pattern, highlightOn+s.pattern+highlightOff, 1)
+}
@@ -0,0 +1,58 @@
+package fs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEditSearch(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+	term.NoANSI = true
+	term.FS.EchoWrite("alpha\nbeta\ngamma\nbeta again", "notes.txt", false)
+	term.In = strings.NewReader("/beta\nn\nn\n/\n/delta\n:wq\n")
+
+	if _, err := term.Execute("edit notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	var reports []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "> ") {
+			reports = append(reports, strings.TrimPrefix(line, "> "))
+		}
+	}
+	want := []string{
+		"Found at line 2",
+		"Found at line 4",
+		"Found at line 2",
+		"Found at line 4",
+		"Pattern not found: delta",
+	}
+	if len(reports) < len(want) || strings.Join(reports[:len(want)], "|") != strings.Join(want, "|") {
+		t.Errorf("Expected the searches to report %q, got %q", want, reports)
+	}
+	if content, _ := term.FS.Cat("notes.txt"); content != "alpha\nbeta\ngamma\nbeta again\n" {
+		t.Errorf("n after a search should not be added to the buffer, got %q", content)
+	}
+}
+
+func TestEditSearchHighlight(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+	term.FS.EchoWrite("one\ntwo", "notes.txt", false)
+	term.In = strings.NewReader("/tw\nn\nthree\nn\n:wq\n")
+
+	if _, err := term.Execute("edit notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2> "+highlightOn+"tw"+highlightOff+"o\n") {
+		t.Errorf("Expected the match to be highlighted, got %q", out.String())
+	}
+	// n is only a search right after one; otherwise it is a line like any other
+	if content, _ := term.FS.Cat("notes.txt"); content != "one\ntwo\nthree\nn\n" {
+		t.Errorf("Expected n to be appended once the search is over, got %q", content)
+	}
+}
@@ -0,0 +1,21 @@
+package fs
+
+import "strings"
+
+// changesBuffer reports whether an editor input line changes the buffer or writes it out:
+// a line of text to append, or a command such as :d or :w. View mode refuses those.
+func changesBuffer(input string) bool {
+	cmd, ok := strings.CutPrefix(input, ":")
+	if !ok {
+		return input != ""
+	}
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "w", "wq", "saveas", "d", "m", "p", "u":
+		return true
+	}
+	return false
+}
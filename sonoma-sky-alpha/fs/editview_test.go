@@ -0,0 +1,47 @@
+package fs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+	term.NoANSI = true
+	term.FS.EchoWrite("alpha\nbeta", "notes.txt", false)
+	term.In = strings.NewReader("gamma\n:d 1\n:u\n:w\n:w copy.txt\n:wq\n/beta\n:y 1\n:q\n")
+
+	if _, err := term.Execute("view notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), "> read-only\n"); got != 6 {
+		t.Errorf("Expected the 6 changes and saves to be refused, got %d in %q", got, out.String())
+	}
+	for _, want := range []string{"Found at line 2\n", "Yanked 1 line\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected searching and yanking to work, missing %q in %q", want, out.String())
+		}
+	}
+	if content, _ := term.FS.Cat("notes.txt"); content != "alpha\nbeta\n" {
+		t.Errorf("Expected view to leave the file alone, got %q", content)
+	}
+	if _, err := term.FS.ResolvePath("copy.txt"); err == nil {
+		t.Error("Expected view to refuse saving to another file")
+	}
+}
+
+func TestViewMissingFile(t *testing.T) {
+	term := NewTerminal()
+	term.Out = &bytes.Buffer{}
+
+	_, err := term.Execute("view missing.txt")
+	if err == nil || !strings.HasPrefix(err.Error(), "view: missing.txt: ") {
+		t.Errorf("Expected view of a missing file to fail, got %v", err)
+	}
+	if _, err := term.FS.ResolvePath("missing.txt"); err == nil {
+		t.Error("Expected view not to create the file")
+	}
+}
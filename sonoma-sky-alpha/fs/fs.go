@@ -2,10 +2,18 @@ package fs
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,31 +24,120 @@ const (
 	Directory
 )
 
+// String names the type as error messages describe it
+func (t FileType) String() string {
+	if t == Directory {
+		return "directory"
+	}
+	return "regular file"
+}
+
 type VirtualFile struct {
 	Name        string
 	Type        FileType
-	Content     []byte                  // For files
+	Content     []byte                  // For files; shared between copies, so never modified in place
 	Children    map[string]*VirtualFile // For directories
 	Parent      *VirtualFile
 	Permissions uint32
 	ModTime     time.Time
 	Size        int64
+	Owner       string
+	Group       string
+	Attrs       map[string]string // Extended attributes set with setattr, nil until the first one
+	Ino         uint64            // Inode number, unique to the node and kept when it is moved or renamed
+
+	path    string // Cached absolute path, valid while pathGen matches the file system's
+	pathGen uint64
 }
 
 type FileSystem struct {
 	Root       *VirtualFile
 	CurrentDir *VirtualFile
 	PrevDir    *VirtualFile // For cd -
+	Home       string       // Path that ~ resolves to
+	Users      []string     // Users given a home in the pristine tree, the first being the initial user
+	User       string       // Owner assigned to newly created files
+	Index      []string     // Paths recorded by the last UpdateDB, nil until built
+	Trash      []TrashEntry // Nodes removed by rm, oldest first
+	TrashLimit int          // Maximum number of trash entries kept
+	MaxOutput  int          // Bytes cat prints before truncating, 0 for no limit
+	MaxDepth   int          // Components a path may have before resolving fails, 0 for no limit
+	Umask      uint32       // Permission bits cleared from newly created files and directories
+	CdHint     bool         // cd to a file suggests its parent directory; off unless -cd-hint is given, as in POSIX shells
+
+	journal *[]Operation  // Receives changes while a Terminal is tracking a command
+	pathGen uint64        // Bumped whenever a node is moved, renamed or unlinked
+	cache   *resolveCache // Resolved paths, nil when disabled with SetResolveCache(0)
 }
 
 type Terminal struct {
-	FS      *FileSystem
-	History []string
-	Running bool
+	FS        *FileSystem
+	History   []string
+	Running   bool
+	User      string
+	Status    int               // Exit status of the last command: 0 on success, 1 if it failed, 130 if interrupted
+	Prompt    string            // Prompt format; \u expands to the user, \w to the working directory
+	In        io.Reader         // Input for the command loop and commands that read, such as the editor
+	Out       io.Writer         // Receives output printed while a command runs, such as the editor
+	Err       io.Writer         // Receives error messages
+	Stdin     string            // Input from the previous command in a pipeline or a here-string, read by commands such as xargs
+	NoANSI    bool              // Print no escape sequences, for terminals that don't understand them
+	IgnoreEOF int               // Ends of input (Ctrl-D) ignored in a row before one exits, as IGNOREEOF in bash
+	RawInput  bool              // In delivers keystrokes as typed, unechoed: ReadLine edits the line, with Ctrl-R searching History
+	Aliases   map[string]string // Names that stand for a command line at the start of a command
+
+	exec        sync.Mutex      // Held while a command line runs, so background jobs and the foreground take turns
+	locked      bool            // Whether the running command line holds exec
+	hasStdin    bool            // Whether the running command has Stdin attached, so cat and wc read it
+	partial     bool            // Whether the output of the last command leaves its last line unended, as echo -n does
+	ctx         context.Context // Context of the running command line, cancelled when it is interrupted
+	interruptMu sync.Mutex      // Guards interrupt
+	interrupt   func()          // Cancels the foreground command line, nil when none is running
+	jobsMu      sync.Mutex      // Guards jobs and lastJob
+	jobs        []*Job          // Background jobs still running, oldest first
+	lastJob     int             // Id of the most recently started job
+	shellMu     sync.Mutex      // Guards Aliases and History, which a command line uses before it takes exec
+
+	UndoStack []Operation // Changes made by previous commands, most recent last
+	UndoDepth int         // Maximum number of commands kept on UndoStack
+
+	suStack []string // Users to return to when exiting su sessions
+
+	reader    *bufio.Reader // Buffers In; shared so read-ahead is never lost between readers
+	readerSrc io.Reader     // The In that reader wraps
+	pending   chan lineRead // A read still waiting for its line, which the next ReadLine returns
 }
 
+// DefaultUser is the user a new terminal starts as
+const DefaultUser = "user"
+
+// DefaultMaxDepth is the default number of components ResolvePath accepts in a path
+const DefaultMaxDepth = 256
+
+// DefaultUmask is the umask a new file system starts with, giving 0755 directories and 0644 files
+const DefaultUmask = 0022
+
+// Modes of newly created directories and files before the umask is applied
+const (
+	DirMode  = 0777
+	FileMode = 0666
+)
+
+// DefaultMaxOutput is the default number of bytes cat prints before truncating
+const DefaultMaxOutput = 10 << 20
+
+// TruncatedNotice is printed after output cut short by MaxOutput
+const TruncatedNotice = "[output truncated]"
+
+// DefaultPrompt is the prompt format used when none is configured
+const DefaultPrompt = `\w$ `
+
+// lastIno is the inode number most recently given to a node
+var lastIno atomic.Uint64
+
 func NewDirectory(name string, parent *VirtualFile) *VirtualFile {
 	return &VirtualFile{
+		Ino:         lastIno.Add(1),
 		Name:        name,
 		Type:        Directory,
 		Children:    make(map[string]*VirtualFile),
@@ -48,12 +145,15 @@ func NewDirectory(name string, parent *VirtualFile) *VirtualFile {
 		Permissions: 0755,
 		ModTime:     time.Now(),
 		Size:        0,
+		Owner:       DefaultUser,
+		Group:       DefaultUser,
 	}
 }
 
 func NewFile(name string, parent *VirtualFile, content []byte) *VirtualFile {
 	size := int64(len(content))
 	return &VirtualFile{
+		Ino:         lastIno.Add(1),
 		Name:        name,
 		Type:        RegularFile,
 		Content:     content,
@@ -61,55 +161,340 @@ func NewFile(name string, parent *VirtualFile, content []byte) *VirtualFile {
 		Permissions: 0644,
 		ModTime:     time.Now(),
 		Size:        size,
+		Owner:       DefaultUser,
+		Group:       DefaultUser,
 	}
 }
 
-func NewFileSystem() *FileSystem {
+// setContent replaces a file's content and updates its size and modification time
+func (f *VirtualFile) setContent(content []byte) {
+	f.Content = content
+	f.Size = int64(len(content))
+	f.ModTime = time.Now()
+}
+
+// newTree builds the pristine tree of / and /home/user, returning the root and the user directory
+func newTree(users []string) (*VirtualFile, *VirtualFile) {
 	root := NewDirectory("", nil)
 	root.Name = "/"
 
 	home := NewDirectory("home", root)
-	user := NewDirectory("user", home)
-
 	root.Children["home"] = home
-	home.Children["user"] = user
-	home.Parent = root
-	user.Parent = home
+
+	// / and /home belong to the first user, each home to its own user
+	var first *VirtualFile
+	for _, name := range users {
+		parent := home
+		if name == RootUser {
+			parent = root
+		}
+		dir := NewDirectory(filepath.Base(homeDir(name)), parent)
+		dir.Owner, dir.Group = name, name
+		parent.Children[dir.Name] = dir
+		if first == nil {
+			first = dir
+			root.Owner, root.Group = name, name
+			home.Owner, home.Group = name, name
+		}
+	}
+	return root, first
+}
+
+// homeDir returns the home directory of a user: /root for root and /home/<name> for anyone else
+func homeDir(name string) string {
+	if name == RootUser {
+		return "/root"
+	}
+	return "/home/" + name
+}
+
+// checkUserName rejects user names that cannot name a home directory
+func checkUserName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid user name: %q", name)
+	}
+	return nil
+}
+
+// NewFileSystem returns a file system for DefaultUser, with its home at /home/user
+func NewFileSystem() *FileSystem {
+	fs, _ := NewFileSystemFor(DefaultUser)
+	return fs
+}
+
+// NewFileSystemFor returns a file system with a home directory for each of users, owned by
+// them. The first user is the current one and starts in their home; without users it is
+// DefaultUser.
+func NewFileSystemFor(users ...string) (*FileSystem, error) {
+	if len(users) == 0 {
+		users = []string{DefaultUser}
+	}
+	for _, name := range users {
+		if err := checkUserName(name); err != nil {
+			return nil, err
+		}
+	}
+	root, user := newTree(users)
 
 	return &FileSystem{
 		Root:       root,
 		CurrentDir: user,
 		PrevDir:    root,
+		Home:       homeDir(users[0]),
+		User:       users[0],
+		Users:      users,
+		TrashLimit: DefaultTrashLimit,
+		MaxOutput:  DefaultMaxOutput,
+		MaxDepth:   DefaultMaxDepth,
+		Umask:      DefaultUmask,
+		cache:      newResolveCache(DefaultResolveCacheSize),
+	}, nil
+}
+
+// Reset discards every change and restores the pristine tree, keeping settings such as the
+// current user, trash limit and output limit. The current user's home is recreated and entered.
+func (fs *FileSystem) Reset() {
+	root, user := newTree(fs.Users)
+	fs.Root = root
+	fs.CurrentDir = user
+	fs.PrevDir = root
+	fs.Index = nil
+	fs.Trash = nil
+	fs.pathGen++
+	fs.invalidate()
+
+	if fs.Home != homeDir(fs.Users[0]) {
+		// Create the home as root, as SetUser does, then hand it to the user
+		user := fs.User
+		fs.User = RootUser
+		fs.Mkdir(fs.Home, true)
+		fs.Chown(user, fs.Home)
+		fs.User = user
+		fs.Cd(fs.Home)
+	}
+	fs.PrevDir = root
+}
+
+// own assigns the current user as owner and group of a newly created file, and the
+// permissions of its type masked by the umask
+func (fs *FileSystem) own(file *VirtualFile) *VirtualFile {
+	file.Owner = fs.User
+	file.Group = fs.User
+	if file.Type == Directory {
+		file.Permissions = DirMode &^ fs.Umask
+	} else {
+		file.Permissions = FileMode &^ fs.Umask
 	}
+	return file
+}
+
+// ownCopy is own for a copy of src, which starts from the mode of src masked by the umask
+// rather than the default mode, so a copy is never more open than its source
+func (fs *FileSystem) ownCopy(file, src *VirtualFile) *VirtualFile {
+	fs.own(file)
+	file.Permissions = src.Permissions &^ fs.Umask
+	return file
 }
 
 func NewTerminal() *Terminal {
-	fs := NewFileSystem()
+	t, _ := NewTerminalFor(DefaultUser)
+	return t
+}
+
+// NewTerminalFor returns a terminal whose file system has a home for each of users, logged
+// in as the first, as NewFileSystemFor describes
+func NewTerminalFor(users ...string) (*Terminal, error) {
+	fs, err := NewFileSystemFor(users...)
+	if err != nil {
+		return nil, err
+	}
 	return &Terminal{
-		FS:      fs,
-		History: []string{},
-		Running: true,
+		FS:        fs,
+		History:   []string{},
+		Running:   true,
+		User:      fs.User,
+		Prompt:    DefaultPrompt,
+		In:        os.Stdin,
+		Out:       os.Stdout,
+		Err:       os.Stderr,
+		UndoDepth: DefaultUndoDepth,
+	}, nil
+}
+
+// Reset returns the terminal to a fresh session: pristine file system, no history or aliases,
+// nothing to undo and no su sessions
+func (t *Terminal) Reset() {
+	t.leaveSu()
+	t.FS.Reset()
+	t.shellMu.Lock()
+	t.History = []string{}
+	t.Aliases = nil
+	t.shellMu.Unlock()
+	t.UndoStack = nil
+	t.Running = true
+	t.Status = 0
+}
+
+// SetUser switches the terminal to the given user, creating /home/<name> if needed
+// so that ~ resolves under it
+func (t *Terminal) SetUser(name string) error {
+	if err := checkUserName(name); err != nil {
+		return err
 	}
+	home := homeDir(name)
+	if err := t.makeHome(name, home); err != nil {
+		return err
+	}
+	t.User = name
+	t.FS.Home = home
+	t.FS.User = name
+	return nil
+}
+
+// makeHome creates home for user name if needed. It is made as root, as login would, then
+// handed to the user. This is a side effect of switching users, not an undoable change.
+func (t *Terminal) makeHome(name, home string) error {
+	journal, user := t.FS.journal, t.FS.User
+	t.FS.journal, t.FS.User = nil, RootUser
+	defer func() { t.FS.journal, t.FS.User = journal, user }()
+	if err := t.FS.Mkdir(home, true); err != nil {
+		return err
+	}
+	return t.FS.Chown(name, home)
 }
 
-// ResolvePath resolves a path to a VirtualFile, handling absolute/relative paths, ., .., and ~
+// Su starts a session as the given user (root when empty); Exit returns to the previous user
+func (t *Terminal) Su(name string) error {
+	if name == "" {
+		name = RootUser
+	}
+	prev := t.User
+	if err := t.SetUser(name); err != nil {
+		return fmt.Errorf("su: %v", err)
+	}
+	t.suStack = append(t.suStack, prev)
+	return nil
+}
+
+// Sudo runs fn as root, bypassing permission checks, and restores the current user afterwards.
+// Only the user, the home ~ stands for and the su sessions are restored, so the user's home is
+// left as fn left it, and an su or exit run by fn lasts only until it returns.
+func (t *Terminal) Sudo(fn func() (string, error)) (string, error) {
+	user, home, suStack := t.User, t.FS.Home, slices.Clone(t.suStack)
+	if err := t.SetUser(RootUser); err != nil {
+		return "", fmt.Errorf("sudo: %v", err)
+	}
+	defer func() { t.User, t.FS.User, t.FS.Home, t.suStack = user, user, home, suStack }()
+	return fn()
+}
+
+// uid returns the numeric id reported for a user name
+func uid(name string) int {
+	if name == "root" {
+		return 0
+	}
+	return 1000
+}
+
+// Whoami returns the name of the current user
+func (t *Terminal) Whoami() string {
+	return t.User
+}
+
+// Id returns a user/group summary for the current user
+func (t *Terminal) Id() string {
+	id := uid(t.User)
+	return fmt.Sprintf("uid=%d(%s) gid=%d(%s) groups=%d(%s)", id, t.User, id, t.User, id, t.User)
+}
+
+// RenderPrompt expands the prompt format for the current state
+func (t *Terminal) RenderPrompt() string {
+	prompt := t.Prompt
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+	r := strings.NewReplacer(`\u`, t.User, `\w`, t.FS.Pwd())
+	return r.Replace(prompt)
+}
+
+// expandTilde replaces a leading ~ with the current user's home and ~name with the home of
+// user name, who must be one of Users, root or the current user
+func (fs *FileSystem) expandTilde(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	name, rest, _ := strings.Cut(path[1:], "/")
+	home := fs.Home
+	if name != "" {
+		if name != RootUser && name != fs.User && !slices.Contains(fs.Users, name) {
+			return "", fmt.Errorf("no such user: %s", name)
+		}
+		home = homeDir(name)
+	}
+	if rest == "" {
+		return home, nil
+	}
+	return strings.TrimSuffix(home, "/") + "/" + rest, nil
+}
+
+// ResolvePath resolves a path to a VirtualFile, handling absolute/relative paths, ., .., ~ and ~user
 func (fs *FileSystem) ResolvePath(path string) (*VirtualFile, error) {
 	if path == "" {
 		return fs.CurrentDir, nil
 	}
 
 	// Handle ~ as home directory
-	if path == "~" {
-		homePath := "/home/user"
-		return fs.ResolvePath(homePath)
+	if strings.HasPrefix(path, "~") {
+		expanded, err := fs.expandTilde(path)
+		if err != nil {
+			return nil, err
+		}
+		return fs.ResolvePath(expanded)
+	}
+
+	if fs.cache == nil {
+		return fs.resolve(path)
 	}
+	// Permission checks depend on the user, so entries are per user
+	key := path
+	if !IsAbsolute(path) {
+		key = fs.GetPath(fs.CurrentDir) + "/" + path
+	}
+	key = fs.User + "\x00" + key
+	if node, ok := fs.cache.get(key); ok {
+		return node, nil
+	}
+	node, err := fs.resolve(path)
+	if err == nil {
+		fs.cache.put(key, node)
+	}
+	return node, err
+}
+
+// ErrNotFound is wrapped by the error of a path lookup that finds nothing under some name
+var ErrNotFound = errors.New("no such file or directory")
 
+// resolve walks path component by component from the root or the current directory
+func (fs *FileSystem) resolve(path string) (*VirtualFile, error) {
 	// Split into components
 	components := strings.Split(path, "/")
 	if len(components) == 1 && components[0] == "" {
 		components = []string{}
 	}
 
+	// Bound the walk so a runaway path fails instead of tying up the terminal
+	if fs.MaxDepth > 0 && len(components) > fs.MaxDepth {
+		depth := 0
+		for _, comp := range components {
+			if comp != "" && comp != "." {
+				depth++
+			}
+		}
+		if depth > fs.MaxDepth {
+			return nil, fmt.Errorf("path too long: more than %d components", fs.MaxDepth)
+		}
+	}
+
 	var current *VirtualFile
 	if components[0] == "" { // Absolute path
 		current = fs.Root
@@ -135,30 +520,81 @@ func (fs *FileSystem) ResolvePath(path string) (*VirtualFile, error) {
 		if current.Type != Directory {
 			return nil, fmt.Errorf("not a directory: %s", current.Name)
 		}
+		if err := fs.checkAccess(current, AccessExec, current.Name); err != nil {
+			return nil, err
+		}
 
 		child, exists := current.Children[comp]
 		if !exists {
-			return nil, fmt.Errorf("no such file or directory: %s", comp)
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, comp)
 		}
 		current = child
 	}
 
+	// A trailing slash names a directory, so it cannot resolve to a file
+	if hasTrailingSlash(path) && current.Type != Directory {
+		return nil, fmt.Errorf("not a directory: %s", current.Name)
+	}
 	return current, nil
 }
 
+// hasTrailingSlash reports whether path ends in a slash, as in "dir/", other than the root itself
+func hasTrailingSlash(path string) bool {
+	return len(path) > 1 && strings.HasSuffix(path, "/")
+}
+
 // GetPath returns the full path of a VirtualFile relative to root
+// Paths are cached on each node and reused until a move, rename or unlink invalidates them.
 func (fs *FileSystem) GetPath(file *VirtualFile) string {
 	if file == fs.Root {
 		return "/"
 	}
+	if file.path != "" && file.pathGen == fs.pathGen {
+		return file.path
+	}
+
+	path := "/" + file.Name
+	if file.Parent != nil && file.Parent != fs.Root {
+		path = fs.GetPath(file.Parent) + path
+	}
+	file.path = path
+	file.pathGen = fs.pathGen
+	return path
+}
+
+// link places node in parent under name, invalidating cached paths since node may have moved
+func (fs *FileSystem) link(parent *VirtualFile, name string, node *VirtualFile) {
+	node.Parent = parent
+	node.Name = name
+	parent.Children[name] = node
+	fs.pathGen++
+	fs.invalidate()
+}
+
+// contains reports whether node is dir itself or one of its descendants
+func contains(dir *VirtualFile, node *VirtualFile) bool {
+	for ; node != nil; node = node.Parent {
+		if node == dir {
+			return true
+		}
+	}
+	return false
+}
 
-	pathParts := []string{}
-	current := file
-	for current != nil && current != fs.Root {
-		pathParts = append([]string{current.Name}, pathParts...)
-		current = current.Parent
+// walk calls fn for file and every descendant in depth-first order, visiting children by name
+func walk(file *VirtualFile, path string, fn func(path string, file *VirtualFile)) {
+	fn(path, file)
+	if file.Type != Directory {
+		return
+	}
+	names := make([]string, 0, len(file.Children))
+	for name := range file.Children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		walk(file.Children[name], strings.TrimSuffix(path, "/")+"/"+name, fn)
 	}
-	return "/" + strings.Join(pathParts, "/")
 }
 
 // IsAbsolute returns true if path is absolute
@@ -184,55 +620,109 @@ func (fs *FileSystem) Exists(path string) (bool, error) {
 	return true, nil
 }
 
-// ParseCommand parses the input string into command and arguments, handling basic quoted strings
+// SplitPipeline splits input at each | that is neither quoted nor escaped, returning the
+// text of each command in the pipeline
+func SplitPipeline(input string) []string {
+	var stages []string
+	var quoteChar rune
+	start := 0
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quoteChar != 0:
+			if r == quoteChar {
+				quoteChar = 0
+			} else if r == '\\' && quoteChar == '"' {
+				i++
+			}
+		case r == '\\':
+			i++
+		case r == '"' || r == '\'':
+			quoteChar = r
+		case r == '|':
+			stages = append(stages, string(runes[start:i]))
+			start = i + 1
+		}
+	}
+	return append(stages, string(runes[start:]))
+}
+
+// ParseCommand parses the input string into command and arguments. Single quotes keep
+// their contents literally, double quotes allow \" and \\ escapes, and outside quotes a
+// backslash escapes the next character. Quoted parts join adjacent text into one token.
 func ParseCommand(input string) (cmd string, args []string, err error) {
-	input = strings.TrimSpace(input)
-	if input == "" {
-		return "", nil, nil
+	tokens, _, err := splitWords(input)
+	if err != nil || len(tokens) == 0 {
+		return "", nil, err
 	}
+	return tokens[0], tokens[1:], nil
+}
+
+// splitWords splits input into the tokens ParseCommand describes. It also returns each token
+// as it was typed, quotes and backslashes included, so an operator such as 2> can be told
+// apart from the same text quoted.
+func splitWords(input string) (tokens, raw []string, err error) {
+	runes := []rune(strings.TrimSpace(input))
 
-	var tokens []string
 	var current strings.Builder
-	var inQuote bool
+	var inToken bool // set by quotes too, so "" is an empty argument
 	var quoteChar rune
+	start := 0 // Index of the first rune of the current token
 
-	for i, r := range input {
-		if inQuote {
-			current.WriteRune(r)
-			if r == quoteChar {
-				inQuote = false
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-			continue
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if !inToken && quoteChar == 0 && r != ' ' && r != '\t' {
+			start = i
 		}
-
 		switch {
+		case quoteChar == '\'':
+			if r == '\'' {
+				quoteChar = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case quoteChar == '"':
+			switch {
+			case r == '"':
+				quoteChar = 0
+			case r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\'):
+				i++
+				current.WriteRune(runes[i])
+			default:
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, nil, fmt.Errorf("syntax error: trailing backslash")
+			}
+			i++
+			current.WriteRune(runes[i])
+			inToken = true
+		case r == '"' || r == '\'':
+			quoteChar = r
+			inToken = true
 		case r == ' ' || r == '\t':
-			if current.Len() > 0 {
+			if inToken {
 				tokens = append(tokens, current.String())
+				raw = append(raw, string(runes[start:i]))
 				current.Reset()
+				inToken = false
 			}
-		case r == '"' || r == '\'':
-			inQuote = true
-			quoteChar = r
-			current.Reset()
 		default:
 			current.WriteRune(r)
-		}
-
-		if i == len(input)-1 && current.Len() > 0 {
-			tokens = append(tokens, current.String())
+			inToken = true
 		}
 	}
 
-	if len(tokens) == 0 {
-		return "", nil, nil
+	if quoteChar != 0 {
+		return nil, nil, fmt.Errorf("syntax error: unterminated %c quote", quoteChar)
 	}
-
-	cmd = tokens[0]
-	args = tokens[1:]
-	return cmd, args, nil
+	if inToken {
+		tokens = append(tokens, current.String())
+		raw = append(raw, string(runes[start:]))
+	}
+	return tokens, raw, nil
 }
 
 // Pwd returns the current working directory path
@@ -248,7 +738,7 @@ func (fs *FileSystem) Cd(path string) error {
 
 	if path == "-" {
 		if fs.PrevDir == nil {
-			return fmt.Errorf("no previous directory")
+			return fmt.Errorf("cd: no previous directory")
 		}
 		oldDir := fs.CurrentDir
 		fs.CurrentDir = fs.PrevDir
@@ -257,11 +747,30 @@ func (fs *FileSystem) Cd(path string) error {
 	}
 
 	newDir, err := fs.ResolvePath(path)
+	if err != nil && hasTrailingSlash(path) {
+		// A file named with a trailing slash is reported as a file, as it is without the slash
+		if file, ferr := fs.ResolvePath(strings.TrimRight(path, "/")); ferr == nil && file.Type != Directory {
+			newDir, err = file, nil
+		}
+	}
 	if err != nil {
-		return err
+		// Offer a sibling of the missing directory when it looks like a typo
+		parentPath, name := filepath.Split(strings.TrimSuffix(path, "/"))
+		if parent, perr := fs.ResolvePath(parentPath); perr == nil && parent.Type == Directory && name != "" {
+			if _, exists := parent.Children[name]; !exists {
+				if match := closestDir(parent, name); match != "" {
+					return fmt.Errorf("cd: no such directory '%s' (did you mean '%s'?)", path, parentPath+match)
+				}
+			}
+		}
+		return fmt.Errorf("cd: %s: %v", path, err)
 	}
 	if newDir.Type != Directory {
-		return fmt.Errorf("%s is not a directory", path)
+		// Offer the directory holding the file, unless that is where we already are
+		if parent := newDir.Parent; fs.CdHint && parent != nil && parent != fs.CurrentDir {
+			return fmt.Errorf("cd: %s: Not a directory (%s); try 'cd %s'", path, newDir.Type, filepath.Dir(strings.TrimSuffix(path, "/")))
+		}
+		return fmt.Errorf("cd: %s: Not a directory (%s)", path, newDir.Type)
 	}
 
 	fs.PrevDir = fs.CurrentDir
@@ -290,12 +799,12 @@ func (fs *FileSystem) Mkdir(path string, parents bool) error {
 	// Clean the path
 	absPath = filepath.Clean(absPath)
 	if absPath == "/" {
-		return fmt.Errorf("cannot create directory at root")
+		return fmt.Errorf("mkdir: cannot create directory at root")
 	}
 
 	components := strings.Split(strings.Trim(absPath, "/"), "/")
 	if len(components) == 0 {
-		return fmt.Errorf("invalid path")
+		return fmt.Errorf("mkdir: invalid path")
 	}
 
 	current := fs.Root
@@ -317,30 +826,37 @@ func (fs *FileSystem) Mkdir(path string, parents bool) error {
 		if !parents && !isLast {
 			child, exists := current.Children[comp]
 			if !exists {
-				return fmt.Errorf("no such file or directory")
+				return fmt.Errorf("mkdir: cannot create directory '%s': no such file or directory", path)
 			}
 			if child.Type != Directory {
-				return fmt.Errorf("%s is not a directory", comp)
+				return fmt.Errorf("mkdir: cannot create directory '%s': %s is not a directory", path, comp)
 			}
 			current = child
 			continue
 		}
+		if !parents {
+			if _, exists := current.Children[comp]; exists {
+				return fmt.Errorf("mkdir: cannot create directory '%s': file exists", path)
+			}
+		}
 
 		// Create if not exists
 		if _, exists := current.Children[comp]; !exists {
 			if isLast {
 				// Create the directory
-				newDir := NewDirectory(comp, current)
+				newDir := fs.own(NewDirectory(comp, current))
 				current.Children[comp] = newDir
+				fs.record(createOp{newDir})
 			} else {
 				// Create intermediate directory
-				newDir := NewDirectory(comp, current)
+				newDir := fs.own(NewDirectory(comp, current))
 				current.Children[comp] = newDir
+				fs.record(createOp{newDir})
 			}
 		} else {
 			child := current.Children[comp]
 			if child.Type != Directory {
-				return fmt.Errorf("cannot create directory '%s': not a directory", comp)
+				return fmt.Errorf("mkdir: cannot create directory '%s': %s is not a directory", path, comp)
 			}
 		}
 		current = current.Children[comp]
@@ -355,6 +871,16 @@ func (fs *FileSystem) Touch(path string) error {
 		return fmt.Errorf("touch: missing operand")
 	}
 
+	// With a trailing slash the path can only be an existing directory
+	if hasTrailingSlash(path) {
+		dir, err := fs.ResolvePath(path)
+		if err != nil {
+			return fmt.Errorf("touch: %s: %v", path, err)
+		}
+		dir.ModTime = time.Now()
+		return nil
+	}
+
 	// Resolve the parent directory
 	dirPath, fileName := filepath.Split(path)
 	dir, err := fs.ResolvePath(dirPath)
@@ -372,8 +898,9 @@ func (fs *FileSystem) Touch(path string) error {
 		file.Size = int64(len(file.Content))
 	} else {
 		// Create new empty file
-		newFile := NewFile(fileName, dir, []byte{})
+		newFile := fs.own(NewFile(fileName, dir, []byte{}))
 		dir.Children[fileName] = newFile
+		fs.record(createOp{newFile})
 	}
 
 	return nil
@@ -438,40 +965,115 @@ func getPermString(perm uint32, isDir bool) string {
 	return sb.String()
 }
 
+// LsOptions selects what Ls shows and how
+type LsOptions struct {
+	Long      bool
+	All       bool   // Include dotfiles, and the . and .. entries
+	AlmostAll bool   // Include dotfiles but not . and ..
+	Porcelain bool   // Stable tab-separated format for scripts, listing only real entries; overrides Long
+	TimeStyle string // Time format for Long: "default", "iso", "full-iso" or "+" and a Go layout
+	Directory bool   // List a directory as itself rather than its contents
+	Inode     bool   // Prefix each entry with its inode number; ignored by Porcelain
+	Total     bool   // End with the bytes in the listed entries, counting everything below directories (LsAll)
+}
+
+// timeLayout returns the Go time layout for an ls --time-style value; empty means default
+func timeLayout(style string) (string, error) {
+	switch {
+	case style == "" || style == "default":
+		return "Jan 02 15:04", nil
+	case style == "iso":
+		return "2006-01-02 15:04", nil
+	case style == "full-iso":
+		return "2006-01-02 15:04:05.000000000 -0700", nil
+	case strings.HasPrefix(style, "+"):
+		return style[1:], nil
+	default:
+		return "", fmt.Errorf("invalid time style '%s'", style)
+	}
+}
+
 // Ls lists the contents of the directory at path
 func (fs *FileSystem) Ls(path string, long, all bool) (string, error) {
+	return fs.LsWith(path, LsOptions{Long: long, All: all})
+}
+
+// LsWith lists the contents of the directory at path using opts
+func (fs *FileSystem) LsWith(path string, opts LsOptions) (string, error) {
 	if path == "" {
 		path = "."
 	}
+	layout, err := timeLayout(opts.TimeStyle)
+	if err != nil {
+		return "", fmt.Errorf("ls: %v", err)
+	}
 
 	dir, err := fs.ResolvePath(path)
 	if err != nil {
 		return "", fmt.Errorf("ls: %v", err)
 	}
-	if dir.Type != Directory {
-		return "", fmt.Errorf("ls: %s: not a directory", path)
+	if dir.Type != Directory || opts.Directory {
+		// A file, or any path with -d, lists as itself, under the name it was given
+		switch {
+		case opts.Porcelain:
+			return porcelainLine(dir, path), nil
+		case opts.Long:
+			return withInode(dir, longLine(dir, path, layout), opts), nil
+		default:
+			return withInode(dir, path, opts), nil
+		}
+	}
+	if err := fs.checkAccess(dir, AccessRead, path); err != nil {
+		return "", fmt.Errorf("ls: %v", err)
 	}
 
-	var lines []string
-	if long {
+	all := opts.All || opts.AlmostAll
+	// . and .. are the directory and its parent; the root is its own parent
+	dots := []string{}
+	if opts.All && !opts.AlmostAll {
+		dots = []string{".", ".."}
+	}
+	dotFile := func(name string) *VirtualFile {
+		if name == ".." && dir.Parent != nil {
+			return dir.Parent
+		}
+		return dir
+	}
+	lines := make([]string, 0, len(dir.Children)+len(dots))
+	if opts.Porcelain {
+		names := make([]string, 0, len(dir.Children))
+		for name := range dir.Children {
+			if !all && strings.HasPrefix(name, ".") {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			lines = append(lines, porcelainLine(dir.Children[name], name))
+		}
+	} else if opts.Long {
 		// Long format
+		for _, name := range dots {
+			lines = append(lines, withInode(dotFile(name), longLine(dotFile(name), name, layout), opts))
+		}
 		for name, child := range dir.Children {
-			if !all && strings.HasPrefix(name, ".") && name != "." && name != ".." {
+			if !all && strings.HasPrefix(name, ".") {
 				continue
 			}
-			permStr := getPermString(child.Permissions, child.Type == Directory)
-			timeStr := child.ModTime.Format("Jan 02 15:04")
-			line := fmt.Sprintf("%s 1 user user %d %s %s", permStr, child.Size, timeStr, name)
-			lines = append(lines, line)
+			lines = append(lines, withInode(child, longLine(child, name, layout), opts))
 		}
 	} else {
 		// Short format
-		var names []string
+		names := make([]string, 0, len(dir.Children)+len(dots))
+		for _, name := range dots {
+			names = append(names, withInode(dotFile(name), name, opts))
+		}
 		for name := range dir.Children {
-			if !all && strings.HasPrefix(name, ".") && name != "." && name != ".." {
+			if !all && strings.HasPrefix(name, ".") {
 				continue
 			}
-			names = append(names, name)
+			names = append(names, withInode(dir.Children[name], name, opts))
 		}
 		lines = append(lines, strings.Join(names, " "))
 	}
@@ -479,8 +1081,147 @@ func (fs *FileSystem) Ls(path string, long, all bool) (string, error) {
 	return strings.Join(lines, "\n"), nil
 }
 
+// withInode prefixes an ls entry with the file's inode number when opts.Inode is set
+func withInode(file *VirtualFile, entry string, opts LsOptions) string {
+	if !opts.Inode {
+		return entry
+	}
+	return fmt.Sprintf("%d %s", file.Ino, entry)
+}
+
+// LsAll lists several paths as coreutils does: the files first, then each directory under
+// a "path:" header, or with opts.Directory every path as itself. A path that fails is
+// reported without stopping the others.
+func (fs *FileSystem) LsAll(paths []string, opts LsOptions) (string, error) {
+	if opts.Total {
+		opts.Total = false
+		listing, err := fs.LsAll(paths, opts)
+		total := fmt.Sprintf("total %d bytes", fs.listedBytes(paths, opts))
+		if listing == "" {
+			return total, err
+		}
+		return listing + "\n" + total, err
+	}
+	if len(paths) == 0 {
+		return fs.LsWith(".", opts)
+	}
+	if len(paths) == 1 {
+		return fs.LsWith(paths[0], opts)
+	}
+
+	var files, dirs []string
+	var errs []error
+	for _, path := range paths {
+		file, err := fs.ResolvePath(path)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("ls: %v", err))
+		case file.Type == Directory && !opts.Directory:
+			dirs = append(dirs, path)
+		default:
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	sort.Strings(dirs)
+
+	var sections []string
+	if len(files) > 0 {
+		lines := make([]string, 0, len(files))
+		for _, path := range files {
+			line, err := fs.LsWith(path, opts)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			lines = append(lines, line)
+		}
+		sep := " "
+		if opts.Long || opts.Porcelain {
+			sep = "\n"
+		}
+		sections = append(sections, strings.Join(lines, sep))
+	}
+	for _, path := range dirs {
+		listing, err := fs.LsWith(path, opts)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sections = append(sections, path+":\n"+listing)
+	}
+	return strings.Join(sections, "\n\n"), errors.Join(errs...)
+}
+
+// listedBytes returns the bytes in the entries LsAll lists for paths: the files themselves and
+// everything below the directories. Paths that can't be listed count for nothing.
+func (fs *FileSystem) listedBytes(paths []string, opts LsOptions) int64 {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var total int64
+	add := func(top *VirtualFile) {
+		walk(top, "", func(_ string, file *VirtualFile) {
+			total += int64(len(file.Content))
+		})
+	}
+	for _, path := range paths {
+		file, err := fs.ResolvePath(path)
+		if err != nil {
+			continue
+		}
+		if file.Type != Directory || opts.Directory {
+			add(file)
+			continue
+		}
+		if !fs.CanAccess(file, AccessRead) {
+			continue
+		}
+		for name, child := range file.Children {
+			if opts.All || opts.AlmostAll || !strings.HasPrefix(name, ".") {
+				add(child)
+			}
+		}
+	}
+	return total
+}
+
+// longLine formats one ls -l entry: permissions, link count, owner, group, size, time in
+// the given layout and name
+func longLine(file *VirtualFile, name string, layout string) string {
+	permStr := getPermString(file.Permissions, file.Type == Directory)
+	timeStr := file.ModTime.Format(layout)
+	return fmt.Sprintf("%s %d %s %s %d %s %s", permStr, linkCount(file), file.Owner, file.Group, file.Size, timeStr, name)
+}
+
+// linkCount returns the number of names that refer to file. A file has only its own; a
+// directory also has its . and the .. of each subdirectory.
+func linkCount(file *VirtualFile) int {
+	if file.Type != Directory {
+		return 1
+	}
+	n := 2
+	for _, child := range file.Children {
+		if child.Type == Directory {
+			n++
+		}
+	}
+	return n
+}
+
+// RmOptions controls which directories Rm will remove
+type RmOptions struct {
+	Recursive bool // Remove directories and everything in them
+	Dir       bool // Remove empty directories, as rmdir does
+}
+
 // Rm removes the file or directory at the given path. If recursive is true, removes directories recursively.
 func (fs *FileSystem) Rm(path string, recursive bool) error {
+	return fs.RmWith(path, RmOptions{Recursive: recursive})
+}
+
+// RmWith removes the file or directory at the given path according to opts
+func (fs *FileSystem) RmWith(path string, opts RmOptions) error {
 	if path == "" {
 		return fmt.Errorf("rm: missing operand")
 	}
@@ -499,40 +1240,22 @@ func (fs *FileSystem) Rm(path string, recursive bool) error {
 		return fmt.Errorf("rm: cannot remove root")
 	}
 
-	name := target.Name
-
-	// If it's a directory and not recursive, error
-	if target.Type == Directory {
-		if !recursive {
+	// A directory needs -r, or -d when it is empty
+	if target.Type == Directory && !opts.Recursive {
+		if !opts.Dir {
 			return fmt.Errorf("rm: %s: is a directory", path)
 		}
-		// Recursive delete
-		err = fs.deleteRecursive(target)
-		if err != nil {
-			return err
+		if len(target.Children) > 0 {
+			return fmt.Errorf("rm: %s: directory not empty", path)
 		}
-	} else {
-		// File, just delete
-		delete(parent.Children, name)
 	}
 
-	return nil
-}
+	// Detach the node (and with it any subtree) and keep it in the trash for restore
+	fullPath := fs.GetPath(target)
+	delete(parent.Children, target.Name)
+	fs.record(removeOp{fs: fs, node: target, parent: parent, name: target.Name})
+	fs.trash(fullPath, target)
 
-// deleteRecursive deletes a directory and all its contents
-func (fs *FileSystem) deleteRecursive(dir *VirtualFile) error {
-	for _, child := range dir.Children {
-		if child.Type == Directory {
-			err := fs.deleteRecursive(child)
-			if err != nil {
-				return err
-			}
-		}
-		// Delete the child
-		delete(dir.Parent.Children, child.Name)
-	}
-	// Now delete the dir itself
-	delete(dir.Parent.Children, dir.Name)
 	return nil
 }
 
@@ -559,11 +1282,24 @@ func (fs *FileSystem) Rmdir(path string) error {
 	}
 
 	delete(parent.Children, target.Name)
+	fs.record(removeOp{fs: fs, node: target, parent: parent, name: target.Name})
 	return nil
 }
 
+// CopyOptions controls how Cp and Mv treat sources and existing destinations
+type CopyOptions struct {
+	Recursive bool       // Copy directories recursively
+	Backup    BackupMode // Keep an overwritten destination under a backup name
+	Preserve  bool       // Keep the mode, ownership, modification time and attributes of copies
+}
+
 // Cp copies the source to the destination. If recursive is true, copies directories recursively.
 func (fs *FileSystem) Cp(source string, dest string, recursive bool) error {
+	return fs.CpWith(source, dest, CopyOptions{Recursive: recursive})
+}
+
+// CpWith copies the source to the destination according to opts
+func (fs *FileSystem) CpWith(source string, dest string, opts CopyOptions) error {
 	if source == "" || dest == "" {
 		return fmt.Errorf("cp: missing file operand")
 	}
@@ -572,55 +1308,41 @@ func (fs *FileSystem) Cp(source string, dest string, recursive bool) error {
 	if err != nil {
 		return fmt.Errorf("cp: %s: %v", source, err)
 	}
+	if err := fs.checkAccess(srcFile, AccessRead, source); err != nil {
+		return fmt.Errorf("cp: %v", err)
+	}
 
-	// Determine destination parent and name
-	var destParent *VirtualFile
-	var destName string
-
-	destExists, err := fs.Exists(dest)
+	destParent, destName, err := fs.destination("cp", srcFile, dest)
 	if err != nil {
 		return err
 	}
 
-	if destExists {
-		destFile, err := fs.ResolvePath(dest)
-		if err != nil {
-			return err
-		}
-		if destFile.Type == Directory {
-			// Copy into directory with source name
-			destParent = destFile
-			destName = srcFile.Name
-		} else {
-			// Overwrite file
-			destParent = destFile.Parent
-			destName = destFile.Name
-		}
-	} else {
-		// Create in parent dir
-		destParentPath := filepath.Dir(dest)
-		destParent, err = fs.ResolvePath(destParentPath)
-		if err != nil {
-			return fmt.Errorf("cp: %s: %v", destParentPath, err)
-		}
-		if destParent.Type != Directory {
-			return fmt.Errorf("cp: %s: not a directory", destParentPath)
-		}
-		destName = filepath.Base(dest)
+	if srcFile.Type == Directory && !opts.Recursive {
+		return fmt.Errorf("cp: omitting directory %s", source)
+	}
+	if destParent.Children[destName] == srcFile {
+		return fmt.Errorf("cp: '%s' and '%s' are the same file", source, dest)
+	}
+	if srcFile.Type == Directory && contains(srcFile, destParent) {
+		return fmt.Errorf("cp: cannot copy a directory, '%s', into itself, '%s'", source, dest)
+	}
+	if err := fs.backup(destParent, destName, srcFile, opts.Backup); err != nil {
+		return fmt.Errorf("cp: %v", err)
 	}
 
 	if srcFile.Type == RegularFile {
-		// Copy file
-		newContent := make([]byte, len(srcFile.Content))
-		copy(newContent, srcFile.Content)
-		newFile := NewFile(destName, destParent, newContent)
-		destParent.Children[destName] = newFile
+		// Copy file, sharing the content buffer until either side is rewritten
+		newFile := fs.ownCopy(NewFile(destName, destParent, srcFile.Content), srcFile)
+		if opts.Preserve {
+			preserve(newFile, srcFile)
+		}
+		fs.attach(destParent, destName, newFile)
 	} else if srcFile.Type == Directory {
-		if !recursive {
-			return fmt.Errorf("cp: omitting directory %s", source)
+		// Recursive copy, refused as a whole when anything in the tree cannot be read
+		if err := fs.checkTreeAccess(srcFile, AccessRead, source); err != nil {
+			return fmt.Errorf("cp: %v", err)
 		}
-		// Recursive copy
-		err = fs.copyRecursive(srcFile, destParent, destName)
+		err = fs.copyRecursive(srcFile, destParent, destName, opts.Preserve)
 		if err != nil {
 			return err
 		}
@@ -631,30 +1353,84 @@ func (fs *FileSystem) Cp(source string, dest string, recursive bool) error {
 	return nil
 }
 
+// destination returns the directory and name that cmd (cp or mv) gives src for the operand
+// dest: inside dest under the source name when dest is a directory, otherwise dest itself.
+// A trailing slash requires dest to be a directory, or to be created as one from src. Only a
+// dest that is not found is created; any other failure to resolve it is returned.
+func (fs *FileSystem) destination(cmd string, src *VirtualFile, dest string) (*VirtualFile, string, error) {
+	destFile, err := fs.ResolvePath(dest)
+	if err == nil {
+		if destFile.Type == Directory {
+			return destFile, src.Name, nil
+		}
+		return destFile.Parent, destFile.Name, nil
+	}
+
+	clean := filepath.Clean(dest)
+	if hasTrailingSlash(dest) {
+		if existing, err := fs.ResolvePath(clean); (err == nil && existing.Type != Directory) || src.Type != Directory {
+			return nil, "", fmt.Errorf("%s: %s: not a directory", cmd, dest)
+		}
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, "", fmt.Errorf("%s: %s: %v", cmd, dest, err)
+	}
+
+	// Create in parent dir
+	parentPath := filepath.Dir(clean)
+	parent, err := fs.ResolvePath(parentPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %s: %v", cmd, parentPath, err)
+	}
+	if parent.Type != Directory {
+		return nil, "", fmt.Errorf("%s: %s: not a directory", cmd, parentPath)
+	}
+	return parent, filepath.Base(clean), nil
+}
+
 // copyRecursive copies a directory and its contents recursively
-func (fs *FileSystem) copyRecursive(srcDir *VirtualFile, destParent *VirtualFile, destName string) error {
-	destDir := NewDirectory(destName, destParent)
-	destParent.Children[destName] = destDir
+func (fs *FileSystem) copyRecursive(srcDir *VirtualFile, destParent *VirtualFile, destName string, keep bool) error {
+	destDir := fs.ownCopy(NewDirectory(destName, destParent), srcDir)
+	fs.attach(destParent, destName, destDir)
 
 	for name, child := range srcDir.Children {
 		if child.Type == Directory {
-			err := fs.copyRecursive(child, destDir, name)
+			err := fs.copyRecursive(child, destDir, name, keep)
 			if err != nil {
 				return err
 			}
 		} else {
-			newContent := make([]byte, len(child.Content))
-			copy(newContent, child.Content)
-			newFile := NewFile(name, destDir, newContent)
+			newFile := fs.ownCopy(NewFile(name, destDir, child.Content), child)
+			if keep {
+				preserve(newFile, child)
+			}
 			destDir.Children[name] = newFile
 		}
 	}
 
+	// Adding children touches the directory, so its own time is restored last
+	if keep {
+		preserve(destDir, srcDir)
+	}
 	return nil
 }
 
+// preserve gives a copy the mode, ownership, modification time and attributes of its source, as cp -p does
+func preserve(dst, src *VirtualFile) {
+	dst.Permissions = src.Permissions
+	dst.Owner = src.Owner
+	dst.Group = src.Group
+	dst.ModTime = src.ModTime
+	dst.Attrs = copyAttrs(src.Attrs)
+}
+
 // Mv moves or renames the source to the destination
 func (fs *FileSystem) Mv(source string, dest string) error {
+	return fs.MvWith(source, dest, CopyOptions{})
+}
+
+// MvWith moves or renames the source to the destination according to opts
+func (fs *FileSystem) MvWith(source string, dest string, opts CopyOptions) error {
 	if source == "" || dest == "" {
 		return fmt.Errorf("mv: missing file operand")
 	}
@@ -664,55 +1440,30 @@ func (fs *FileSystem) Mv(source string, dest string) error {
 		return fmt.Errorf("mv: %s: %v", source, err)
 	}
 
-	// Determine destination parent and name
-	var destParent *VirtualFile
-	var destName string
-
-	destExists, err := fs.Exists(dest)
+	destParent, destName, err := fs.destination("mv", srcFile, dest)
 	if err != nil {
 		return err
 	}
 
-	if destExists {
-		destFile, err := fs.ResolvePath(dest)
-		if err != nil {
-			return err
-		}
-		if destFile.Type == Directory {
-			// Move into directory with source name
-			destParent = destFile
-			destName = srcFile.Name
-		} else {
-			// Overwrite file
-			destParent = destFile.Parent
-			destName = destFile.Name
-			// Remove existing dest if it's a file
-			delete(destParent.Children, destName)
-		}
-	} else {
-		// Create in parent dir
-		destParentPath := filepath.Dir(dest)
-		destParent, err = fs.ResolvePath(destParentPath)
-		if err != nil {
-			return fmt.Errorf("mv: %s: %v", destParentPath, err)
-		}
-		if destParent.Type != Directory {
-			return fmt.Errorf("mv: %s: not a directory", destParentPath)
-		}
-		destName = filepath.Base(dest)
-	}
-
 	// Remove from source parent
 	srcParent := srcFile.Parent
 	if srcParent == nil {
 		return fmt.Errorf("mv: cannot move root")
 	}
+	if srcFile.Type == Directory && contains(srcFile, destParent) {
+		return fmt.Errorf("mv: cannot move '%s' to a subdirectory of itself, '%s'", source, dest)
+	}
+	if err := fs.backup(destParent, destName, srcFile, opts.Backup); err != nil {
+		return fmt.Errorf("mv: %v", err)
+	}
 	delete(srcParent.Children, srcFile.Name)
+	if replaced, exists := destParent.Children[destName]; exists {
+		fs.record(removeOp{fs: fs, node: replaced, parent: destParent, name: destName})
+	}
+	fs.record(moveOp{fs: fs, node: srcFile, from: srcParent, fromName: srcFile.Name})
 
 	// Update parent and name
-	srcFile.Parent = destParent
-	srcFile.Name = destName
-	destParent.Children[destName] = srcFile
+	fs.link(destParent, destName, srcFile)
 
 	// If directory, update all children parents recursively
 	if srcFile.Type == Directory {
@@ -739,25 +1490,156 @@ func (fs *FileSystem) updateParentsRecursive(dir *VirtualFile, newParent *Virtua
 	return nil
 }
 
+// Chown changes the owner, and optionally the group, of the file at path.
+// spec is USER or USER:GROUP; an empty USER leaves the owner unchanged.
+func (fs *FileSystem) Chown(spec string, path string) error {
+	_, err := fs.ChownWith(spec, path, ChangeOptions{})
+	return err
+}
+
+// ChownWith changes the ownership of the file at path according to opts and returns
+// the verbose report
+func (fs *FileSystem) ChownWith(spec string, path string, opts ChangeOptions) (string, error) {
+	owner, group, hasGroup := strings.Cut(spec, ":")
+	if owner == "" && (!hasGroup || group == "") {
+		return "", fmt.Errorf("chown: invalid user: '%s'", spec)
+	}
+
+	if _, err := fs.ResolvePath(path); err != nil {
+		return "", fmt.Errorf("chown: %s: %v", path, err)
+	}
+	// Giving a file away, or taking one, is for root alone
+	if fs.User != RootUser {
+		return "", fmt.Errorf("chown: changing ownership of '%s': Operation not permitted", path)
+	}
+
+	return fs.apply(path, opts, func(path string, file *VirtualFile) string {
+		oldOwner, oldGroup := file.Owner, file.Group
+		if owner != "" {
+			file.Owner = owner
+		}
+		if hasGroup && group != "" {
+			file.Group = group
+		}
+		if file.Owner == oldOwner && file.Group == oldGroup {
+			return ""
+		}
+		return fmt.Sprintf("changed ownership of '%s' from %s:%s to %s:%s", path, oldOwner, oldGroup, file.Owner, file.Group)
+	}), nil
+}
+
+// CatOptions controls what Cat will print
+type CatOptions struct {
+	Force bool // Print binary content too
+}
+
+// isBinary reports whether content looks like binary data rather than text: it holds a NUL byte
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
+
 // Cat displays the contents of the file at the given path
 func (fs *FileSystem) Cat(path string) (string, error) {
+	return fs.CatWith(path, CatOptions{})
+}
+
+// CatWith returns the contents of the file at path according to opts. Binary content is
+// refused unless opts.Force, since printing it would garble the terminal.
+func (fs *FileSystem) CatWith(path string, opts CatOptions) (string, error) {
 	if path == "" {
 		return "", fmt.Errorf("cat: missing operand")
 	}
+	return fs.CatAll([]string{path}, opts)
+}
 
+// CatAll returns the contents of the files at paths one after the other, byte for byte: each
+// file ends as it is stored, with or without a final newline, and nothing is put between them.
+// A file that can't be read is reported without stopping the others.
+func (fs *FileSystem) CatAll(paths []string, opts CatOptions) (string, error) {
+	var content []byte
+	var errs []error
+	for _, path := range paths {
+		data, err := fs.catFile(path, opts)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		content = append(content, data...)
+	}
+
+	if fs.MaxOutput > 0 && len(content) > fs.MaxOutput {
+		return string(content[:fs.MaxOutput]) + "\n" + TruncatedNotice, errors.Join(errs...)
+	}
+	return string(content), errors.Join(errs...)
+}
+
+// catFile returns the content of the file at path, if cat may print it
+func (fs *FileSystem) catFile(path string, opts CatOptions) ([]byte, error) {
 	file, err := fs.ResolvePath(path)
 	if err != nil {
-		return "", fmt.Errorf("cat: %s: %v", path, err)
+		return nil, fmt.Errorf("cat: %s: %v", path, err)
 	}
 	if file.Type != RegularFile {
-		return "", fmt.Errorf("cat: %s: not a file", path)
+		return nil, fmt.Errorf("cat: %s: not a file", path)
 	}
-
-	return string(file.Content), nil
+	if err := fs.checkAccess(file, AccessRead, path); err != nil {
+		return nil, fmt.Errorf("cat: %v", err)
+	}
+	if !opts.Force && isBinary(file.Content) {
+		return nil, fmt.Errorf("cat: %s: binary file (use -f to force)", path)
+	}
+	return file.Content, nil
 }
 
 // EchoWrite writes or appends text to the file at the given path
 func (fs *FileSystem) EchoWrite(text string, path string, appendMode bool) error {
+	return fs.EchoWriteWith(text, path, appendMode, EchoOptions{})
+}
+
+// EchoOptions control how echo renders its text
+type EchoOptions struct {
+	NoNewline bool // Leave out the trailing newline (-n)
+	Escapes   bool // Interpret \n, \t and \\ in the text (-e)
+}
+
+// Render returns text as echo writes it, ending in a newline unless NoNewline
+func (o EchoOptions) Render(text string) string {
+	if o.Escapes {
+		text = expandEscapes(text)
+	}
+	if !o.NoNewline {
+		text += "\n"
+	}
+	return text
+}
+
+// expandEscapes interprets \n, \t and \\ in text, leaving any other backslash as it is
+func expandEscapes(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			switch text[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case 't':
+				sb.WriteByte('\t')
+				i++
+				continue
+			case '\\':
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(text[i])
+	}
+	return sb.String()
+}
+
+// EchoWriteWith writes or appends text, rendered according to opts, to the file at the given path
+func (fs *FileSystem) EchoWriteWith(text string, path string, appendMode bool, opts EchoOptions) error {
 	if path == "" {
 		return fmt.Errorf("echo: missing filename")
 	}
@@ -772,38 +1654,72 @@ func (fs *FileSystem) EchoWrite(text string, path string, appendMode bool) error
 		return fmt.Errorf("echo: %s: not a directory", dirPath)
 	}
 
-	var content []byte
-	if appendMode {
-		// Append mode
-		if file, exists := dir.Children[fileName]; exists {
-			if file.Type == RegularFile {
-				content = append(file.Content, []byte(text+"\n")...)
-				file.Content = content
-				file.ModTime = time.Now()
-				file.Size = int64(len(content))
-				return nil
-			} else {
-				return fmt.Errorf("echo: %s: not a file", path)
-			}
-		} else {
-			// Create new file with append (same as write for new)
-			content = []byte(text + "\n")
+	content := []byte(opts.Render(text))
+	if file, exists := dir.Children[fileName]; exists {
+		if file.Type != RegularFile {
+			return fmt.Errorf("echo: %s: not a file", path)
 		}
-	} else {
-		// Overwrite mode
-		content = []byte(text + "\n")
+		if err := fs.checkAccess(file, AccessWrite, path); err != nil {
+			return fmt.Errorf("echo: %v", err)
+		}
+		fs.record(newContentOp(file))
+		if appendMode {
+			// Cap the slice so append allocates rather than writing into a buffer a copy may share
+			old := file.Content
+			content = append(old[:len(old):len(old)], content...)
+		}
+		file.setContent(content)
+		return nil
 	}
 
-	// Create or update file
-	newFile := NewFile(fileName, dir, content)
+	// Create new file (appending to a missing file is the same as writing it)
+	newFile := fs.own(NewFile(fileName, dir, content))
 	dir.Children[fileName] = newFile
+	fs.record(createOp{newFile})
 
 	return nil
 }
 
 // Edit opens a simple line-based editor for the given filename
 func (t *Terminal) Edit(filename string) error {
+	return t.EditWith(filename, EditOptions{})
+}
+
+// EditOptions control where the editor opens
+type EditOptions struct {
+	Line     int    // Current line to open at, counted from 1; 0 for none, negative for the last line
+	ReadOnly bool   // Refuse changes and saving, and open only files that exist, as view does
+	Script   string // File of editor input to run instead of reading In, saving when it ends
+}
+
+// EditWith is Edit with options
+func (t *Terminal) EditWith(filename string, opts EditOptions) error {
+	name, access := "edit", AccessRead|AccessWrite
+	if opts.ReadOnly {
+		name, access = "view", AccessRead
+	}
+
+	// With a script the editor takes its input from the script and shows nothing. The script
+	// ends with an implicit :wq, and stops at the first input that fails.
+	out, read := t.Out, t.ReadLine
+	scriptLine := 0
+	if opts.Script != "" {
+		text, err := t.FS.Cat(opts.Script)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, strings.TrimPrefix(err.Error(), "cat: "))
+		}
+		script := append(strings.Split(strings.TrimSuffix(text, "\n"), "\n"), ":wq")
+		out = io.Discard
+		read = func() (string, error) {
+			line := script[scriptLine]
+			scriptLine++
+			return line, nil
+		}
+	}
 	file, err := t.FS.ResolvePath(filename)
+	if err != nil && opts.ReadOnly {
+		return fmt.Errorf("view: %s: %v", filename, err)
+	}
 	if err != nil {
 		// Create new file if not exists
 		dirPath, fileName := filepath.Split(filename)
@@ -814,8 +1730,18 @@ func (t *Terminal) Edit(filename string) error {
 		if dir.Type != Directory {
 			return fmt.Errorf("edit: %s: not a directory", dirPath)
 		}
-		file = NewFile(fileName, dir, []byte{})
+		file = t.FS.own(NewFile(fileName, dir, []byte{}))
 		dir.Children[fileName] = file
+		t.FS.record(createOp{file})
+	}
+	if file.Type == Directory {
+		return fmt.Errorf("%s: %s: is a directory", name, filename)
+	}
+	if err := t.FS.checkAccess(file, access, filename); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if isBinary(file.Content) {
+		return fmt.Errorf("%s: %s: binary file", name, filename)
 	}
 
 	// Load content into lines
@@ -825,82 +1751,266 @@ func (t *Terminal) Edit(filename string) error {
 		lines = lines[:len(lines)-1]
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	current := -1 // Index of the current line, marked on screen; -1 for none
+	if len(lines) > 0 && opts.Line != 0 {
+		current = min(opts.Line, len(lines)) - 1
+		if opts.Line < 0 {
+			current = len(lines) - 1
+		}
+	}
+	var search editorSearch
+	repeat := false        // Whether n repeats the search, as it does right after one
+	var clipboard []string // Lines yanked or deleted, for :p
+	var undo [][]string    // The buffer before each change, most recent last, for :u
+	saved := lines         // The buffer as last saved, telling :q whether there are unsaved changes
+	number := true         // Whether lines are shown with their numbers, see :set
+	var failure string     // Why the last input failed, which ends a script
+	fail := func(message string) {
+		failure = message
+		fmt.Fprintln(out, message)
+	}
 	for {
+		if failure != "" && opts.Script != "" {
+			return fmt.Errorf("%s: %s: line %d: %s", name, opts.Script, scriptLine, failure)
+		}
+		failure = ""
+
 		// Display current buffer with line numbers
-		fmt.Println("--- Editor ---")
+		fmt.Fprintln(out, "--- Editor ---")
 		for i, line := range lines {
-			fmt.Printf("%d: %s\n", i+1, line)
+			marker := ":"
+			if i == current {
+				marker = ">"
+				if !t.NoANSI {
+					line = search.highlight(line)
+				}
+			}
+			if number {
+				fmt.Fprintf(out, "%d%s %s\n", i+1, marker, line)
+			} else {
+				fmt.Fprintln(out, line)
+			}
 		}
-		fmt.Print("> ")
+		fmt.Fprint(out, "> ")
 
-		input, err := reader.ReadString('\n')
+		input, err := read()
 		if err != nil {
 			return err
 		}
 		input = strings.TrimSpace(input)
 
+		if pattern, ok := strings.CutPrefix(input, "/"); ok || (repeat && input == "n") {
+			if ok && pattern != "" {
+				search.pattern = pattern
+			}
+			repeat = search.pattern != ""
+			if !repeat {
+				fail("No previous search")
+			} else if n, found := search.next(lines, current); found {
+				current = n
+				fmt.Fprintf(out, "Found at line %d\n", n+1)
+			} else {
+				fail("Pattern not found: " + search.pattern)
+			}
+			continue
+		}
+		repeat = false
+		if opts.ReadOnly && changesBuffer(input) {
+			fail("read-only")
+			continue
+		}
+
 		if strings.HasPrefix(input, ":") {
 			cmd := strings.TrimPrefix(input, ":")
 			cmd = strings.TrimSpace(cmd)
+			if fields := strings.Fields(cmd); len(fields) > 0 && fields[0] == "set" {
+				for _, option := range fields[1:] {
+					switch option {
+					case "number", "nu":
+						number = true
+					case "nonumber", "nonu":
+						number = false
+					default:
+						fail("Unknown option: " + option)
+					}
+				}
+				if len(fields) == 1 {
+					if number {
+						fmt.Fprintln(out, "number")
+					} else {
+						fmt.Fprintln(out, "nonumber")
+					}
+				}
+				continue
+			} else if len(fields) == 2 && (fields[0] == "w" || fields[0] == "saveas") {
+				savedFile, err := t.saveBufferAs(lines, fields[1])
+				if err != nil {
+					fail(err.Error())
+					continue
+				}
+				// Writing to the file being edited saves the buffer, as :w does
+				if fields[0] == "saveas" || savedFile == file {
+					file, saved = savedFile, lines
+				}
+				fmt.Fprintf(out, "Saved to %s\n", fields[1])
+				continue
+			} else if len(fields) > 1 {
+				edited, message, err := rangeCommand(lines, &clipboard, fields[0], fields[1:])
+				if err != nil {
+					fail(err.Error())
+					continue
+				}
+				if !slices.Equal(edited, lines) {
+					undo = append(undo, lines)
+				}
+				lines = edited
+				current = -1
+				fmt.Fprintln(out, message)
+				continue
+			}
 			switch cmd {
+			case "u":
+				if len(undo) == 0 {
+					fail("Nothing to undo")
+					continue
+				}
+				lines, undo = undo[len(undo)-1], undo[:len(undo)-1]
+				current = -1
+				fmt.Fprintln(out, "Undone")
 			case "w":
 				newContent := strings.Join(lines, "\n") + "\n"
-				file.Content = []byte(newContent)
-				file.ModTime = time.Now()
-				file.Size = int64(len(newContent))
-				fmt.Println("Saved")
+				t.FS.record(newContentOp(file))
+				file.setContent([]byte(newContent))
+				saved = lines
+				fmt.Fprintln(out, "Saved")
 			case "q":
+				if !slices.Equal(lines, saved) {
+					fail("unsaved changes (use :q! to discard)")
+					continue
+				}
+				return nil
+			case "q!":
 				return nil
 			case "wq":
 				newContent := strings.Join(lines, "\n") + "\n"
-				file.Content = []byte(newContent)
-				file.ModTime = time.Now()
-				file.Size = int64(len(newContent))
-				fmt.Println("Saved and quit")
+				t.FS.record(newContentOp(file))
+				file.setContent([]byte(newContent))
+				fmt.Fprintln(out, "Saved and quit")
 				return nil
 			default:
-				fmt.Printf("Unknown command: %s\n", cmd)
+				fail("Unknown command: " + cmd)
 			}
 		} else if input == "" {
 			continue
 		} else {
-			// Insert/append the line
-			lines = append(lines, input)
+			// Insert/append the line, keeping the buffer as it was for :u
+			undo = append(undo, lines)
+			lines = append(lines[:len(lines):len(lines)], input)
 		}
 	}
 }
 
+// lineRead is the result of reading a line from In
+type lineRead struct {
+	line string
+	err  error
+}
+
+// ReadLine reads the next line of input from In without its line ending. A final line
+// without a newline is returned before io.EOF. The command loop and every command that
+// reads input share one buffer, so lines read ahead for one reader stay available to the next.
+// When the running command is interrupted while waiting, ReadLine returns ErrInterrupted and
+// the line it was waiting for goes to the next read.
+// With RawInput the line is edited as it is typed, see lineEditor.
+func (t *Terminal) ReadLine() (string, error) {
+	if t.pending == nil {
+		if t.reader == nil || t.readerSrc != t.In {
+			t.reader = bufio.NewReader(t.In)
+			t.readerSrc = t.In
+		}
+		reader, pending := t.reader, make(chan lineRead, 1)
+		read := func() (string, error) { return reader.ReadString('\n') }
+		if t.RawInput {
+			t.shellMu.Lock()
+			history := slices.Clone(t.History)
+			t.shellMu.Unlock()
+			editor := &lineEditor{in: reader, out: t.Out, history: history, match: -1}
+			read = editor.ReadLine
+		}
+		go func() {
+			line, err := read()
+			pending <- lineRead{line, err}
+		}()
+		t.pending = pending
+	}
+
+	select {
+	case r := <-t.pending:
+		t.pending = nil
+		if r.err == io.EOF && r.line != "" {
+			r.err = nil
+		}
+		return strings.TrimRight(r.line, "\r\n"), r.err
+	case <-t.context().Done():
+		return "", ErrInterrupted
+	}
+}
+
 // Clear clears the terminal screen
 func (t *Terminal) Clear() {
-	fmt.Print("\033[2J\033[H")
+	if !t.NoANSI {
+		fmt.Fprint(t.Out, clearSequence)
+	}
 }
 
-// Exit sets the terminal running state to false
+// ResetScreen puts the terminal display back in its initial state, leaving modes a program set
+// behind, and clears the screen and the scrollback
+func (t *Terminal) ResetScreen() {
+	if !t.NoANSI {
+		fmt.Fprint(t.Out, resetSequence)
+	}
+}
+
+const (
+	clearSequence = "\033[2J\033[H"                // Erase the screen and move the cursor home
+	resetSequence = "\033c\033[3J" + clearSequence // Full reset, then erase the scrollback and screen
+)
+
+// leaveSu leaves any su sessions for the user who started the first
+func (t *Terminal) leaveSu() {
+	if len(t.suStack) > 0 {
+		user := t.suStack[0]
+		t.User, t.FS.User, t.FS.Home = user, user, homeDir(user)
+		t.suStack = nil
+	}
+}
+
+// Quit leaves any su sessions and sets the terminal running state to false
+func (t *Terminal) Quit() {
+	t.leaveSu()
+	t.Exit()
+}
+
+// Exit leaves the innermost su session, or sets the terminal running state to false
+// when there is none
 func (t *Terminal) Exit() {
+	if n := len(t.suStack); n > 0 {
+		prev := t.suStack[n-1]
+		t.suStack = t.suStack[:n-1]
+		t.SetUser(prev)
+		return
+	}
 	t.Running = false
+	t.UndoStack = nil
 }
 
 // Help returns a string with available commands
 func (t *Terminal) Help() string {
-	helpText := `Available commands:
-	pwd - Print working directory
-	cd [path] - Change directory
-	mkdir [dirname] [-p] - Create directory
-	touch [filename] - Create empty file
-	ls [path] [-l] [-a] - List directory contents
-	rm [filename] [-r] - Delete file or directory
-	rmdir [dirname] - Remove empty directory
-	cp [source] [dest] [-r] - Copy file or directory
-	mv [source] [dest] - Move/rename file or directory
-	cat [filename] - Display file contents
-	echo [text] > [filename] - Write to file
-	echo [text] >> [filename] - Append to file
-	edit [filename] - Edit file
-	clear - Clear screen
-	exit - Exit emulator
-	quit - Exit emulator
-	help - Show this help
-	`
-	return helpText
+	var b strings.Builder
+	b.WriteString("Available commands:\n")
+	for _, c := range commandOrder {
+		fmt.Fprintf(&b, "\t%s - %s\n", c.Usage, c.Summary)
+	}
+	b.WriteString("Run 'help COMMAND' for details on a command.")
+	return b.String()
 }
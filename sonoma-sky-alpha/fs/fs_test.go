@@ -1,6 +1,9 @@
 package fs
 
 import (
+	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -67,6 +70,97 @@ func TestCd(t *testing.T) {
 	}
 }
 
+func TestCdSuggestion(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("xzy", false)
+	fs.Mkdir("projects/src", true)
+	fs.Touch("xyy")
+
+	err := fs.Cd("xyz")
+	if err == nil || err.Error() != "cd: no such directory 'xyz' (did you mean 'xzy'?)" {
+		t.Errorf("Expected a suggestion for a near miss, got %v", err)
+	}
+	err = fs.Cd("projects/scr")
+	if err == nil || err.Error() != "cd: no such directory 'projects/scr' (did you mean 'projects/src'?)" {
+		t.Errorf("Expected the suggestion to keep the parent path, got %v", err)
+	}
+	err = fs.Cd("/home/usr")
+	if err == nil || !strings.Contains(err.Error(), "did you mean '/home/user'?") {
+		t.Errorf("Expected a suggestion for an absolute path, got %v", err)
+	}
+
+	err = fs.Cd("wildlydifferent")
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Expected no suggestion for an unrelated name, got %v", err)
+	}
+	if fs.Pwd() != "/home/user" {
+		t.Errorf("A failed cd should stay put, got %s", fs.Pwd())
+	}
+}
+
+func TestCdErrors(t *testing.T) {
+	fs := NewFileSystem()
+	fs.PrevDir = nil
+	fs.Mkdir("locked/sub", true)
+	fs.Chmod("600", "locked")
+
+	for _, path := range []string{"-", "/nonexistent", "locked/sub", "wildlydifferent"} {
+		if err := fs.Cd(path); err == nil || !strings.HasPrefix(err.Error(), "cd: ") {
+			t.Errorf("cd %s: expected an error starting with cd:, got %v", path, err)
+		}
+	}
+}
+
+func TestCdFile(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("docs", false)
+	fs.Touch("docs/notes.txt")
+	fs.Touch("todo.txt")
+
+	err := fs.Cd("docs/notes.txt")
+	if err == nil || err.Error() != "cd: docs/notes.txt: Not a directory (regular file)" {
+		t.Errorf("cd to a file should name its type, got %v", err)
+	}
+	err = fs.Cd("docs/notes.txt/")
+	if err == nil || err.Error() != "cd: docs/notes.txt/: Not a directory (regular file)" {
+		t.Errorf("cd to a file with a trailing slash should name its type, got %v", err)
+	}
+
+	fs.CdHint = true
+	err = fs.Cd("docs/notes.txt")
+	if err == nil || err.Error() != "cd: docs/notes.txt: Not a directory (regular file); try 'cd docs'" {
+		t.Errorf("cd to a file should suggest its parent with CdHint, got %v", err)
+	}
+	// No hint when the file is in the current directory
+	err = fs.Cd("todo.txt")
+	if err == nil || err.Error() != "cd: todo.txt: Not a directory (regular file)" {
+		t.Errorf("cd to a file here should not suggest cd ., got %v", err)
+	}
+	if fs.Pwd() != "/home/user" {
+		t.Errorf("a failed cd should stay put, got %s", fs.Pwd())
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"xyz", "xzy", 2},
+		{"sl", "ls", 2},
+		{"abcd", "badc", 3},
+		{"héllo", "hello", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
 func TestMkdir(t *testing.T) {
 	fs := NewFileSystem()
 	err := fs.Mkdir("testdir", false)
@@ -90,6 +184,30 @@ func TestMkdir(t *testing.T) {
 	}
 }
 
+func TestMkdirMultiple(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("exists", false)
+
+	_, err := term.Execute("mkdir one exists missing/sub two")
+	if err == nil {
+		t.Fatal("Expected errors for the existing directory and the missing parent")
+	}
+	for _, path := range []string{"one", "two"} {
+		if isDir, _ := term.FS.IsDirectory(path); !isDir {
+			t.Errorf("mkdir should still create %s", path)
+		}
+	}
+	expected := "mkdir: cannot create directory 'exists': file exists\n" +
+		"mkdir: cannot create directory 'missing/sub': no such file or directory"
+	if err.Error() != expected {
+		t.Errorf("Expected an error per failed path, got %q", err.Error())
+	}
+
+	if _, err := term.Execute("mkdir -p exists one/deeper"); err != nil {
+		t.Errorf("mkdir -p should accept existing directories: %v", err)
+	}
+}
+
 func TestTouch(t *testing.T) {
 	fs := NewFileSystem()
 	err := fs.Touch("test.txt")
@@ -117,6 +235,54 @@ func TestTouch(t *testing.T) {
 	}
 }
 
+func TestTouchMultiple(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Touch("plain.txt")
+
+	_, err := term.Execute("touch a.txt missing/b.txt plain.txt/c.txt d.txt")
+	if err == nil {
+		t.Fatal("Expected an error for the invalid paths")
+	}
+	for _, path := range []string{"a.txt", "d.txt"} {
+		if _, err := term.FS.ResolvePath(path); err != nil {
+			t.Errorf("touch should still create %s: %v", path, err)
+		}
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "touch: missing/b.txt:") || !strings.Contains(msg, "touch: plain.txt/c.txt:") || strings.Count(msg, "\n") != 1 {
+		t.Errorf("Expected one error per failed path, got %q", msg)
+	}
+
+	// The valid files are created as one undoable step
+	term.Execute("undo")
+	if _, err := term.FS.ResolvePath("a.txt"); err == nil {
+		t.Error("undo should remove the files created by touch")
+	}
+}
+
+func TestRmDir(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("empty", false)
+	fs.Mkdir("full/sub", true)
+
+	if err := fs.Rm("empty", false); err == nil || err.Error() != "rm: empty: is a directory" {
+		t.Errorf("rm without -d should refuse an empty directory, got %v", err)
+	}
+	if err := fs.RmWith("empty", RmOptions{Dir: true}); err != nil {
+		t.Errorf("rm -d should remove an empty directory: %v", err)
+	}
+	if exists, _ := fs.Exists("empty"); exists {
+		t.Error("empty should be gone after rm -d")
+	}
+
+	if err := fs.RmWith("full", RmOptions{Dir: true}); err == nil || err.Error() != "rm: full: directory not empty" {
+		t.Errorf("rm -d should refuse a non-empty directory, got %v", err)
+	}
+	if exists, _ := fs.Exists("full/sub"); !exists {
+		t.Error("rm -d should leave a non-empty directory alone")
+	}
+}
+
 func TestLs(t *testing.T) {
 	fs := NewFileSystem()
 	err := fs.Mkdir("testdir", false)
@@ -145,6 +311,269 @@ func TestLs(t *testing.T) {
 	}
 }
 
+func TestLsFile(t *testing.T) {
+	fs := NewFileSystem()
+	if err := fs.EchoWrite("hello", "notes.txt", false); err != nil {
+		t.Fatal(err)
+	}
+	file, _ := fs.ResolvePath("notes.txt")
+
+	output, err := fs.Ls("notes.txt", false, false)
+	if err != nil || output != "notes.txt" {
+		t.Errorf("ls FILE should print the file name, got %q, %v", output, err)
+	}
+
+	output, err = fs.Ls("notes.txt", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "-rw-r--r-- 1 user user 6 " + file.ModTime.Format("Jan 02 15:04") + " notes.txt"
+	if output != expected {
+		t.Errorf("ls -l FILE should show permissions, size and time, got %q, want %q", output, expected)
+	}
+
+	output, _ = fs.Ls("/home/user/notes.txt", true, false)
+	if !strings.HasSuffix(output, " /home/user/notes.txt") {
+		t.Errorf("ls -l should name the file as given, got %q", output)
+	}
+}
+
+func TestLsMultiple(t *testing.T) {
+	term := NewTerminal()
+	fs := term.FS
+	fs.Touch("b.txt")
+	fs.Touch("a.txt")
+	fs.Touch("notes.md")
+	fs.Mkdir("docs", false)
+	fs.Touch("docs/readme")
+	fs.Mkdir("empty", false)
+
+	output, err := fs.LsAll([]string{"b.txt", "a.txt"}, LsOptions{})
+	if err != nil || output != "a.txt b.txt" {
+		t.Errorf("ls of two files should list them sorted on one line, got %q (%v)", output, err)
+	}
+
+	output, err = fs.LsAll([]string{"docs", "b.txt", "empty", "a.txt"}, LsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a.txt b.txt\n\ndocs:\nreadme\n\nempty:\n"; output != want {
+		t.Errorf("ls should list files first, then each directory under a header: expected %q, got %q", want, output)
+	}
+
+	output, err = fs.LsAll([]string{"docs", "missing"}, LsOptions{})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("ls should report a missing path, got %v", err)
+	}
+	if output != "docs:\nreadme" {
+		t.Errorf("ls should still list the paths that exist, got %q", output)
+	}
+
+	stdout, _, err := term.RunCommand("ls *.txt docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a.txt b.txt\n\ndocs:\nreadme\n"; stdout != want {
+		t.Errorf("ls *.txt docs: expected %q, got %q", want, stdout)
+	}
+}
+
+func TestLsDirectory(t *testing.T) {
+	term := NewTerminal()
+	fs := term.FS
+	fs.Mkdir("dir", false)
+	fs.Touch("dir/inner.txt")
+	fs.Mkdir("other", false)
+	fs.Touch("file.txt")
+
+	for _, path := range []string{"dir", "."} {
+		output, err := fs.LsWith(path, LsOptions{Directory: true})
+		if err != nil || output != path {
+			t.Errorf("ls -d %s: expected %q, got %q (%v)", path, path, output, err)
+		}
+	}
+
+	output, _ := fs.LsWith("dir", LsOptions{Directory: true, Long: true})
+	if !strings.HasPrefix(output, "drwx") || !strings.HasSuffix(output, " dir") {
+		t.Errorf("ls -ld dir should describe the directory itself, got %q", output)
+	}
+
+	stdout, _, err := term.RunCommand("ls -d */")
+	if err != nil || stdout != "dir/ other/\n" {
+		t.Errorf("ls -d */ should list only the subdirectories, got %q (%v)", stdout, err)
+	}
+}
+
+func TestLsInode(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Touch("a.txt")
+	fs.Touch("b.txt")
+	a, _ := fs.ResolvePath("a.txt")
+	b, _ := fs.ResolvePath("b.txt")
+	if a.Ino == 0 || a.Ino == b.Ino {
+		t.Fatalf("distinct files should have distinct inode numbers, got %d and %d", a.Ino, b.Ino)
+	}
+
+	output, err := fs.LsWith("a.txt", LsOptions{Inode: true})
+	if want := fmt.Sprintf("%d a.txt", a.Ino); err != nil || output != want {
+		t.Errorf("ls -i a.txt: expected %q, got %q (%v)", want, output, err)
+	}
+	output, _ = fs.LsWith("a.txt", LsOptions{Inode: true, Long: true})
+	if !strings.HasPrefix(output, fmt.Sprintf("%d -rw", a.Ino)) {
+		t.Errorf("ls -li should put the inode before the long line, got %q", output)
+	}
+
+	// The number belongs to the node, so it survives a rename
+	fs.Mv("a.txt", "c.txt")
+	output, _ = fs.LsAll([]string{"b.txt", "c.txt"}, LsOptions{Inode: true})
+	if want := fmt.Sprintf("%d b.txt %d c.txt", b.Ino, a.Ino); output != want {
+		t.Errorf("expected %q, got %q", want, output)
+	}
+}
+
+func TestLsLinkCount(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("project/src", true)
+	fs.Mkdir("project/docs", true)
+	fs.Touch("project/README")
+
+	linkField := func(path string) string {
+		output, err := fs.LsWith(path, LsOptions{Long: true, Directory: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Fields(output)[1]
+	}
+	if n := linkField("project"); n != "4" {
+		t.Errorf("a directory with two subdirectories should have 4 links, got %s", n)
+	}
+	if n := linkField("project/README"); n != "1" {
+		t.Errorf("a file should have 1 link, got %s", n)
+	}
+
+	fs.Rm("project/docs", true)
+	if n := linkField("project"); n != "3" {
+		t.Errorf("removing a subdirectory should drop the count to 3, got %s", n)
+	}
+	if n := linkField("project/src"); n != "2" {
+		t.Errorf("an empty directory should have 2 links, got %s", n)
+	}
+}
+
+func TestLsHidden(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("project", false)
+	term.FS.Touch("project/.env")
+	term.FS.Touch("project/main.go")
+
+	entries := func(input string) []string {
+		output, err := term.Execute(input)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		names := strings.Fields(output)
+		sort.Strings(names)
+		return names
+	}
+	if names := entries("ls project"); !slices.Equal(names, []string{"main.go"}) {
+		t.Errorf("ls should leave out hidden entries, got %q", names)
+	}
+	if names := entries("ls -a project"); !slices.Equal(names, []string{".", "..", ".env", "main.go"}) {
+		t.Errorf("ls -a should list . and .. and dotfiles, got %q", names)
+	}
+	if names := entries("ls -A project"); !slices.Equal(names, []string{".env", "main.go"}) {
+		t.Errorf("ls -A should list dotfiles without . and .., got %q", names)
+	}
+	if names := entries("ls -A -a project"); len(names) != 4 {
+		t.Errorf("the last of -a and -A should win, got %q", names)
+	}
+
+	// In long format . and .. describe the directory and its parent; the root is its own parent
+	output, _ := term.Execute("ls -l -a -i project")
+	lines := strings.Split(output, "\n")
+	project, _ := term.FS.ResolvePath("project")
+	if !strings.HasPrefix(lines[0], fmt.Sprintf("%d drwxr-xr-x 2 ", project.Ino)) || !strings.HasSuffix(lines[0], " .") {
+		t.Errorf("Expected . to describe project, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], fmt.Sprintf("%d drwxr-xr-x 3 ", project.Parent.Ino)) || !strings.HasSuffix(lines[1], " ..") {
+		t.Errorf("Expected .. to describe the home directory, got %q", lines[1])
+	}
+	root, _ := term.FS.ResolvePath("/")
+	if output, _ := term.Execute("ls -a -i /"); !strings.HasPrefix(output, fmt.Sprintf("%d . %d ..", root.Ino, root.Ino)) {
+		t.Errorf("Expected the root to be its own parent, got %q", output)
+	}
+}
+
+func TestLsTotal(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("project/src/deep", true)
+	term.FS.EchoWrite("0123456789", "project/README", false)        // 11 bytes
+	term.FS.EchoWrite("package main", "project/src/main.go", false) // 13 bytes
+	term.FS.EchoWrite("x", "project/src/deep/x.txt", false)         // 2 bytes
+	term.FS.EchoWrite("SECRET=1", "project/.env", false)            // 9 bytes
+
+	tests := []struct {
+		input    string
+		expected string // Last line of the output
+	}{
+		{"ls --total project", "total 26 bytes"},
+		{"ls -l --total project", "total 26 bytes"},
+		{"ls -A --total project", "total 35 bytes"},
+		{"ls --total project/src", "total 15 bytes"},
+		{"ls --total project/README project/src", "total 26 bytes"},
+		{"ls -d --total project", "total 35 bytes"},
+		{"ls --total project/src/deep/x.txt", "total 2 bytes"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		lines := strings.Split(output, "\n")
+		if lines[len(lines)-1] != tt.expected {
+			t.Errorf("%s: expected %q last, got %q", tt.input, tt.expected, output)
+		}
+	}
+
+	term.FS.Mkdir("empty", false)
+	if output, _ := term.Execute("ls --total empty"); output != "total 0 bytes" {
+		t.Errorf("Expected only the total for an empty directory, got %q", output)
+	}
+}
+
+func TestLsTimeStyle(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Touch("notes.txt")
+	file, _ := term.FS.ResolvePath("notes.txt")
+	file.ModTime = time.Date(2024, time.March, 5, 14, 7, 9, 120000000, time.FixedZone("", 2*60*60))
+
+	tests := []struct {
+		style    string
+		expected string
+	}{
+		{"", "Mar 05 14:07"},
+		{" --time-style=default", "Mar 05 14:07"},
+		{" --time-style=iso", "2024-03-05 14:07"},
+		{" --time-style=full-iso", "2024-03-05 14:07:09.120000000 +0200"},
+		{" --time-style=+2006/01/02", "2024/03/05"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute("ls -l" + tt.style + " notes.txt")
+		if err != nil {
+			t.Errorf("ls -l%s: %v", tt.style, err)
+			continue
+		}
+		if expected := "-rw-r--r-- 1 user user 0 " + tt.expected + " notes.txt"; output != expected {
+			t.Errorf("ls -l%s: expected %q, got %q", tt.style, expected, output)
+		}
+	}
+
+	if _, err := term.Execute("ls -l --time-style=fancy"); err == nil {
+		t.Error("An unknown time style should error")
+	}
+}
+
 func TestCat(t *testing.T) {
 	fs := NewFileSystem()
 	err := fs.Touch("test.txt")
@@ -165,6 +594,50 @@ func TestCat(t *testing.T) {
 	}
 }
 
+func TestCatFiles(t *testing.T) {
+	term := NewTerminal()
+	term.FS.EchoWrite("one", "a.txt", false)
+	term.FS.EchoWrite("two", "b.txt", false)
+	term.FS.EchoWriteWith("no newline", "c.txt", false, EchoOptions{NoNewline: true})
+	term.FS.EchoWriteWith("last", "d.txt", false, EchoOptions{NoNewline: true})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"cat a.txt b.txt", "one\ntwo\n"},
+		{"cat c.txt a.txt", "no newlineone\n"},
+		{"cat a.txt c.txt d.txt", "one\nno newlinelast"},
+		{"cat c.txt c.txt", "no newlineno newline"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil || output != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.input, tt.expected, output, err)
+		}
+	}
+
+	// A file that can't be read is reported, and the others still printed
+	output, err := term.Execute("cat a.txt missing.txt b.txt")
+	if output != "one\ntwo\n" || err == nil || !strings.Contains(err.Error(), "cat: missing.txt") {
+		t.Errorf("Expected the readable files and an error for the missing one, got %q (%v)", output, err)
+	}
+}
+
+func TestCatBinary(t *testing.T) {
+	fs := NewFileSystem()
+	fs.EchoWrite("ELF\x00\x01data", "prog.bin", false)
+
+	_, err := fs.Cat("prog.bin")
+	if err == nil || err.Error() != "cat: prog.bin: binary file (use -f to force)" {
+		t.Errorf("cat of a file with NUL bytes should refuse, got %v", err)
+	}
+	output, err := fs.CatWith("prog.bin", CatOptions{Force: true})
+	if err != nil || output != "ELF\x00\x01data\n" {
+		t.Errorf("cat -f should print binary content, got %q (%v)", output, err)
+	}
+}
+
 func TestEcho(t *testing.T) {
 	fs := NewFileSystem()
 	err := fs.EchoWrite("Hello", "test.txt", false)
@@ -185,3 +658,415 @@ func TestEcho(t *testing.T) {
 		t.Error("echo >> should append")
 	}
 }
+
+func TestFileSystemForUsers(t *testing.T) {
+	fs, err := NewFileSystemFor("alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.Pwd() != "/home/alice" || fs.User != "alice" {
+		t.Errorf("alice should start in /home/alice, got %s as %s", fs.Pwd(), fs.User)
+	}
+	if err := fs.Touch("~/notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := fs.Exists("/home/alice/notes.txt"); !exists {
+		t.Error("~ should resolve under /home/alice")
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		home, err := fs.ResolvePath("/home/" + name)
+		if err != nil {
+			t.Fatalf("%s should have a home: %v", name, err)
+		}
+		if home.Owner != name {
+			t.Errorf("/home/%s should belong to %s, got %s", name, name, home.Owner)
+		}
+	}
+	if exists, _ := fs.Exists("/home/user"); exists {
+		t.Error("/home/user should only exist for the default user")
+	}
+
+	fs.Cd("/home/bob")
+	if err := fs.Cd("~"); err != nil || fs.Pwd() != "/home/alice" {
+		t.Errorf("cd ~ should return to /home/alice, got %s (%v)", fs.Pwd(), err)
+	}
+
+	fs.Reset()
+	if exists, _ := fs.Exists("/home/bob"); !exists || fs.Pwd() != "/home/alice" {
+		t.Errorf("reset should rebuild every configured home and enter alice's, got %s", fs.Pwd())
+	}
+
+	if _, err := NewFileSystemFor("a/b"); err == nil {
+		t.Error("a user name with a slash should be rejected")
+	}
+}
+
+func TestTildeUser(t *testing.T) {
+	fs, _ := NewFileSystemFor("bob", "alice")
+	if err := fs.EchoWrite("hi", "/home/alice/file", false); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := fs.ResolvePath("~alice/file")
+	if err != nil || file.Name != "file" {
+		t.Fatalf("~alice/file should resolve under /home/alice, got %v", err)
+	}
+	if err := fs.Cd("~alice"); err != nil || fs.Pwd() != "/home/alice" {
+		t.Errorf("cd ~alice should enter /home/alice, got %s (%v)", fs.Pwd(), err)
+	}
+	if err := fs.Cd("~bob/"); err != nil || fs.Pwd() != "/home/bob" {
+		t.Errorf("~bob/ should be the current user's home, got %s (%v)", fs.Pwd(), err)
+	}
+
+	for _, path := range []string{"~mallory", "~mallory/file"} {
+		if _, err := fs.ResolvePath(path); err == nil || err.Error() != "no such user: mallory" {
+			t.Errorf("%s should fail with no such user, got %v", path, err)
+		}
+	}
+	if err := fs.Cd("~mallory"); err == nil || !strings.Contains(err.Error(), "no such user") {
+		t.Errorf("cd ~mallory should fail with no such user, got %v", err)
+	}
+}
+
+func TestTerminalForUser(t *testing.T) {
+	term, err := NewTerminalFor("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if term.Whoami() != "alice" {
+		t.Errorf("expected alice, got %s", term.Whoami())
+	}
+	term.RunCommand("cd /")
+	term.RunCommand("cd ~")
+	if stdout, _, _ := term.RunCommand("pwd"); stdout != "/home/alice\n" {
+		t.Errorf("cd ~ should enter /home/alice, got %q", stdout)
+	}
+}
+
+func TestWhoami(t *testing.T) {
+	term := NewTerminal()
+	if term.Whoami() != "user" {
+		t.Errorf("Expected default user 'user', got %s", term.Whoami())
+	}
+
+	err := term.SetUser("alice")
+	if err != nil {
+		t.Error(err)
+	}
+	if term.Whoami() != "alice" {
+		t.Errorf("Expected alice, got %s", term.Whoami())
+	}
+	if !strings.Contains(term.Id(), "uid=1000(alice)") {
+		t.Errorf("id should report alice, got %s", term.Id())
+	}
+
+	home, err := term.FS.ResolvePath("~")
+	if err != nil {
+		t.Error(err)
+	}
+	if term.FS.GetPath(home) != "/home/alice" {
+		t.Errorf("~ should resolve to /home/alice, got %s", term.FS.GetPath(home))
+	}
+
+	err = term.FS.Cd("~")
+	if err != nil {
+		t.Error(err)
+	}
+	term.Prompt = `\u:\w$ `
+	if term.RenderPrompt() != "alice:/home/alice$ " {
+		t.Errorf("Unexpected prompt %q", term.RenderPrompt())
+	}
+}
+
+func TestChown(t *testing.T) {
+	fs := NewFileSystem()
+	err := fs.Touch("file.txt")
+	if err != nil {
+		t.Error(err)
+	}
+
+	file, _ := fs.ResolvePath("file.txt")
+	if file.Owner != "user" || file.Group != "user" {
+		t.Errorf("New file should be owned by user:user, got %s:%s", file.Owner, file.Group)
+	}
+
+	fs.User = RootUser
+	err = fs.Chown("alice", "file.txt")
+	if err != nil {
+		t.Error(err)
+	}
+	if file.Owner != "alice" || file.Group != "user" {
+		t.Errorf("Expected alice:user, got %s:%s", file.Owner, file.Group)
+	}
+
+	err = fs.Chown("alice:staff", "file.txt")
+	if err != nil {
+		t.Error(err)
+	}
+	if file.Owner != "alice" || file.Group != "staff" {
+		t.Errorf("Expected alice:staff, got %s:%s", file.Owner, file.Group)
+	}
+
+	output, err := fs.Ls(".", true, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(output, " alice staff 0 ") {
+		t.Errorf("ls -l should show owner and group columns, got %s", output)
+	}
+
+	err = fs.Chown("bob", "missing.txt")
+	if err == nil {
+		t.Error("chown of a missing file should error")
+	}
+}
+
+func TestChownRecursive(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("dir/sub", true)
+	term.FS.Touch("dir/a.txt")
+	term.FS.Touch("dir/sub/b.txt")
+
+	output, err := term.Execute("sudo chown -Rv alice:staff dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"dir", "dir/a.txt", "dir/sub", "dir/sub/b.txt"} {
+		file, _ := term.FS.ResolvePath(path)
+		if file.Owner != "alice" || file.Group != "staff" {
+			t.Errorf("%s: expected alice:staff, got %s:%s", path, file.Owner, file.Group)
+		}
+	}
+	if !strings.Contains(output, "changed ownership of 'dir/sub/b.txt' from user:user to alice:staff") || strings.Count(output, "\n") != 3 {
+		t.Errorf("chown -v should report each changed path, got:\n%s", output)
+	}
+
+	term.FS.Touch("other.txt")
+	term.Execute("sudo chown bob dir other.txt")
+	if file, _ := term.FS.ResolvePath("dir/a.txt"); file.Owner != "alice" {
+		t.Error("chown without -R should leave descendants alone")
+	}
+	if file, _ := term.FS.ResolvePath("other.txt"); file.Owner != "bob" || file.Group != "user" {
+		t.Errorf("Expected bob:user, got %s:%s", file.Owner, file.Group)
+	}
+}
+
+func TestCatMaxOutput(t *testing.T) {
+	fs := NewFileSystem()
+	fs.MaxOutput = 1 << 20
+	large := strings.Repeat("x", 3<<20)
+	err := fs.EchoWrite(large, "big.txt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := fs.Cat("big.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(output, "\n"+TruncatedNotice) {
+		t.Error("truncated cat should end with the notice")
+	}
+	if len(output) != fs.MaxOutput+len("\n"+TruncatedNotice) {
+		t.Errorf("Expected output cut at %d bytes, got %d", fs.MaxOutput, len(output)-len("\n"+TruncatedNotice))
+	}
+
+	fs.MaxOutput = 0
+	output, _ = fs.Cat("big.txt")
+	if len(output) != len(large)+1 || strings.Contains(output, TruncatedNotice) {
+		t.Error("a zero limit should disable truncation")
+	}
+}
+
+func TestCpSharesContentUntilWritten(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("src", false)
+	fs.EchoWrite("original", "src/a.txt", false)
+	fs.EchoWrite("more", "src/a.txt", true)
+
+	if err := fs.Cp("src", "dst", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Cp("src/a.txt", "b.txt", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Appending to each copy must not leak into the other or into the original
+	fs.EchoWrite("from dst", "dst/a.txt", true)
+	fs.EchoWrite("from b", "b.txt", true)
+	fs.EchoWrite("from src", "src/a.txt", true)
+
+	expected := map[string]string{
+		"src/a.txt": "original\nmore\nfrom src\n",
+		"dst/a.txt": "original\nmore\nfrom dst\n",
+		"b.txt":     "original\nmore\nfrom b\n",
+	}
+	for path, want := range expected {
+		got, _ := fs.Cat(path)
+		if got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+
+	fs.EchoWrite("replaced", "dst/a.txt", false)
+	if got, _ := fs.Cat("src/a.txt"); got != expected["src/a.txt"] {
+		t.Errorf("overwriting the copy changed the original to %q", got)
+	}
+}
+
+func TestReset(t *testing.T) {
+	term := NewTerminal()
+	fs := term.FS
+	fs.Mkdir("project/src", true)
+	fs.EchoWrite("data", "project/notes.txt", false)
+	fs.Cd("project")
+	fs.Rm("notes.txt", false)
+	fs.UpdateDB()
+	run(term, func() error { return fs.Touch("/tmp.txt") })
+
+	term.Reset()
+	if fs.Pwd() != "/home/user" {
+		t.Errorf("reset should return to /home/user, got %s", fs.Pwd())
+	}
+	for _, path := range []string{"/home/user/project", "/tmp.txt"} {
+		if exists, _ := fs.Exists(path); exists {
+			t.Errorf("%s should not survive a reset", path)
+		}
+	}
+	if output, _ := fs.Ls("/", false, true); output != ". .. home" {
+		t.Errorf("root should only contain home after reset, got %q", output)
+	}
+	if len(fs.Trash) != 0 || fs.Index != nil || len(term.UndoStack) != 0 {
+		t.Error("reset should clear the trash, locate index and undo stack")
+	}
+
+	// The next session starts clean and works normally
+	if err := fs.Touch("fresh.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if output, _ := fs.Ls(".", false, false); output != "fresh.txt" {
+		t.Errorf("Expected only fresh.txt, got %q", output)
+	}
+}
+
+func TestResetKeepsUser(t *testing.T) {
+	term := NewTerminal()
+	term.SetUser("alice")
+	term.FS.Cd("~")
+	term.FS.Touch("mine.txt")
+
+	term.Reset()
+	if term.FS.Pwd() != "/home/alice" {
+		t.Errorf("reset should return to the user's home, got %s", term.FS.Pwd())
+	}
+	if exists, _ := term.FS.Exists("mine.txt"); exists {
+		t.Error("files in the user's home should not survive a reset")
+	}
+	if err := term.FS.Touch("again.txt"); err != nil {
+		t.Errorf("the recreated home should be writable by its user: %v", err)
+	}
+}
+
+func TestCpMvIntoSelf(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("a/b", true)
+	fs.Touch("f.txt")
+
+	if err := fs.Cp("a", "a/b", true); err == nil {
+		t.Error("Copying a directory into itself should fail")
+	}
+	if err := fs.Cp("f.txt", ".", false); err == nil {
+		t.Error("Copying a file onto itself should fail")
+	}
+	if err := fs.Mv("a", "a/b/c"); err == nil {
+		t.Error("Moving a directory into its own subdirectory should fail")
+	}
+	if dir, _ := fs.ResolvePath("a/b"); dir == nil || dir.Parent.Name != "a" {
+		t.Error("Failed moves must leave the tree unchanged")
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("dir", false)
+	fs.EchoWrite("data", "f", false)
+
+	if err := fs.Cp("f", "dir/", false); err != nil {
+		t.Fatalf("cp f dir/ should copy into the directory: %v", err)
+	}
+	if _, err := fs.ResolvePath("dir/f"); err != nil {
+		t.Error("cp f dir/ should create dir/f")
+	}
+
+	if err := fs.Cp("f", "missing/", false); err == nil {
+		t.Error("cp f missing/ should error")
+	}
+	if _, err := fs.ResolvePath("missing"); err == nil {
+		t.Error("cp f missing/ should not create missing")
+	}
+	if err := fs.Cp("f", "dir/f/", false); err == nil {
+		t.Error("cp onto an existing file with a trailing slash should error")
+	}
+	if err := fs.Mv("f", "missing/"); err == nil {
+		t.Error("mv f missing/ should error")
+	}
+
+	if err := fs.Touch("newfile/"); err == nil {
+		t.Error("touch newfile/ should error")
+	}
+	if _, err := fs.ResolvePath("newfile"); err == nil {
+		t.Error("touch newfile/ should not create newfile")
+	}
+	if err := fs.Touch("f/"); err == nil {
+		t.Error("touch of a file with a trailing slash should error")
+	}
+	if err := fs.Touch("dir/"); err != nil {
+		t.Errorf("touch dir/ should update the directory: %v", err)
+	}
+	dir, _ := fs.ResolvePath("dir")
+	if _, exists := dir.Children[""]; exists {
+		t.Error("touch dir/ should not create an empty-named file")
+	}
+
+	if _, err := fs.Cat("f/"); err == nil {
+		t.Error("a trailing slash should not resolve to a file")
+	}
+
+	// A directory can be copied or moved to a new name given with a trailing slash
+	if err := fs.Cp("dir", "copy/", true); err != nil {
+		t.Errorf("cp -r dir copy/ should create copy: %v", err)
+	}
+	if err := fs.Mv("copy", "moved/"); err != nil {
+		t.Errorf("mv copy moved/ should rename the directory: %v", err)
+	}
+	if isDir, _ := fs.IsDirectory("moved/f"); isDir {
+		t.Error("moved/f should be a file")
+	}
+	if _, err := fs.ResolvePath("moved/f"); err != nil {
+		t.Errorf("moved should hold the copied file: %v", err)
+	}
+}
+
+func TestDestinationResolveErrors(t *testing.T) {
+	fs := NewFileSystem()
+	fs.EchoWrite("data", "f", false)
+	fs.Mkdir("locked", false)
+	fs.Mkdir("locked/sub", false)
+	fs.Chmod("600", "locked")
+
+	// Only a destination that does not exist is created; a search failure is reported
+	for _, err := range []error{fs.Cp("f", "locked/sub", false), fs.Mv("f", "locked/sub")} {
+		if err == nil || !strings.Contains(err.Error(), "permission denied") {
+			t.Errorf("Expected permission denied, got %v", err)
+		}
+	}
+	fs.Chmod("755", "locked")
+	if isDir, err := fs.IsDirectory("locked/sub"); err != nil || !isDir {
+		t.Errorf("locked/sub should still be a directory, got %v", err)
+	}
+
+	if err := fs.Cp("f", "f/x", false); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("Expected not a directory for a file mid-path, got %v", err)
+	}
+}
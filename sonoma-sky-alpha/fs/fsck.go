@@ -0,0 +1,117 @@
+package fs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FsckProblem is an inconsistency found in the tree
+type FsckProblem struct {
+	Path     string
+	Message  string
+	Repaired bool
+}
+
+// String formats the problem as "path: message", noting whether it was repaired
+func (p FsckProblem) String() string {
+	if p.Repaired {
+		return fmt.Sprintf("%s: %s (repaired)", p.Path, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// Fsck walks the tree from the root checking that every node points back to the directory
+// holding it under the name it is held by, that no node is reachable twice (which includes
+// cycles), that directories have a Children map and that file sizes match their content.
+// With repair set, each problem is fixed in place: pointers and sizes are corrected and
+// entries leading to an already visited node are removed.
+func (fs *FileSystem) Fsck(repair bool) []FsckProblem {
+	var problems []FsckProblem
+	report := func(path, format string, args ...interface{}) {
+		problems = append(problems, FsckProblem{Path: path, Message: fmt.Sprintf(format, args...), Repaired: repair})
+	}
+
+	if fs.Root.Parent != nil {
+		report("/", "root has a parent")
+		if repair {
+			fs.Root.Parent = nil
+		}
+	}
+
+	visited := map[*VirtualFile]string{fs.Root: "/"}
+	var check func(dir *VirtualFile, path string)
+	check = func(dir *VirtualFile, path string) {
+		if dir.Children == nil {
+			report(path, "directory has no children map")
+			if repair {
+				dir.Children = make(map[string]*VirtualFile)
+			}
+			return
+		}
+
+		names := make([]string, 0, len(dir.Children))
+		for name := range dir.Children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			child := dir.Children[name]
+			childPath := strings.TrimSuffix(path, "/") + "/" + name
+			if child == nil {
+				report(childPath, "entry has no node")
+				if repair {
+					delete(dir.Children, name)
+				}
+				continue
+			}
+			if first, seen := visited[child]; seen {
+				report(childPath, "node is already linked at %s", first)
+				if repair {
+					delete(dir.Children, name)
+				}
+				continue
+			}
+			visited[child] = childPath
+
+			if child.Parent != dir {
+				report(childPath, "parent pointer does not point to %s", path)
+				if repair {
+					child.Parent = dir
+				}
+			}
+			if child.Name != name {
+				report(childPath, "name is %q", child.Name)
+				if repair {
+					child.Name = name
+				}
+			}
+
+			switch child.Type {
+			case Directory:
+				check(child, childPath)
+			case RegularFile:
+				if child.Size != int64(len(child.Content)) {
+					report(childPath, "size is %d but content is %d bytes", child.Size, len(child.Content))
+					if repair {
+						child.Size = int64(len(child.Content))
+					}
+				}
+				if child.Children != nil {
+					report(childPath, "file has a children map")
+					if repair {
+						child.Children = nil
+					}
+				}
+			}
+		}
+	}
+	check(fs.Root, "/")
+
+	if repair && len(problems) > 0 {
+		fs.pathGen++
+		fs.invalidate()
+	}
+	return problems
+}
@@ -0,0 +1,95 @@
+package fs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFsckCleanTree(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("a/b", true)
+	fs.EchoWrite("hello", "a/b/f.txt", false)
+
+	if problems := fs.Fsck(false); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+}
+
+func TestFsckDetectsAndRepairs(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("a/b", true)
+	fs.Mkdir("empty", false)
+	fs.EchoWrite("hello", "a/f.txt", false)
+	a, _ := fs.ResolvePath("a")
+	b, _ := fs.ResolvePath("a/b")
+	file, _ := fs.ResolvePath("a/f.txt")
+	empty, _ := fs.ResolvePath("empty")
+
+	// Corrupt the tree in every way fsck knows about
+	file.Size = 99
+	file.Parent = b
+	b.Name = "wrong"
+	b.Children["loop"] = a
+	empty.Children = nil
+
+	problems := fs.Fsck(false)
+	want := []string{
+		"/home/user/a/b: name is \"wrong\"",
+		"/home/user/a/b/loop: node is already linked at /home/user/a",
+		"/home/user/a/f.txt: parent pointer does not point to /home/user/a",
+		"/home/user/a/f.txt: size is 99 but content is 6 bytes",
+		"/home/user/empty: directory has no children map",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems, got %v", len(want), problems)
+	}
+	for i, problem := range problems {
+		if problem.String() != want[i] || problem.Repaired {
+			t.Errorf("Problem %d: expected %q, got %q", i, want[i], problem.String())
+		}
+	}
+
+	// Checking alone must not change anything
+	if file.Size != 99 || b.Children["loop"] != a {
+		t.Fatal("Fsck without repair should leave the tree untouched")
+	}
+
+	for _, problem := range fs.Fsck(true) {
+		if !problem.Repaired {
+			t.Errorf("Expected %q to be repaired", problem)
+		}
+	}
+	if problems := fs.Fsck(false); len(problems) != 0 {
+		t.Errorf("Expected a repaired tree to be clean, got %v", problems)
+	}
+	if file.Size != 6 || file.Parent != a || b.Name != "b" || empty.Children == nil {
+		t.Error("Repair should restore sizes, parents, names and children maps")
+	}
+	if _, exists := b.Children["loop"]; exists {
+		t.Error("Repair should remove the entry forming a cycle")
+	}
+	if got := fs.GetPath(b); got != "/home/user/a/b" {
+		t.Errorf("Expected GetPath to see the repaired name, got %s", got)
+	}
+}
+
+func TestFsckCommand(t *testing.T) {
+	term := NewTerminal()
+	output, err := term.Execute("fsck")
+	if err != nil || output != "fsck: no problems found" {
+		t.Fatalf("Unexpected clean fsck output %q (%v)", output, err)
+	}
+
+	term.FS.EchoWrite("hi", "f.txt", false)
+	file, _ := term.FS.ResolvePath("f.txt")
+	file.Size = 0
+
+	output, err = term.Execute("fsck")
+	if err == nil || term.Status != 1 || !strings.Contains(output, "size is 0") {
+		t.Errorf("Expected fsck to fail on a stale size, got %q (%v)", output, err)
+	}
+	output, err = term.Execute("fsck -r")
+	if err != nil || !strings.HasSuffix(output, "fsck: 1 problems repaired") {
+		t.Errorf("Expected fsck -r to repair, got %q (%v)", output, err)
+	}
+}
@@ -0,0 +1,118 @@
+package fs
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hasGlobMeta reports whether path contains a *, ? or [ wildcard
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// Glob returns the paths matching pattern, sorted, written relative or absolute as the
+// pattern is. Wildcards match within a single path component, and as in a shell a name
+// starting with a dot is only matched by a component that starts with a dot too. A
+// trailing slash matches directories only and is kept on each match.
+func (fs *FileSystem) Glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s'", pattern)
+	}
+
+	start, prefix := fs.CurrentDir, ""
+	if strings.HasPrefix(pattern, "/") {
+		start, prefix = fs.Root, "/"
+	}
+	nodes, names := []*VirtualFile{start}, []string{prefix}
+	join := func(base, name string) string {
+		if base == "" || strings.HasSuffix(base, "/") {
+			return base + name
+		}
+		return base + "/" + name
+	}
+
+	for _, part := range strings.Split(pattern, "/") {
+		if part == "" {
+			continue
+		}
+		var nextNodes []*VirtualFile
+		var nextNames []string
+		for i, node := range nodes {
+			if node.Type != Directory {
+				continue
+			}
+			if !hasGlobMeta(part) {
+				child := node.Children[part]
+				switch part {
+				case ".":
+					child = node
+				case "..":
+					child = node.Parent
+					if child == nil {
+						child = node
+					}
+				}
+				if child != nil {
+					nextNodes = append(nextNodes, child)
+					nextNames = append(nextNames, join(names[i], part))
+				}
+				continue
+			}
+			// Unreadable directories contribute no matches, as in a shell
+			if !fs.CanAccess(node, AccessRead) {
+				continue
+			}
+			children := make([]string, 0, len(node.Children))
+			for name := range node.Children {
+				children = append(children, name)
+			}
+			sort.Strings(children)
+			for _, name := range children {
+				if strings.HasPrefix(name, ".") && !strings.HasPrefix(part, ".") {
+					continue
+				}
+				if ok, _ := filepath.Match(part, name); ok {
+					nextNodes = append(nextNodes, node.Children[name])
+					nextNames = append(nextNames, join(names[i], name))
+				}
+			}
+		}
+		nodes, names = nextNodes, nextNames
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		var dirs []string
+		for i, node := range nodes {
+			if node.Type == Directory {
+				dirs = append(dirs, join(names[i], ""))
+			}
+		}
+		names = dirs
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// expandGlobs replaces each argument holding a wildcard with the paths it matches. An
+// argument that matches nothing is kept as it is, so the command reports it as missing.
+func (fs *FileSystem) expandGlobs(args []string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		if !hasGlobMeta(arg) {
+			expanded = append(expanded, arg)
+			continue
+		}
+		matches, err := fs.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			expanded = append(expanded, arg)
+			continue
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded, nil
+}
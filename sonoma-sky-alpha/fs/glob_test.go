@@ -0,0 +1,46 @@
+package fs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("src/app", true)
+	fs.Mkdir("src/lib", true)
+	for _, name := range []string{"a.txt", "b.txt", "c.go", ".hidden.txt", "src/app/main.go", "src/lib/util.go"} {
+		if err := fs.Touch(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.txt", []string{"a.txt", "b.txt"}},
+		{".*.txt", []string{".hidden.txt"}},
+		{"?.go", []string{"c.go"}},
+		{"[ab].txt", []string{"a.txt", "b.txt"}},
+		{"src/*/*.go", []string{"src/app/main.go", "src/lib/util.go"}},
+		{"/home/user/src/a*", []string{"/home/user/src/app"}},
+		{"src/*/", []string{"src/app/", "src/lib/"}},
+		{"*/", []string{"src/"}},
+		{"*.md", nil},
+	}
+	for _, tt := range tests {
+		got, err := fs.Glob(tt.pattern)
+		if err != nil {
+			t.Errorf("%s: %v", tt.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.pattern, tt.want, got)
+		}
+	}
+
+	if _, err := fs.Glob("[a"); err == nil {
+		t.Error("a malformed pattern should be an error")
+	}
+}
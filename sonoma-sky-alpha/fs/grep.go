@@ -0,0 +1,88 @@
+package fs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GrepOptions controls how Grep matches and what it prints around each match
+type GrepOptions struct {
+	IgnoreCase  bool // Match letters regardless of case
+	LineNumbers bool // Prefix each line with its number
+	Before      int  // Context lines printed before each match
+	After       int  // Context lines printed after each match
+}
+
+// Grep returns the lines of the files at paths that match the regular expression pattern.
+// With several files each line is prefixed by the file name. Context lines are marked with
+// "-" instead of ":", and groups of lines that are not contiguous are separated by "--".
+func (fs *FileSystem) Grep(pattern string, paths []string, opts GrepOptions) (string, error) {
+	expr := pattern
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("grep: invalid pattern '%s'", pattern)
+	}
+
+	var out []string
+	for _, path := range paths {
+		file, err := fs.ResolvePath(path)
+		if err != nil {
+			return strings.Join(out, "\n"), fmt.Errorf("grep: %s: %v", path, err)
+		}
+		if file.Type == Directory {
+			return strings.Join(out, "\n"), fmt.Errorf("grep: %s: is a directory", path)
+		}
+		if err := fs.checkAccess(file, AccessRead, path); err != nil {
+			return strings.Join(out, "\n"), fmt.Errorf("grep: %v", err)
+		}
+
+		prefix := func(n int, sep string) string {
+			p := ""
+			if len(paths) > 1 {
+				p = path + sep
+			}
+			if opts.LineNumbers {
+				p += fmt.Sprintf("%d%s", n+1, sep)
+			}
+			return p
+		}
+
+		text := strings.TrimSuffix(string(file.Content), "\n")
+		if text == "" {
+			continue
+		}
+		lines := strings.Split(text, "\n")
+		last, afterLeft := -1, 0 // Index of the last line printed from this file, context still owed
+		for i, line := range lines {
+			if !re.MatchString(line) {
+				if afterLeft > 0 {
+					out = append(out, prefix(i, "-")+line)
+					last = i
+					afterLeft--
+				}
+				continue
+			}
+			start := i - opts.Before
+			if start <= last {
+				start = last + 1
+			}
+			if start < 0 {
+				start = 0
+			}
+			// A group that does not continue the previous one is set apart
+			if (opts.Before > 0 || opts.After > 0) && len(out) > 0 && (last < 0 || start > last+1) {
+				out = append(out, "--")
+			}
+			for j := start; j < i; j++ {
+				out = append(out, prefix(j, "-")+lines[j])
+			}
+			out = append(out, prefix(i, ":")+line)
+			last, afterLeft = i, opts.After
+		}
+	}
+	return strings.Join(out, "\n"), nil
+}
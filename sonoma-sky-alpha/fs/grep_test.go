@@ -0,0 +1,58 @@
+package fs
+
+import "testing"
+
+func TestGrepContext(t *testing.T) {
+	fs := NewFileSystem()
+	log := "one\ntwo\nerror A\nthree\nfour\nfive\nsix\nerror B\nseven\n"
+	if err := fs.EchoWrite(log[:len(log)-1], "app.log", false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts GrepOptions
+		want string
+	}{
+		{"plain", GrepOptions{}, "error A\nerror B"},
+		{"after", GrepOptions{After: 1}, "error A\nthree\n--\nerror B\nseven"},
+		{"before", GrepOptions{Before: 2}, "one\ntwo\nerror A\n--\nfive\nsix\nerror B"},
+		{"context with numbers", GrepOptions{Before: 1, After: 1, LineNumbers: true},
+			"2-two\n3:error A\n4-three\n--\n7-six\n8:error B\n9-seven"},
+		// Groups that touch merge without a separator
+		{"overlapping", GrepOptions{Before: 2, After: 2}, "one\ntwo\nerror A\nthree\nfour\nfive\nsix\nerror B\nseven"},
+		{"ignore case", GrepOptions{IgnoreCase: true}, "error A\nerror B"},
+	}
+	for _, tt := range tests {
+		got, err := fs.Grep("error", []string{"app.log"}, tt.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestGrepFiles(t *testing.T) {
+	term := NewTerminal()
+	fs := term.FS
+	fs.EchoWrite("alpha\nbeta", "a.txt", false)
+	fs.EchoWrite("BETA\ngamma", "b.txt", false)
+
+	stdout, _, err := term.RunCommand("grep -i -A 1 beta *.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a.txt:beta\n--\nb.txt:BETA\nb.txt-gamma\n"; stdout != want {
+		t.Errorf("expected %q, got %q", want, stdout)
+	}
+
+	if _, _, err := term.RunCommand("grep -C x beta a.txt"); err == nil {
+		t.Error("a non-numeric context length should be an error")
+	}
+	if _, err := fs.Grep("beta", []string{"missing.txt"}, GrepOptions{}); err == nil {
+		t.Error("grep of a missing file should fail")
+	}
+}
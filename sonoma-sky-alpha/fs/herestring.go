@@ -0,0 +1,29 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hereString removes a here-string, <<< WORD or <<<WORD, from args, where raw holds each
+// argument as typed so a quoted "<<<" is left alone. It returns the remaining arguments and
+// their raw forms, the input WORD stands for, which ends in a newline, and whether there was one.
+func hereString(args, raw []string) ([]string, []string, string, bool, error) {
+	for i, arg := range args {
+		if !strings.HasPrefix(raw[i], "<<<") {
+			continue
+		}
+		word := strings.TrimPrefix(arg, "<<<")
+		rest := append([]string{}, args[:i]...)
+		restRaw := append([]string{}, raw[:i]...)
+		if word == "" {
+			if i+1 == len(args) {
+				return nil, nil, "", false, fmt.Errorf("syntax error near unexpected token 'newline'")
+			}
+			word = args[i+1]
+			i++
+		}
+		return append(rest, args[i+1:]...), append(restRaw, raw[i+1:]...), word + "\n", true, nil
+	}
+	return args, raw, "", false, nil
+}
@@ -0,0 +1,56 @@
+package fs
+
+import "testing"
+
+func TestHereString(t *testing.T) {
+	term := NewTerminal()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`wc -w <<< "one two three"`, "3"},
+		{`wc <<< "one two"`, "1 2 8"},
+		{`cat <<< "hello"`, "hello"},
+		{`cat <<<"hi there"`, "hi there"},
+		{`echo a b | wc -w`, "2"},
+		{`echo ignored | cat <<< "mine"`, "mine"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if output != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, output)
+		}
+	}
+
+	if _, err := term.Execute("cat <<<"); err == nil {
+		t.Error("A here-string without a word should error")
+	}
+	if _, err := term.Execute("cat"); err == nil {
+		t.Error("cat without a file or input should error")
+	}
+}
+
+func TestQuotedHereString(t *testing.T) {
+	term := NewTerminal()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`echo "<<<" hi`, "<<< hi"},
+		{`echo '<<<word'`, "<<<word"},
+		{`echo \<<< hi`, "<<< hi"},
+		{`cat <<<"<<<"`, "<<<"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil || output != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.input, tt.expected, output, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package fs
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInterrupted is returned by a command stopped by Interrupt, as by Ctrl-C in a shell
+var ErrInterrupted = errors.New("interrupted")
+
+// InterruptStatus is the exit status of an interrupted command line: 128 plus SIGINT's number
+const InterruptStatus = 130
+
+// Interrupt cancels the command line running in the foreground, if any. Commands that wait,
+// such as sleep and the editor, return ErrInterrupted; background jobs keep running.
+func (t *Terminal) Interrupt() {
+	t.interruptMu.Lock()
+	defer t.interruptMu.Unlock()
+	if t.interrupt != nil {
+		t.interrupt()
+	}
+}
+
+// foreground returns the context of a command line run in the foreground, which Interrupt
+// cancels, and the function to call once the line has finished
+func (t *Terminal) foreground() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.interruptMu.Lock()
+	t.interrupt = cancel
+	t.interruptMu.Unlock()
+	return ctx, func() {
+		t.interruptMu.Lock()
+		t.interrupt = nil
+		t.interruptMu.Unlock()
+		cancel()
+	}
+}
+
+// context returns the context of the running command line, which is never cancelled outside one
+func (t *Terminal) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
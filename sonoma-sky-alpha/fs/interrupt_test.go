@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestInterruptStopsSleep(t *testing.T) {
+	term := NewTerminal()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		term.Interrupt()
+	}()
+
+	start := time.Now()
+	_, err := term.Execute("sleep 5")
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("Expected the sleep to be interrupted, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the interrupted sleep returned after %v", elapsed)
+	}
+	if term.Status != InterruptStatus {
+		t.Errorf("Expected status %d, got %d", InterruptStatus, term.Status)
+	}
+
+	// The terminal goes on with the next command, and an interrupt between commands is ignored
+	term.Interrupt()
+	if output, err := term.Execute("pwd"); err != nil || output != "/home/user" {
+		t.Errorf("pwd after the interrupt: got %q (%v)", output, err)
+	}
+}
+
+func TestInterruptSparesJobs(t *testing.T) {
+	term := NewTerminal()
+	term.Out, term.Err = nil, nil
+	term.Execute("sleep 100ms &")
+	term.Interrupt()
+
+	start := time.Now()
+	term.WaitJobs()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("the background sleep was cut short after %v", elapsed)
+	}
+}
+
+func TestInterruptAbortsEdit(t *testing.T) {
+	in, typing := io.Pipe()
+	defer typing.Close()
+	term := NewTerminal()
+	term.In, term.Out = in, io.Discard
+	term.FS.EchoWrite("original", "notes.txt", false)
+
+	done := make(chan error)
+	go func() {
+		_, err := term.Execute("edit notes.txt")
+		done <- err
+	}()
+	typing.Write([]byte("added\n"))
+	term.Interrupt()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrInterrupted) {
+			t.Errorf("Expected the editor to be interrupted, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the editor kept waiting for input after the interrupt")
+	}
+	if content, _ := term.FS.Cat("notes.txt"); content != "original\n" {
+		t.Errorf("The interrupted editor should not save, got %q", content)
+	}
+}
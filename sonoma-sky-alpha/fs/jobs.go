@@ -0,0 +1,216 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job is a command line running in the background, started with a trailing &
+type Job struct {
+	ID      int
+	Command string
+	Started time.Time
+
+	done   chan struct{}
+	resume chan struct{} // Closed to let the job go on once stopped; nil while it is not stopped
+}
+
+// jobKey is the context key of the job a command line runs in
+type jobKey struct{}
+
+// Wait blocks until the job has finished
+func (j *Job) Wait() {
+	<-j.done
+}
+
+// StartJob runs fn in the background as a job for command and returns it. The job is listed
+// by Jobs until fn returns. Its output and error are printed when it finishes.
+func (t *Terminal) StartJob(command string, fn func() (string, error)) *Job {
+	return t.startJob(command, func(context.Context) (string, bool, error) {
+		output, err := fn()
+		return output, false, err
+	})
+}
+
+// startJob is StartJob for a command line, passing fn a context that tells yield which job it
+// runs in
+func (t *Terminal) startJob(command string, fn func(ctx context.Context) (string, bool, error)) *Job {
+	t.jobsMu.Lock()
+	t.lastJob++
+	job := &Job{ID: t.lastJob, Command: command, Started: time.Now(), done: make(chan struct{})}
+	t.jobs = append(t.jobs, job)
+	t.jobsMu.Unlock()
+
+	go func() {
+		defer close(job.done)
+		t.pauseJob(job)
+		output, partial, err := fn(context.WithValue(context.Background(), jobKey{}, job))
+		if output != "" && t.Out != nil {
+			if !partial {
+				output += "\n"
+			}
+			fmt.Fprint(t.Out, output)
+		}
+		if err != nil && t.Err != nil {
+			fmt.Fprintf(t.Err, "Error: [%d] %v\n", job.ID, err)
+		}
+
+		t.jobsMu.Lock()
+		defer t.jobsMu.Unlock()
+		for i, j := range t.jobs {
+			if j == job {
+				t.jobs = append(t.jobs[:i], t.jobs[i+1:]...)
+				break
+			}
+		}
+	}()
+	return job
+}
+
+// Jobs returns the jobs still running, oldest first
+func (t *Terminal) Jobs() []*Job {
+	t.jobsMu.Lock()
+	defer t.jobsMu.Unlock()
+	return append([]*Job(nil), t.jobs...)
+}
+
+// WaitJobs blocks until every running job has finished
+func (t *Terminal) WaitJobs() {
+	for _, job := range t.Jobs() {
+		job.Wait()
+	}
+}
+
+// StopJob stops a background job, as Ctrl-Z would a process, until ResumeJob. The job can only
+// pause between commands: before it starts, or once a command such as sleep is done waiting.
+func (t *Terminal) StopJob(job *Job) error {
+	t.jobsMu.Lock()
+	defer t.jobsMu.Unlock()
+	if job.resume != nil {
+		return fmt.Errorf("job %d already stopped", job.ID)
+	}
+	job.resume = make(chan struct{})
+	return nil
+}
+
+// ResumeJob lets a job stopped by StopJob go on
+func (t *Terminal) ResumeJob(job *Job) error {
+	t.jobsMu.Lock()
+	defer t.jobsMu.Unlock()
+	if job.resume == nil {
+		return fmt.Errorf("job %d already running", job.ID)
+	}
+	close(job.resume)
+	job.resume = nil
+	return nil
+}
+
+// Stopped reports whether the job has been stopped by StopJob
+func (t *Terminal) Stopped(job *Job) bool {
+	t.jobsMu.Lock()
+	defer t.jobsMu.Unlock()
+	return job.resume != nil
+}
+
+// pauseJob blocks while job is stopped
+func (t *Terminal) pauseJob(job *Job) {
+	for {
+		t.jobsMu.Lock()
+		resume := job.resume
+		t.jobsMu.Unlock()
+		if resume == nil {
+			return
+		}
+		<-resume
+	}
+}
+
+// findJob returns the job a job control command names, N or %N, or the most recent job when
+// spec is empty
+func (t *Terminal) findJob(spec string) (*Job, error) {
+	jobs := t.Jobs()
+	if spec == "" {
+		if len(jobs) == 0 {
+			return nil, fmt.Errorf("no current job")
+		}
+		return jobs[len(jobs)-1], nil
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(spec, "%"))
+	if err == nil {
+		for _, job := range jobs {
+			if job.ID == id {
+				return job, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: no such job", spec)
+}
+
+// Fg resumes job if it is stopped and waits for it to finish, printing its command line first.
+// The wait ends early with ErrInterrupted if the command line running Fg is interrupted.
+func (t *Terminal) Fg(job *Job) error {
+	fmt.Fprintln(t.Out, job.Command)
+	if t.Stopped(job) {
+		t.ResumeJob(job)
+	}
+	var err error
+	ctx := t.context()
+	t.yield(func() {
+		select {
+		case <-job.done:
+		case <-ctx.Done():
+			err = ErrInterrupted
+		}
+	})
+	return err
+}
+
+// Ps lists the running jobs with their id, the time since they started and their command,
+// marking stopped ones
+func (t *Terminal) Ps() string {
+	lines := []string{fmt.Sprintf("%-4s %-8s %s", "JOB", "ELAPSED", "COMMAND")}
+	for _, job := range t.Jobs() {
+		elapsed := time.Since(job.Started).Truncate(time.Second)
+		line := fmt.Sprintf("%-4d %-8s %s", job.ID, elapsed, job.Command)
+		if t.Stopped(job) {
+			line += " (stopped)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// yield runs fn without holding the terminal, so jobs and the foreground can run commands
+// meanwhile. It is for commands that only wait, such as sleep; the running command line's
+// undo journal, pipeline input and context are set aside and restored afterwards. A job stopped
+// meanwhile pauses before it takes the terminal back.
+func (t *Terminal) yield(fn func()) {
+	if !t.locked {
+		fn()
+		return
+	}
+	journal, stdin, hasStdin, partial, ctx := t.FS.journal, t.Stdin, t.hasStdin, t.partial, t.ctx
+	t.locked = false
+	t.exec.Unlock()
+	defer func() {
+		if job, ok := ctx.Value(jobKey{}).(*Job); ok {
+			t.pauseJob(job)
+		}
+		t.exec.Lock()
+		t.locked = true
+		t.FS.journal, t.Stdin, t.hasStdin, t.partial, t.ctx = journal, stdin, hasStdin, partial, ctx
+	}()
+	fn()
+}
+
+// backgroundCommand reports whether input ends with a single & and returns it without the &
+func backgroundCommand(input string) (string, bool) {
+	trimmed := strings.TrimSpace(input)
+	if !strings.HasSuffix(trimmed, "&") || strings.HasSuffix(trimmed, "&&") || strings.HasSuffix(trimmed, `\&`) {
+		return input, false
+	}
+	return strings.TrimSpace(strings.TrimSuffix(trimmed, "&")), true
+}
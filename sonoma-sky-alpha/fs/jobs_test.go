@@ -0,0 +1,145 @@
+package fs
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPsListsJobsUntilDone(t *testing.T) {
+	term := NewTerminal()
+	release := make(chan struct{})
+	job := term.StartJob("build all", func() (string, error) {
+		<-release
+		return "", nil
+	})
+
+	output, err := term.ExecuteCommand("ps", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(output, "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "JOB") {
+		t.Fatalf("ps should print a header and one job, got %q", output)
+	}
+	if fields := strings.Fields(lines[1]); len(fields) != 4 || fields[0] != "1" || fields[1] != "0s" || fields[2]+" "+fields[3] != "build all" {
+		t.Errorf("ps should show the job id, elapsed time and command, got %q", lines[1])
+	}
+
+	close(release)
+	job.Wait()
+	term.WaitJobs()
+	if output, _ := term.ExecuteCommand("ps", nil); strings.Contains(output, "build all") {
+		t.Errorf("a finished job should leave ps, got %q", output)
+	}
+}
+
+func TestBackgroundCommand(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.Out = &out
+
+	output, err := term.Execute("echo done > log.txt &")
+	if err != nil || output != "[1]" {
+		t.Fatalf("a background command should report its job id, got %q (%v)", output, err)
+	}
+	term.WaitJobs()
+	if content, _ := term.FS.Cat("log.txt"); content != "done\n" {
+		t.Errorf("the background command should have run, got %q", content)
+	}
+
+	term.Execute("pwd &")
+	term.WaitJobs()
+	if out.String() != "/home/user\n" {
+		t.Errorf("a job's output should be printed when it finishes, got %q", out.String())
+	}
+
+	// && is not a background marker
+	if line, background := backgroundCommand("a && b"); background || line != "a && b" {
+		t.Errorf("&& should not start a job, got %q %v", line, background)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer that jobs can print to while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestFgBg(t *testing.T) {
+	out := &lockedBuffer{}
+	term := NewTerminal()
+	term.Out = out
+
+	// The first job is stopped while it sleeps, so the second finishes first
+	term.Execute("sleep 100ms | echo first &")
+	if _, err := term.Execute("stop"); err != nil {
+		t.Fatal(err)
+	}
+	term.Execute("echo second &")
+	time.Sleep(200 * time.Millisecond)
+	if out.String() != "second\n" {
+		t.Fatalf("the stopped job should not finish, got %q", out.String())
+	}
+	if output, _ := term.Execute("ps"); !strings.Contains(output, "sleep 100ms | echo first (stopped)") {
+		t.Errorf("ps should mark the stopped job, got %q", output)
+	}
+
+	// fg resumes it and returns once it has finished
+	if _, err := term.Execute("fg %1"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "second\nsleep 100ms | echo first\nfirst\n" {
+		t.Errorf("fg should print the command and wait for the job's output, got %q", out.String())
+	}
+	if len(term.Jobs()) != 0 {
+		t.Errorf("the job should be done after fg, got %v", term.Jobs())
+	}
+
+	// bg lets a stopped job finish in the background
+	term.Execute("sleep 50ms | echo third &")
+	term.Execute("stop 3")
+	time.Sleep(100 * time.Millisecond)
+	output, err := term.Execute("bg 3")
+	if err != nil || output != "[3] sleep 50ms | echo third &" {
+		t.Errorf("bg should report the resumed job, got %q (%v)", output, err)
+	}
+	term.WaitJobs()
+	if !strings.HasSuffix(out.String(), "first\nthird\n") {
+		t.Errorf("the resumed job should finish, got %q", out.String())
+	}
+
+	for _, input := range []string{"fg", "bg 7", "stop %x"} {
+		if _, err := term.Execute(input); err == nil {
+			t.Errorf("%s without such a job should error", input)
+		}
+	}
+}
+
+func TestBgRunningJob(t *testing.T) {
+	term := NewTerminal()
+	release := make(chan struct{})
+	job := term.StartJob("wait", func() (string, error) {
+		<-release
+		return "", nil
+	})
+	if _, err := term.Execute("bg 1"); err == nil {
+		t.Error("bg of a running job should error")
+	}
+	close(release)
+	job.Wait()
+}
@@ -0,0 +1,34 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UpdateDB rebuilds the locate index from the current tree and returns the number of indexed paths.
+// The index is a snapshot: later changes are not visible to Locate until it is rebuilt.
+func (fs *FileSystem) UpdateDB() int {
+	index := []string{}
+	walk(fs.Root, "/", func(path string, file *VirtualFile) {
+		if file != fs.Root {
+			index = append(index, path)
+		}
+	})
+	fs.Index = index
+	return len(index)
+}
+
+// Locate returns the indexed paths containing pattern
+func (fs *FileSystem) Locate(pattern string) ([]string, error) {
+	if fs.Index == nil {
+		return nil, fmt.Errorf("locate: no database, run updatedb first")
+	}
+
+	var matches []string
+	for _, path := range fs.Index {
+		if strings.Contains(path, pattern) {
+			matches = append(matches, path)
+		}
+	}
+	return matches, nil
+}
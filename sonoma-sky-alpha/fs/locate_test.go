@@ -0,0 +1,43 @@
+package fs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocate(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("docs/reports", true)
+	fs.Touch("docs/reports/q1-report.txt")
+	fs.Touch("docs/notes.txt")
+
+	_, err := fs.Locate("report")
+	if err == nil {
+		t.Error("locate before updatedb should error")
+	}
+
+	if n := fs.UpdateDB(); n != 6 {
+		t.Errorf("Expected 6 indexed paths, got %d", n)
+	}
+
+	matches, err := fs.Locate("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/home/user/docs/reports", "/home/user/docs/reports/q1-report.txt"}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected %v, got %v", expected, matches)
+	}
+
+	// The index is only refreshed explicitly
+	fs.Touch("docs/report-draft.txt")
+	matches, _ = fs.Locate("draft")
+	if len(matches) != 0 {
+		t.Errorf("Stale index should not see new files, got %v", matches)
+	}
+	fs.UpdateDB()
+	matches, _ = fs.Locate("draft")
+	if len(matches) != 1 || matches[0] != "/home/user/docs/report-draft.txt" {
+		t.Errorf("Rebuilt index should find the new file, got %v", matches)
+	}
+}
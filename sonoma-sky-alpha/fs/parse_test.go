@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		cmd   string
+		args  []string
+	}{
+		{"ls -l", "ls", []string{"-l"}},
+		{"  cd   dir\t", "cd", []string{"dir"}},
+		{`echo "hello world"`, "echo", []string{"hello world"}},
+		{`echo 'it''s'`, "echo", []string{"its"}},
+		{`echo "it's" 'say "hi"'`, "echo", []string{"it's", `say "hi"`}},
+		{`touch a"b c"d`, "touch", []string{"ab cd"}},
+		{`touch "" x`, "touch", []string{"", "x"}},
+		{`touch my\ file`, "touch", []string{"my file"}},
+		{`echo "a\"b\\c\d"`, "echo", []string{`a"b\c\d`}},
+		{`echo 'a\b'`, "echo", []string{`a\b`}},
+		{"touch café", "touch", []string{"café"}},
+		{"", "", nil},
+		{`''`, "", nil},
+	}
+	for _, tt := range tests {
+		cmd, args, err := ParseCommand(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.input, err)
+			continue
+		}
+		if cmd != tt.cmd || len(args) != len(tt.args) || (len(args) > 0 && !reflect.DeepEqual(args, tt.args)) {
+			t.Errorf("%q: expected %q %q, got %q %q", tt.input, tt.cmd, tt.args, cmd, args)
+		}
+	}
+}
+
+func TestParseCommandErrors(t *testing.T) {
+	for _, input := range []string{`echo "abc`, `echo 'abc`, `echo abc\`, `echo "a\"`} {
+		if _, _, err := ParseCommand(input); err == nil {
+			t.Errorf("%q: expected a syntax error", input)
+		}
+	}
+}
+
+// quoteToken single-quotes token so that ParseCommand reads it back unchanged
+func quoteToken(token string) string {
+	return "'" + strings.ReplaceAll(token, "'", `'\''`) + "'"
+}
+
+func FuzzParseCommand(f *testing.F) {
+	seeds := []string{
+		"ls -la /home",
+		`echo "hello world" > out.txt`,
+		`echo 'single' "double" mixed`,
+		`echo "unclosed`,
+		`echo 'unclosed`,
+		`echo trailing\`,
+		`echo "esc\"aped" 'it'\''s'`,
+		`a"b"'c'd`,
+		`"" '' ""''`,
+		"\t\t",
+		"touch café ünïcödé",
+		"echo \xff\xfe",
+		`\\\"\'`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		cmd, args, err := ParseCommand(input)
+		if err != nil {
+			return
+		}
+
+		// Quoting the parsed tokens and parsing again must give the same tokens
+		tokens := append([]string{cmd}, args...)
+		quoted := make([]string, len(tokens))
+		for i, token := range tokens {
+			quoted[i] = quoteToken(token)
+		}
+		cmd2, args2, err := ParseCommand(strings.Join(quoted, " "))
+		if err != nil {
+			t.Fatalf("re-parsing %q: %v", quoted, err)
+		}
+		if cmd2 != cmd || len(args2) != len(args) || (len(args) > 0 && !reflect.DeepEqual(args2, args)) {
+			t.Fatalf("%q: parsed %q %q, re-parsed %q %q", input, cmd, args, cmd2, args2)
+		}
+	})
+}
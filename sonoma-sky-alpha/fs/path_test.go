@@ -0,0 +1,101 @@
+package fs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetPathAfterMove(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("a/b/c", true)
+	fs.Touch("a/b/c/file.txt")
+	file, _ := fs.ResolvePath("a/b/c/file.txt")
+	dir, _ := fs.ResolvePath("a/b")
+
+	if path := fs.GetPath(file); path != "/home/user/a/b/c/file.txt" {
+		t.Fatalf("Unexpected path %s", path)
+	}
+
+	// Renaming an ancestor must invalidate the cached paths of every descendant
+	if err := fs.Mv("a/b", "a/renamed"); err != nil {
+		t.Fatal(err)
+	}
+	if path := fs.GetPath(file); path != "/home/user/a/renamed/c/file.txt" {
+		t.Errorf("Expected path under the renamed directory, got %s", path)
+	}
+
+	if err := fs.Mkdir("/tmp", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Mv("a", "/tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if path := fs.GetPath(dir); path != "/tmp/a/renamed" {
+		t.Errorf("Expected /tmp/a/renamed, got %s", path)
+	}
+	if path := fs.GetPath(file); path != "/tmp/a/renamed/c/file.txt" {
+		t.Errorf("Expected /tmp/a/renamed/c/file.txt, got %s", path)
+	}
+
+	term := &Terminal{FS: fs, UndoDepth: DefaultUndoDepth}
+	fs.Cd("/")
+	run(term, func() error { return fs.Mv("tmp", "var") })
+	if path := fs.GetPath(file); path != "/var/a/renamed/c/file.txt" {
+		t.Errorf("Expected /var/a/renamed/c/file.txt, got %s", path)
+	}
+	if err := term.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if path := fs.GetPath(file); path != "/tmp/a/renamed/c/file.txt" {
+		t.Errorf("undo of the move should restore the path, got %s", path)
+	}
+}
+
+// deepFileSystem returns a file system with a directory nested depth levels below the root
+func deepFileSystem(depth int) (*FileSystem, *VirtualFile) {
+	fs := NewFileSystem()
+	path := "/" + strings.Repeat("level/", depth)
+	fs.Mkdir(path, true)
+	dir, _ := fs.ResolvePath(path)
+	return fs, dir
+}
+
+func BenchmarkGetPath(b *testing.B) {
+	fs, dir := deepFileSystem(50)
+	for i := 0; i < b.N; i++ {
+		fs.GetPath(dir)
+	}
+}
+
+func BenchmarkGetPathUncached(b *testing.B) {
+	fs, dir := deepFileSystem(50)
+	for i := 0; i < b.N; i++ {
+		fs.pathGen++ // invalidate, as a move would, so every call rebuilds the path
+		fs.GetPath(dir)
+	}
+}
+
+func TestResolvePathMaxDepth(t *testing.T) {
+	fs := NewFileSystem()
+	fs.MaxDepth = 8
+	fs.Mkdir("a/b/c", true)
+
+	// Redundant components count only when they walk somewhere
+	if _, err := fs.ResolvePath("/home/user/./a//b/./c/"); err != nil {
+		t.Errorf("Expected a path within the limit to resolve, got %v", err)
+	}
+
+	long := "/home/user/" + strings.Repeat("a/..//", 5)
+	_, err := fs.ResolvePath(long)
+	if err == nil || !strings.Contains(err.Error(), "path too long") {
+		t.Errorf("Expected a path too long error, got %v", err)
+	}
+	if err := fs.Cd(long); err == nil {
+		t.Error("Expected cd to reject an overly long path")
+	}
+
+	fs.MaxDepth = 0
+	if _, err := fs.ResolvePath(long); err != nil {
+		t.Errorf("A zero MaxDepth should disable the limit, got %v", err)
+	}
+}
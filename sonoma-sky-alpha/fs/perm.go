@@ -0,0 +1,150 @@
+package fs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Access bits checked against a file's owner, group or other permission triplet
+const (
+	AccessRead  uint32 = 4
+	AccessWrite uint32 = 2
+	AccessExec  uint32 = 1
+)
+
+// RootUser is the user that bypasses all permission checks
+const RootUser = "root"
+
+// CanAccess reports whether the current user has all of the requested access bits on file.
+// The owner triplet applies to the file's owner, the group triplet to members of its group
+// (each user is the sole member of the group named after them) and the other triplet to everyone else.
+func (fs *FileSystem) CanAccess(file *VirtualFile, want uint32) bool {
+	if fs.User == RootUser {
+		return true
+	}
+
+	var bits uint32
+	switch {
+	case file.Owner == fs.User:
+		bits = (file.Permissions >> 6) & 7
+	case file.Group == fs.User:
+		bits = (file.Permissions >> 3) & 7
+	default:
+		bits = file.Permissions & 7
+	}
+	return bits&want == want
+}
+
+// checkAccess returns a permission denied error unless the current user has the requested access
+func (fs *FileSystem) checkAccess(file *VirtualFile, want uint32, path string) error {
+	if !fs.CanAccess(file, want) {
+		return fmt.Errorf("%s: permission denied", path)
+	}
+	return nil
+}
+
+// checkTreeAccess is checkAccess for dir and every file and directory below it. Directories
+// also need the execute bit to be entered.
+func (fs *FileSystem) checkTreeAccess(dir *VirtualFile, want uint32, path string) error {
+	var err error
+	walk(dir, path, func(path string, file *VirtualFile) {
+		access := want
+		if file.Type == Directory {
+			access |= AccessExec
+		}
+		if err == nil {
+			err = fs.checkAccess(file, access, path)
+		}
+	})
+	return err
+}
+
+// checkOwner returns an error naming the first file chmod with opts would change at path that
+// the current user does not own. Only the owner of a file, or root, may change its mode.
+func (fs *FileSystem) checkOwner(path string, opts ChangeOptions) error {
+	if fs.User == RootUser {
+		return nil
+	}
+	var err error
+	check := func(path string, file *VirtualFile) {
+		if err == nil && file.Owner != fs.User {
+			err = fmt.Errorf("'%s': Operation not permitted", path)
+		}
+	}
+	file, _ := fs.ResolvePath(path)
+	if opts.Recursive {
+		walk(file, path, check)
+	} else {
+		check(path, file)
+	}
+	return err
+}
+
+// ChangeOptions control how chmod and chown apply a change
+type ChangeOptions struct {
+	Recursive bool // Change every descendant of a directory too
+	Verbose   bool // Report each file whose mode or ownership changed
+}
+
+// apply calls change on the file at path, and on its descendants when opts.Recursive.
+// change returns a report line when it changed the file; those lines are returned when opts.Verbose.
+func (fs *FileSystem) apply(path string, opts ChangeOptions, change func(path string, file *VirtualFile) string) string {
+	file, _ := fs.ResolvePath(path)
+	var report []string
+	note := func(path string, file *VirtualFile) {
+		if line := change(path, file); line != "" && opts.Verbose {
+			report = append(report, line)
+		}
+	}
+	if opts.Recursive {
+		walk(file, path, note)
+	} else {
+		note(path, file)
+	}
+	fs.invalidate()
+	return strings.Join(report, "\n")
+}
+
+// Chmod sets the permission bits of the file at path from an octal mode such as 644
+func (fs *FileSystem) Chmod(mode string, path string) error {
+	_, err := fs.ChmodWith(mode, path, ChangeOptions{})
+	return err
+}
+
+// ChmodWith sets the permission bits of the file at path according to opts and returns
+// the verbose report
+func (fs *FileSystem) ChmodWith(mode string, path string, opts ChangeOptions) (string, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0777 {
+		return "", fmt.Errorf("chmod: invalid mode: '%s'", mode)
+	}
+
+	if _, err := fs.ResolvePath(path); err != nil {
+		return "", fmt.Errorf("chmod: %s: %v", path, err)
+	}
+	if err := fs.checkOwner(path, opts); err != nil {
+		return "", fmt.Errorf("chmod: changing permissions of %v", err)
+	}
+
+	return fs.apply(path, opts, func(path string, file *VirtualFile) string {
+		old := file.Permissions
+		if old == uint32(perm) {
+			return ""
+		}
+		file.Permissions = uint32(perm)
+		isDir := file.Type == Directory
+		return fmt.Sprintf("mode of '%s' changed from %04o (%s) to %04o (%s)",
+			path, old, getPermString(old, isDir)[1:], perm, getPermString(file.Permissions, isDir)[1:])
+	}), nil
+}
+
+// SetUmask sets the umask from an octal mode such as 027
+func (fs *FileSystem) SetUmask(mode string) error {
+	mask, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || mask > 0777 {
+		return fmt.Errorf("umask: invalid mode: '%s'", mode)
+	}
+	fs.Umask = uint32(mask)
+	return nil
+}
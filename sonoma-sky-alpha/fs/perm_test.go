@@ -0,0 +1,408 @@
+package fs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChmod(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Touch("file.txt")
+
+	err := fs.Chmod("600", "file.txt")
+	if err != nil {
+		t.Error(err)
+	}
+	file, _ := fs.ResolvePath("file.txt")
+	if file.Permissions != 0600 {
+		t.Errorf("Expected permissions 600, got %o", file.Permissions)
+	}
+
+	if fs.Chmod("9x", "file.txt") == nil {
+		t.Error("chmod with an invalid mode should error")
+	}
+}
+
+func TestPermissionEnforcement(t *testing.T) {
+	term := NewTerminal()
+	err := term.FS.EchoWrite("secret", "secret.txt", false)
+	if err != nil {
+		t.Error(err)
+	}
+	err = term.FS.Chmod("640", "secret.txt")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// The owner can read
+	output, err := term.FS.Cat("/home/user/secret.txt")
+	if err != nil {
+		t.Errorf("Owner should be able to read: %v", err)
+	}
+	if output != "secret\n" {
+		t.Errorf("Unexpected content %q", output)
+	}
+
+	// Others lack the read bit
+	err = term.SetUser("alice")
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = term.FS.Cat("/home/user/secret.txt")
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Other user read should be denied, got %v", err)
+	}
+	err = term.FS.EchoWrite("overwrite", "/home/user/secret.txt", false)
+	if err == nil {
+		t.Error("Other user write should be denied")
+	}
+
+	// Directories without the execute bit cannot be traversed
+	term.SetUser("user")
+	term.FS.Chmod("700", "/home/user")
+	term.SetUser("alice")
+	_, err = term.FS.ResolvePath("/home/user/secret.txt")
+	if err == nil {
+		t.Error("Traversing a directory without execute permission should be denied")
+	}
+	_, err = term.FS.Ls("/home/user", false, false)
+	if err == nil {
+		t.Error("Listing a directory without permission should be denied")
+	}
+
+	// Root bypasses checks
+	term.SetUser(RootUser)
+	output, err = term.FS.Cat("/home/user/secret.txt")
+	if err != nil {
+		t.Errorf("Root should bypass permission checks: %v", err)
+	}
+	if output != "secret\n" {
+		t.Errorf("Unexpected content %q", output)
+	}
+}
+
+func TestSudo(t *testing.T) {
+	term := NewTerminal()
+	term.FS.EchoWrite("secret", "/home/user/secret.txt", false)
+	term.FS.Chmod("600", "/home/user/secret.txt")
+	term.SetUser("alice")
+
+	_, err := term.FS.Cat("/home/user/secret.txt")
+	if err == nil {
+		t.Fatal("Read should be denied without sudo")
+	}
+
+	output, err := term.Sudo(func() (string, error) {
+		return term.FS.Cat("/home/user/secret.txt")
+	})
+	if err != nil {
+		t.Errorf("sudo should bypass permission checks: %v", err)
+	}
+	if output != "secret\n" {
+		t.Errorf("Unexpected content %q", output)
+	}
+	if term.Whoami() != "alice" {
+		t.Errorf("sudo should restore the previous user, got %s", term.Whoami())
+	}
+}
+
+func TestSudoKeepsHome(t *testing.T) {
+	term := NewTerminal()
+	term.Execute("touch ~/notes.txt")
+	term.Execute("chmod 700 ~")
+
+	if _, err := term.Execute("sudo ls /"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := term.FS.ResolvePath("~/notes.txt"); err != nil {
+		t.Errorf("Files in ~ should survive sudo, got %v", err)
+	}
+	home, _ := term.FS.ResolvePath("~")
+	if home.Permissions != 0700 || home.Owner != "user" {
+		t.Errorf("sudo should leave the home alone, got %s %o", home.Owner, home.Permissions)
+	}
+	if term.Whoami() != "user" || term.FS.User != "user" || term.FS.Home != "/home/user" {
+		t.Errorf("sudo should restore the user and ~, got %s %s", term.Whoami(), term.FS.Home)
+	}
+
+	// A home removed under sudo stays removed rather than coming back empty
+	term.Execute("cd /")
+	if _, err := term.Execute("sudo rm -r /home/user"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := term.FS.ResolvePath("/home/user"); err == nil {
+		t.Error("sudo should not recreate the home on the way out")
+	}
+}
+
+func TestSudoSu(t *testing.T) {
+	term := NewTerminal()
+
+	// An su under sudo ends with it, so exit still leaves the terminal
+	term.Execute("sudo su bob")
+	if term.Whoami() != "user" {
+		t.Errorf("sudo su should end with sudo, got %s", term.Whoami())
+	}
+	term.Execute("exit")
+	if term.Running || term.Whoami() != "user" {
+		t.Errorf("exit after sudo su should end the terminal as user, got %s running=%v", term.Whoami(), term.Running)
+	}
+
+	// An exit under sudo leaves the su session alone
+	term = NewTerminal()
+	term.Execute("su bob")
+	term.Execute("sudo exit")
+	if term.Whoami() != "bob" || !term.Running {
+		t.Errorf("sudo exit should leave bob's session, got %s running=%v", term.Whoami(), term.Running)
+	}
+	term.Execute("exit")
+	if term.Whoami() != "user" || !term.Running {
+		t.Errorf("exit should return from bob to user, got %s running=%v", term.Whoami(), term.Running)
+	}
+}
+
+func TestSuQuit(t *testing.T) {
+	term := NewTerminal()
+	term.Execute("su")
+	term.Execute("su bob")
+	term.Execute("quit")
+	if term.Running {
+		t.Error("quit should end the terminal inside su sessions")
+	}
+	if term.Whoami() != "user" {
+		t.Errorf("quit should leave the su sessions, got %s", term.Whoami())
+	}
+}
+
+func TestSuExit(t *testing.T) {
+	term := NewTerminal()
+
+	err := term.Su("")
+	if err != nil {
+		t.Error(err)
+	}
+	if term.Whoami() != RootUser {
+		t.Errorf("su with no user should switch to root, got %s", term.Whoami())
+	}
+	home, _ := term.FS.ResolvePath("~")
+	if term.FS.GetPath(home) != "/root" {
+		t.Errorf("~ should resolve to /root, got %s", term.FS.GetPath(home))
+	}
+
+	err = term.Su("bob")
+	if err != nil {
+		t.Error(err)
+	}
+	if term.Whoami() != "bob" {
+		t.Errorf("Expected bob, got %s", term.Whoami())
+	}
+
+	term.Exit()
+	if term.Whoami() != RootUser || !term.Running {
+		t.Errorf("exit should return to root, got %s", term.Whoami())
+	}
+	term.Exit()
+	if term.Whoami() != "user" || !term.Running {
+		t.Errorf("exit should return to user, got %s", term.Whoami())
+	}
+	home, _ = term.FS.ResolvePath("~")
+	if term.FS.GetPath(home) != "/home/user" {
+		t.Errorf("~ should resolve to /home/user, got %s", term.FS.GetPath(home))
+	}
+
+	term.Exit()
+	if term.Running {
+		t.Error("exit outside su should stop the terminal")
+	}
+}
+
+func TestSuReset(t *testing.T) {
+	term := NewTerminal()
+	if err := term.Su("bob"); err != nil {
+		t.Fatal(err)
+	}
+	term.Reset()
+	if term.Whoami() != "user" || term.FS.Home != "/home/user" {
+		t.Errorf("reset should end the su session, got %s with home %s", term.Whoami(), term.FS.Home)
+	}
+	term.Exit()
+	if term.Running {
+		t.Errorf("exit after reset should stop the terminal, got user %s", term.Whoami())
+	}
+}
+
+func TestChmodRecursive(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("dir/sub", true)
+	term.FS.Touch("dir/a.txt")
+	term.FS.Touch("dir/sub/b.txt")
+	term.FS.Chmod("700", "dir/a.txt")
+
+	output, err := term.Execute("chmod -R -v 700 dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"dir", "dir/a.txt", "dir/sub", "dir/sub/b.txt"} {
+		file, _ := term.FS.ResolvePath(path)
+		if file.Permissions != 0700 {
+			t.Errorf("%s: expected permissions 700, got %o", path, file.Permissions)
+		}
+	}
+
+	expected := strings.Join([]string{
+		"mode of 'dir' changed from 0755 (rwxr-xr-x) to 0700 (rwx------)",
+		"mode of 'dir/sub' changed from 0755 (rwxr-xr-x) to 0700 (rwx------)",
+		"mode of 'dir/sub/b.txt' changed from 0644 (rw-r--r--) to 0700 (rwx------)",
+	}, "\n")
+	if output != expected {
+		t.Errorf("chmod -v should report each changed path, got:\n%s", output)
+	}
+
+	output, _ = term.Execute("chmod -R 600 dir")
+	if output != "" {
+		t.Errorf("chmod without -v should print nothing, got %q", output)
+	}
+	if file, _ := term.FS.ResolvePath("/home/user/dir"); file.Permissions != 0600 {
+		t.Errorf("chmod -R should change the directory itself, got %o", file.Permissions)
+	}
+}
+
+func TestUmask(t *testing.T) {
+	term := NewTerminal()
+	if output, _ := term.Execute("umask"); output != "0022" {
+		t.Errorf("Expected the default umask 0022, got %q", output)
+	}
+
+	term.Execute("mkdir default")
+	term.Execute("touch default.txt")
+	if _, err := term.Execute("umask 077"); err != nil {
+		t.Fatal(err)
+	}
+	if output, _ := term.Execute("umask"); output != "0077" {
+		t.Errorf("Expected umask 0077, got %q", output)
+	}
+	term.Execute("mkdir -p private/sub")
+	term.Execute("touch private/notes.txt")
+	term.Execute("cp default.txt key.txt")
+
+	expected := map[string]uint32{
+		"default":           0755,
+		"default.txt":       0644,
+		"private":           0700,
+		"private/sub":       0700,
+		"private/notes.txt": 0600,
+		"key.txt":           0600,
+	}
+	for path, perm := range expected {
+		file, err := term.FS.ResolvePath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file.Permissions != perm {
+			t.Errorf("%s: expected permissions %o, got %o", path, perm, file.Permissions)
+		}
+	}
+
+	if _, err := term.Execute("umask 999"); err == nil {
+		t.Error("umask with an invalid mode should error")
+	}
+	if term.FS.Umask != 0077 {
+		t.Errorf("An invalid mode should leave the umask alone, got %o", term.FS.Umask)
+	}
+}
+
+func TestCpRequiresRead(t *testing.T) {
+	term := NewTerminal()
+	term.FS.EchoWrite("secret", "secret.txt", false)
+	term.FS.Chmod("600", "secret.txt")
+	term.FS.Mkdir("dir", false)
+	term.FS.EchoWrite("hidden", "dir/hidden.txt", false)
+	term.FS.Chmod("600", "dir/hidden.txt")
+	term.SetUser("alice")
+
+	for _, input := range []string{"cp /home/user/secret.txt copy.txt", "cp -r /home/user/dir copy"} {
+		_, err := term.Execute(input)
+		if err == nil || !strings.Contains(err.Error(), "permission denied") {
+			t.Errorf("%s: expected permission denied, got %v", input, err)
+		}
+	}
+	for _, path := range []string{"copy.txt", "copy"} {
+		if _, err := term.FS.ResolvePath(path); err == nil {
+			t.Errorf("%s should not have been created", path)
+		}
+	}
+
+	// A readable tree still copies
+	term.SetUser("user")
+	term.FS.Chmod("644", "dir/hidden.txt")
+	term.SetUser("alice")
+	if _, err := term.Execute("cp -r /home/user/dir copy"); err != nil {
+		t.Errorf("Copying a readable tree should work, got %v", err)
+	}
+}
+
+func TestCpKeepsMode(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Touch("private.txt")
+	term.FS.Chmod("600", "private.txt")
+	term.FS.Touch("script.sh")
+	term.FS.Chmod("777", "script.sh")
+	term.FS.Mkdir("dir", false)
+	term.FS.Chmod("700", "dir")
+
+	for _, input := range []string{"cp private.txt copy.txt", "cp script.sh copy.sh", "cp -r dir copy"} {
+		if _, err := term.Execute(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string]uint32{
+		"copy.txt": 0600,
+		"copy.sh":  0755, // The umask still applies
+		"copy":     0700,
+	}
+	for path, perm := range expected {
+		file, _ := term.FS.ResolvePath(path)
+		if file.Permissions != perm {
+			t.Errorf("%s: expected permissions %o, got %o", path, perm, file.Permissions)
+		}
+	}
+}
+
+func TestChangeRequiresPrivilege(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("dir", false)
+	term.FS.Touch("dir/mine.txt")
+	term.SetUser("alice")
+	term.FS.Touch("/home/alice/own.txt")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"chown alice /home/user/dir", "chown: changing ownership of '/home/user/dir': Operation not permitted"},
+		{"chown alice /home/alice/own.txt", "chown: changing ownership of '/home/alice/own.txt': Operation not permitted"},
+		{"chmod 777 /home/user/dir", "chmod: changing permissions of '/home/user/dir': Operation not permitted"},
+	}
+	for _, tt := range tests {
+		if _, err := term.Execute(tt.input); err == nil || err.Error() != tt.want {
+			t.Errorf("%s: expected %q, got %v", tt.input, tt.want, err)
+		}
+	}
+	if dir, _ := term.FS.ResolvePath("/home/user/dir"); dir.Owner != "user" || dir.Permissions != 0755 {
+		t.Errorf("A refused change should leave the file alone, got %s %o", dir.Owner, dir.Permissions)
+	}
+
+	// The owner may change the mode; root may change anything
+	if _, err := term.Execute("chmod 600 /home/alice/own.txt"); err != nil {
+		t.Errorf("The owner should be able to chmod, got %v", err)
+	}
+	if _, err := term.Execute("sudo chown alice /home/user/dir"); err != nil {
+		t.Errorf("root should be able to chown, got %v", err)
+	}
+
+	// chmod -R refuses a tree holding a file someone else owns
+	term.Execute("sudo chown user /home/user/dir/mine.txt")
+	if _, err := term.Execute("chmod -R 700 /home/user/dir"); err == nil || !strings.Contains(err.Error(), "mine.txt': Operation not permitted") {
+		t.Errorf("Expected chmod -R to be refused, got %v", err)
+	}
+}
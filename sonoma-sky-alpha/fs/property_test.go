@@ -0,0 +1,136 @@
+package fs
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+var propertySeed = flag.Int64("seed", 0, "seed for the property tests (0 picks one from the clock)")
+
+// propertyNames are the names random paths are built from; few enough that operations collide
+var propertyNames = []string{"a", "b", "c", "d.txt", "e.txt"}
+
+// randomPath returns a path of one to three names under /p
+func randomPath(rng *rand.Rand) string {
+	parts := []string{"/p"}
+	for n := rng.Intn(3) + 1; n > 0; n-- {
+		parts = append(parts, propertyNames[rng.Intn(len(propertyNames))])
+	}
+	return strings.Join(parts, "/")
+}
+
+// randomOp applies one random file system operation, returning a description of it.
+// Operations are expected to fail often; only the invariants afterwards matter.
+func randomOp(fs *FileSystem, rng *rand.Rand) string {
+	src, dst := randomPath(rng), randomPath(rng)
+	switch rng.Intn(6) {
+	case 0:
+		fs.Mkdir(src, true)
+		return "mkdir -p " + src
+	case 1:
+		fs.Touch(src)
+		return "touch " + src
+	case 2:
+		text := strings.Repeat("x", rng.Intn(20))
+		fs.EchoWrite(text, src, rng.Intn(2) == 0)
+		return "echo " + text + " > " + src
+	case 3:
+		fs.Cp(src, dst, true)
+		return "cp -r " + src + " " + dst
+	case 4:
+		fs.Mv(src, dst)
+		return "mv " + src + " " + dst
+	default:
+		fs.Rm(src, true)
+		return "rm -r " + src
+	}
+}
+
+// checkInvariants walks the tree from the root, adding every node it finds to seen, and
+// verifies the links, paths and sizes of every node in seen that is still in a directory
+func checkInvariants(fs *FileSystem, seen map[*VirtualFile]bool) error {
+	visited := make(map[*VirtualFile]bool)
+	var walkErr error
+	var walkTree func(dir *VirtualFile)
+	walkTree = func(dir *VirtualFile) {
+		for name, child := range dir.Children {
+			if walkErr != nil {
+				return
+			}
+			if visited[child] {
+				walkErr = fmt.Errorf("%s is reachable twice", name)
+				return
+			}
+			visited[child] = true
+			seen[child] = true
+			if child.Name != name || child.Parent != dir {
+				walkErr = fmt.Errorf("%s is linked as %q under a directory it does not point back to", child.Name, name)
+				return
+			}
+			if child.Type == Directory {
+				walkTree(child)
+			}
+		}
+	}
+	walkTree(fs.Root)
+	if walkErr != nil {
+		return walkErr
+	}
+
+	for node := range seen {
+		if node.Parent == nil || node.Parent.Children[node.Name] != node {
+			continue // removed
+		}
+
+		// Following parents from a linked node must reach the root without a cycle
+		steps := 0
+		for dir := node; dir != fs.Root; dir = dir.Parent {
+			if dir.Parent == nil {
+				break // in a removed subtree
+			}
+			if steps++; steps > len(seen) {
+				return fmt.Errorf("%s is part of a cycle", node.Name)
+			}
+		}
+		if !visited[node] {
+			continue
+		}
+
+		path := fs.GetPath(node)
+		resolved, err := fs.ResolvePath(path)
+		if err != nil || resolved != node {
+			return fmt.Errorf("GetPath gave %s, which does not resolve back to the node (%v)", path, err)
+		}
+		if node.Type == RegularFile && node.Size != int64(len(node.Content)) {
+			return fmt.Errorf("%s has size %d but %d bytes of content", path, node.Size, len(node.Content))
+		}
+	}
+	return nil
+}
+
+func TestPropertyTreeInvariants(t *testing.T) {
+	seed := *propertySeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("seed %d (rerun with -seed=%d)", seed, seed)
+	rng := rand.New(rand.NewSource(seed))
+
+	for run := 0; run < 50; run++ {
+		fs := NewFileSystem()
+		fs.Mkdir("/p", false)
+		seen := make(map[*VirtualFile]bool)
+
+		var ops []string
+		for i := 0; i < 100; i++ {
+			ops = append(ops, randomOp(fs, rng))
+			if err := checkInvariants(fs, seen); err != nil {
+				t.Fatalf("seed %d, run %d: %v after:\n%s", seed, run, err, strings.Join(ops, "\n"))
+			}
+		}
+	}
+}
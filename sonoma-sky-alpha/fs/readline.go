@@ -0,0 +1,256 @@
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Keys the line editor acts on when RawInput is set
+const (
+	keyCtrlA     = 0x01
+	keyCtrlD     = 0x04
+	keyCtrlE     = 0x05
+	keyCtrlG     = 0x07
+	keyBackspace = 0x08
+	keyNewline   = '\n'
+	keyCtrlK     = 0x0b
+	keyEnter     = '\r'
+	keyCtrlR     = 0x12
+	keyCtrlU     = 0x15
+	keyCtrlW     = 0x17
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// Keys sent as escape sequences, given values no character has so typing never reads as one
+const (
+	keyLeft  rune = -1
+	keyRight rune = -2
+)
+
+// lineEditor reads a line a keystroke at a time, echoing it, for input that is not line
+// buffered by the terminal. It has emacs-style bindings:
+//
+//	Ctrl-A, Ctrl-E  move to the start or end of the line
+//	Left, Right     move a character back or forward; typing inserts at the cursor
+//	Ctrl-K          delete from the cursor to the end of the line
+//	Ctrl-W          delete the word before the cursor
+//	Ctrl-U          delete the whole line
+//
+// Ctrl-R starts a reverse search of history: typing narrows it to the most recent command
+// containing the text typed, Ctrl-R again goes on to older ones, Enter runs the command found
+// and Ctrl-G gives up the search. Any other editing key leaves the command found on the line.
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history []string // Previous commands, oldest first
+
+	line      []rune
+	pos       int // Cursor position in line
+	searching bool
+	query     []rune
+	match     int  // Index in history of the command found, -1 for none
+	failed    bool // Whether the last search found nothing, which keeps the previous match
+	col       int  // Column of the cursor counted from the end of the prompt
+}
+
+// ReadLine reads a line, returning io.EOF for a Ctrl-D on an empty line
+func (e *lineEditor) ReadLine() (string, error) {
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(e.line) > 0 {
+				err = nil
+			}
+			fmt.Fprintln(e.out)
+			return string(e.line), err
+		}
+
+		switch {
+		case r == keyEnter || r == keyNewline:
+			e.acceptSearch()
+			fmt.Fprintln(e.out)
+			return string(e.line), nil
+		case r == keyCtrlD:
+			if len(e.line) == 0 && !e.searching {
+				return "", io.EOF
+			}
+		case r == keyCtrlR:
+			if !e.searching {
+				e.searching, e.query, e.match = true, nil, -1
+				e.redraw()
+			} else if e.match >= 0 {
+				e.search(e.match - 1)
+			}
+		case r == keyCtrlG:
+			if e.searching {
+				e.searching = false
+				e.redraw()
+			}
+		case e.searching && (r == keyBackspace || r == keyDelete):
+			if len(e.query) > 0 {
+				e.query, e.match = e.query[:len(e.query)-1], -1
+				e.search(len(e.history) - 1)
+			}
+		case e.searching && unicode.IsPrint(r):
+			e.query = append(e.query, r)
+			from := e.match
+			if from < 0 {
+				from = len(e.history) - 1
+			}
+			e.search(from)
+		case r == keyEscape:
+			e.acceptSearch()
+			e.edit(e.escapeSequence())
+		default:
+			e.acceptSearch()
+			e.edit(r)
+		}
+	}
+}
+
+// edit applies an editing key, or inserts a printable character at the cursor
+func (e *lineEditor) edit(r rune) {
+	switch {
+	case r == keyCtrlA:
+		e.move(0)
+		return
+	case r == keyCtrlE:
+		e.move(len(e.line))
+		return
+	case r == keyLeft:
+		e.move(max(e.pos-1, 0))
+		return
+	case r == keyRight:
+		e.move(min(e.pos+1, len(e.line)))
+		return
+	case r == keyCtrlK:
+		e.line = e.line[:e.pos]
+	case r == keyCtrlU:
+		e.line, e.pos = nil, 0
+	case r == keyCtrlW:
+		start := e.pos
+		for start > 0 && unicode.IsSpace(e.line[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(e.line[start-1]) {
+			start--
+		}
+		e.line, e.pos = append(e.line[:start], e.line[e.pos:]...), start
+	case r == keyBackspace || r == keyDelete:
+		if e.pos == 0 {
+			return
+		}
+		e.line, e.pos = append(e.line[:e.pos-1], e.line[e.pos:]...), e.pos-1
+	case unicode.IsPrint(r) && e.pos == len(e.line):
+		// Typing at the end of the line needs no redraw
+		e.line, e.pos = append(e.line, r), e.pos+1
+		fmt.Fprint(e.out, string(r))
+		e.col++
+		return
+	case unicode.IsPrint(r):
+		e.line = append(e.line[:e.pos], append([]rune{r}, e.line[e.pos:]...)...)
+		e.pos++
+	default:
+		return
+	}
+	e.redraw()
+}
+
+// search finds the most recent command containing the query, starting at history[from] and
+// skipping the command already found, and shows it
+func (e *lineEditor) search(from int) {
+	e.failed = true
+	if len(e.query) > 0 {
+		current := ""
+		if e.match >= 0 {
+			current = e.history[e.match]
+		}
+		for i := min(from, len(e.history)-1); i >= 0; i-- {
+			if strings.Contains(e.history[i], string(e.query)) && (i == e.match || e.history[i] != current) {
+				e.match, e.failed = i, false
+				break
+			}
+		}
+	}
+	e.redraw()
+}
+
+// acceptSearch ends a search, leaving the command found on the line
+func (e *lineEditor) acceptSearch() {
+	if !e.searching {
+		return
+	}
+	e.searching = false
+	if e.match >= 0 {
+		e.line = []rune(e.history[e.match])
+		e.pos = len(e.line)
+	}
+	e.redraw()
+}
+
+// move puts the cursor at pos in the line
+func (e *lineEditor) move(pos int) {
+	if pos < e.pos {
+		fmt.Fprintf(e.out, "\033[%dD", e.pos-pos)
+	} else if pos > e.pos {
+		fmt.Fprintf(e.out, "\033[%dC", pos-e.pos)
+	}
+	e.col += pos - e.pos
+	e.pos = pos
+}
+
+// escapeSequence reads the rest of an escape sequence and returns the key it stands for,
+// keyLeft or keyRight. Other keys, such as the up arrow, come back as keyEscape, which edit
+// ignores.
+func (e *lineEditor) escapeSequence() rune {
+	if next, err := e.in.Peek(1); err != nil || (next[0] != '[' && next[0] != 'O') {
+		return keyEscape
+	}
+	e.in.ReadByte()
+	for {
+		b, err := e.in.ReadByte()
+		if err != nil {
+			return keyEscape
+		}
+		if b >= 0x40 && b <= 0x7e {
+			switch b {
+			case 'D':
+				return keyLeft
+			case 'C':
+				return keyRight
+			}
+			return keyEscape
+		}
+	}
+}
+
+// redraw replaces what was written since the prompt with the line, or the search in progress,
+// and puts the cursor back in place
+func (e *lineEditor) redraw() {
+	text, cursor := string(e.line), e.pos
+	if e.searching {
+		label := "reverse-i-search"
+		if e.failed && len(e.query) > 0 {
+			label = "failed " + label
+		}
+		found := ""
+		if e.match >= 0 {
+			found = e.history[e.match]
+		}
+		text = fmt.Sprintf("(%s)`%s': %s", label, string(e.query), found)
+		cursor = utf8.RuneCountInString(text)
+	}
+	if e.col > 0 {
+		fmt.Fprintf(e.out, "\033[%dD", e.col)
+	}
+	fmt.Fprint(e.out, "\033[K"+text)
+	if back := utf8.RuneCountInString(text) - cursor; back > 0 {
+		fmt.Fprintf(e.out, "\033[%dD", back)
+	}
+	e.col = cursor
+}
@@ -0,0 +1,107 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReverseSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"typed", "pwd\r", "pwd"},
+		{"backspace", "pwx\x7fd\r", "pwd"},
+		{"most recent match", "\x12echo\r", "echo two"},
+		{"older match", "\x12echo\x12\r", "echo one"},
+		{"no older match", "\x12echo\x12\x12\x12\r", "echo one"},
+		{"narrowed", "\x12ec\x12o o\r", "echo one"},
+		{"no match", "\x12zzz\r", ""},
+		{"abandoned", "ls\x12echo\x07\r", "ls"},
+		{"edited after", "\x12two\x1b[A!\r", "echo two!"},
+		{"moved after", "\x12two\x01#\r", "#echo two"},
+	}
+	for _, tt := range tests {
+		term := NewTerminal()
+		term.History = []string{"echo one", "ls", "echo two", "echo two"}
+		term.RawInput = true
+		term.In = strings.NewReader(tt.keys)
+		term.Out = io.Discard
+
+		line, err := term.ReadLine()
+		if err != nil || line != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.name, tt.expected, line, err)
+		}
+	}
+}
+
+func TestLineEditing(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"start of line", "cho hi\x01e\r", "echo hi"},
+		{"end of line", "cho hi\x01e\x05!\r", "echo hi!"},
+		{"left arrow", "echo hi\x1b[D\x1b[Dx\r", "echo xhi"},
+		{"right arrow", "echo hi\x01\x1b[C\x1b[C\x1b[C\x1b[C\x1b[Cyo \r", "echo yo hi"},
+		{"arrows stop at the ends", "ab\x1b[D\x1b[D\x1b[Dx\x05\x1b[Cy\r", "xaby"},
+		{"application mode arrows", "ab\x1bODx\r", "axb"},
+		{"backspace mid-line", "echo hii\x1b[D\x7f\r", "echo hi"},
+		{"kill to end", "echo hi there\x1b[D\x1b[D\x1b[D\x1b[D\x1b[D\x1b[D\x0b\r", "echo hi"},
+		{"delete word", "echo hi there\x17\r", "echo hi "},
+		{"delete word before spaces", "echo hi there  \x17\x17\r", "echo "},
+		{"delete word mid-line", "echo hi there\x1b[D\x1b[D\x1b[D\x1b[D\x1b[D\x1b[D\x17\r", "echo  there"},
+		{"kill line", "rm -r /\x15pwd\r", "pwd"},
+		{"up arrow ignored", "pwd\x1b[A\r", "pwd"},
+		{"capital C and D typed", "echo CD\r", "echo CD"},
+		{"capital C and D typed mid-line", "echo !\x1b[DCD\r", "echo CD!"},
+	}
+	for _, tt := range tests {
+		term := NewTerminal()
+		term.RawInput = true
+		term.In = strings.NewReader(tt.keys)
+		term.Out = io.Discard
+
+		line, err := term.ReadLine()
+		if err != nil || line != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.name, tt.expected, line, err)
+		}
+	}
+}
+
+func TestLineEditingRedraw(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.RawInput = true
+	term.In = strings.NewReader("ac\x1b[Db\r")
+	term.Out = &out
+	term.ReadLine()
+
+	// Moving only moves the cursor; inserting mid-line rewrites the line and moves back after the insert
+	if out.String() != "ac\033[1D\033[1D\033[Kabc\033[1D\n" {
+		t.Errorf("Unexpected redraw %q", out.String())
+	}
+}
+
+func TestReverseSearchRunsCommand(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.RawInput = true
+	term.In = strings.NewReader("echo hello\recho other\r\x12hel\r\x04")
+	term.Out, term.Err = &out, io.Discard
+	term.Run()
+
+	if !strings.Contains(out.String(), "(reverse-i-search)`hel': echo hello") {
+		t.Errorf("Expected the search to show the match, got %q", out.String())
+	}
+	if n := strings.Count(out.String(), "\nhello\n"); n != 2 {
+		t.Errorf("Expected the found command to run again, got %q", out.String())
+	}
+	if len(term.History) != 3 || term.History[2] != "echo hello" {
+		t.Errorf("Expected the found command in history, got %q", term.History)
+	}
+}
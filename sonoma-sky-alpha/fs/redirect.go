@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// redirection sends the error message of a command, or its output too, away from the terminal
+type redirection struct {
+	stderr string // File the error message is written to, 2> FILE
+	both   string // File the output and error message are written to, &> FILE
+	merge  bool   // Whether the error message is printed with the output, 2>&1
+}
+
+// redirections removes the stderr redirections 2> FILE, 2>&1 and &> FILE from args. The file
+// may also be joined to its operator, as in 2>errors.txt. raw holds each argument as typed,
+// so an operator that was quoted or escaped is left as an argument.
+func redirections(args, raw []string) ([]string, redirection, error) {
+	var r redirection
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if raw[i] == "2>&1" {
+			r.merge = true
+			continue
+		}
+		var target *string
+		var file string
+		if strings.HasPrefix(raw[i], "2>") {
+			target, file = &r.stderr, strings.TrimPrefix(arg, "2>")
+		} else if strings.HasPrefix(raw[i], "&>") {
+			target, file = &r.both, strings.TrimPrefix(arg, "&>")
+		} else {
+			rest = append(rest, arg)
+			continue
+		}
+		if file == "" {
+			if i+1 == len(args) {
+				return nil, r, fmt.Errorf("syntax error near unexpected token 'newline'")
+			}
+			i++
+			file = args[i]
+		}
+		*target = file
+	}
+	return rest, r, nil
+}
+
+// errorText returns the message printed on stderr for err
+func errorText(err error) string {
+	return "Error: " + err.Error() + "\n"
+}
+
+// apply sends the output and error of a command where r says. It returns what is left for
+// the terminal and whether the command failed; err is only set when a file can't be written.
+// Output that leaves its line unended, as t.partial says, is written without a newline added.
+func (r redirection) apply(t *Terminal, output string, cmdErr error) (string, bool, error) {
+	failed := cmdErr != nil
+	message := ""
+	if failed {
+		message = errorText(cmdErr)
+	}
+	switch {
+	case r.both != "":
+		text := output
+		if text != "" && !t.partial {
+			text += "\n"
+		}
+		return "", failed, t.FS.EchoWriteWith(text+message, r.both, false, EchoOptions{NoNewline: true})
+	case r.stderr != "":
+		// The file is created, or emptied, even when there is no error, as in a shell
+		return output, failed, t.FS.EchoWriteWith(message, r.stderr, false, EchoOptions{NoNewline: true})
+	case r.merge && failed:
+		if output != "" && !t.partial {
+			output += "\n"
+		}
+		t.partial = false // The message ends the line
+		return output + strings.TrimSuffix(message, "\n"), true, nil
+	}
+	return output, failed, cmdErr
+}
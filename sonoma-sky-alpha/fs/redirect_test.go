@@ -0,0 +1,77 @@
+package fs
+
+import "testing"
+
+func TestStderrRedirection(t *testing.T) {
+	term := NewTerminal()
+
+	tests := []struct {
+		input  string
+		stdout string
+		stderr string
+		path   string // File the redirection writes
+		file   string
+	}{
+		{"cat missing.txt 2> err.txt", "", "", "err.txt",
+			"Error: cat: missing.txt: no such file or directory: missing.txt\n"},
+		{"pwd 2>err.txt", "/home/user\n", "", "err.txt", ""},
+		{"cat missing.txt 2>&1", "Error: cat: missing.txt: no such file or directory: missing.txt\n", "", "", ""},
+		{"pwd &> both.txt", "", "", "both.txt", "/home/user\n"},
+		{"cat missing.txt &>both.txt", "", "", "both.txt",
+			"Error: cat: missing.txt: no such file or directory: missing.txt\n"},
+	}
+	for _, tt := range tests {
+		stdout, stderr, _ := term.RunCommand(tt.input)
+		if stdout != tt.stdout || stderr != tt.stderr {
+			t.Errorf("%s: expected %q / %q, got %q / %q", tt.input, tt.stdout, tt.stderr, stdout, stderr)
+		}
+		if tt.path == "" {
+			continue
+		}
+		if content, _ := term.FS.Cat(tt.path); content != tt.file {
+			t.Errorf("%s: expected %q in %s, got %q", tt.input, tt.file, tt.path, content)
+		}
+	}
+
+	// The error is still reported in the status
+	term.Execute("cat missing.txt 2> err.txt")
+	if term.Status != 1 {
+		t.Errorf("A redirected error should set the status to 1, got %d", term.Status)
+	}
+	if output, _ := term.Execute("cat missing.txt 2>&1 | wc -w"); output != "9" {
+		t.Errorf("2>&1 should send the error down the pipeline, got %q", output)
+	}
+	if _, err := term.Execute("pwd 2>"); err == nil {
+		t.Error("A redirection without a file should error")
+	}
+}
+
+func TestQuotedRedirection(t *testing.T) {
+	term := NewTerminal()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`echo "2>x"`, "2>x"},
+		{`echo '2>' x`, "2> x"},
+		{`echo 2\>x`, "2>x"},
+		{`echo "2>&1"`, "2>&1"},
+		{`echo '&>x'`, "&>x"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil || output != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.input, tt.expected, output, err)
+		}
+	}
+	if _, err := term.FS.ResolvePath("x"); err == nil {
+		t.Error("A quoted redirection should not create a file")
+	}
+
+	// The file may be quoted when the operator is not
+	term.Execute(`cat missing.txt 2>"err file.txt"`)
+	if content, _ := term.FS.Cat("err file.txt"); content == "" {
+		t.Error("2>\"FILE\" should write the error to FILE")
+	}
+}
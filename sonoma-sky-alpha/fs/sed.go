@@ -0,0 +1,150 @@
+package fs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Substitution is a parsed sed s/PATTERN/REPLACEMENT/[g] command
+type Substitution struct {
+	Pattern  *regexp.Regexp
+	Template string // Replacement in regexp.Expand syntax
+	Global   bool   // Replace every match on a line rather than the first
+}
+
+// ParseSubstitution parses a sed s command. Any character after the s delimits the parts,
+// and a delimiter preceded by a backslash is taken literally. In the replacement & stands
+// for the whole match and \1 to \9 for groups.
+func ParseSubstitution(script string) (*Substitution, error) {
+	if len(script) < 2 || script[0] != 's' {
+		return nil, fmt.Errorf("unknown command: '%s'", script)
+	}
+	delim := script[1]
+	parts := splitUnescaped(script[2:], delim)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unterminated 's' command")
+	}
+	global := false
+	for _, flag := range parts[2] {
+		if flag != 'g' {
+			return nil, fmt.Errorf("unknown option to 's': '%c'", flag)
+		}
+		global = true
+	}
+
+	re, err := regexp.Compile(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s'", parts[0])
+	}
+	return &Substitution{Pattern: re, Template: expandTemplate(parts[1]), Global: global}, nil
+}
+
+// splitUnescaped splits s at each delim that is not preceded by a backslash, dropping the
+// backslash before an escaped delim
+func splitUnescaped(s string, delim byte) []string {
+	var parts []string
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			sb.WriteByte(delim)
+			i++
+		case s[i] == '\\' && i+1 < len(s):
+			sb.WriteString(s[i : i+2])
+			i++
+		case s[i] == delim:
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return append(parts, sb.String())
+}
+
+// expandTemplate turns a sed replacement into regexp.Expand syntax
+func expandTemplate(replacement string) string {
+	var sb strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		c := replacement[i]
+		switch {
+		case c == '&':
+			sb.WriteString("${0}")
+		case c == '$':
+			sb.WriteString("$$")
+		case c == '\\' && i+1 < len(replacement):
+			i++
+			next := replacement[i]
+			switch {
+			case next >= '1' && next <= '9':
+				sb.WriteString("${" + string(next) + "}")
+			case next == 'n':
+				sb.WriteByte('\n')
+			case next == 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(next)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// Apply returns line with the first match, or every match when Global, replaced
+func (s *Substitution) Apply(line string) string {
+	if s.Global {
+		return s.Pattern.ReplaceAllString(line, s.Template)
+	}
+	loc := s.Pattern.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return line
+	}
+	result := s.Pattern.ExpandString(nil, s.Template, line, loc)
+	return line[:loc[0]] + string(result) + line[loc[1]:]
+}
+
+// Sed runs the substitution script on each line of the file at path and returns the result.
+// With inPlace the file is rewritten instead and nothing is returned.
+func (fs *FileSystem) Sed(script, path string, inPlace bool) (string, error) {
+	sub, err := ParseSubstitution(script)
+	if err != nil {
+		return "", fmt.Errorf("sed: -e expression: %v", err)
+	}
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("sed: %s: %v", path, err)
+	}
+	if file.Type == Directory {
+		return "", fmt.Errorf("sed: %s: is a directory", path)
+	}
+	if err := fs.checkAccess(file, AccessRead, path); err != nil {
+		return "", fmt.Errorf("sed: %v", err)
+	}
+
+	text := string(file.Content)
+	if text == "" {
+		return "", nil
+	}
+	newline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = sub.Apply(line)
+	}
+	result := strings.Join(lines, "\n")
+	if !inPlace {
+		return result, nil
+	}
+	if newline {
+		result += "\n"
+	}
+
+	if err := fs.checkAccess(file, AccessWrite, path); err != nil {
+		return "", fmt.Errorf("sed: %v", err)
+	}
+	fs.record(newContentOp(file))
+	file.setContent([]byte(result))
+	return "", nil
+}
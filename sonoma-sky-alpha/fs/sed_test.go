@@ -0,0 +1,60 @@
+package fs
+
+import "testing"
+
+func TestSed(t *testing.T) {
+	fs := NewFileSystem()
+	if err := fs.EchoWrite("a cat and a cat\nno match here\ncat", "pets.txt", false); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"s/cat/dog/", "a dog and a cat\nno match here\ndog"},
+		{"s/cat/dog/g", "a dog and a dog\nno match here\ndog"},
+		{"s/c.t/[&]/", "a [cat] and a cat\nno match here\n[cat]"},
+		{`s/(c)(at)/\2\1/g`, "a atc and a atc\nno match here\natc"},
+		{"s|a c|A C|", "A Cat and a cat\nno match here\ncat"},
+		{`s/cat/a\/b/`, "a a/b and a cat\nno match here\na/b"},
+		{"s/^/> /", "> a cat and a cat\n> no match here\n> cat"},
+		{"s/cat/$1/", "a $1 and a cat\nno match here\n$1"},
+	}
+	for _, tt := range tests {
+		got, err := fs.Sed(tt.script, "pets.txt", false)
+		if err != nil {
+			t.Errorf("%s: %v", tt.script, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.script, tt.want, got)
+		}
+	}
+
+	for _, script := range []string{"p", "s/cat/dog", "s/cat/dog/x", "s/(/x/"} {
+		if _, err := fs.Sed(script, "pets.txt", false); err == nil {
+			t.Errorf("%s should be rejected", script)
+		}
+	}
+}
+
+func TestSedInPlace(t *testing.T) {
+	term := NewTerminal()
+	fs := term.FS
+	fs.EchoWrite("version=v1\nname=v1-app", "app.conf", false)
+
+	stdout, _, err := term.RunCommand("sed -i 's/v1/v2/' app.conf")
+	if err != nil || stdout != "" {
+		t.Fatalf("sed -i should print nothing, got %q (%v)", stdout, err)
+	}
+	content, _ := fs.Cat("app.conf")
+	if content != "version=v2\nname=v2-app\n" {
+		t.Errorf("sed -i should rewrite the file and keep its final newline, got %q", content)
+	}
+
+	term.RunCommand("undo")
+	if content, _ := fs.Cat("app.conf"); content != "version=v1\nname=v1-app\n" {
+		t.Errorf("undo should restore the file edited by sed -i, got %q", content)
+	}
+}
@@ -0,0 +1,109 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IgnoreEOFNotice is printed when an end of input is ignored because of IgnoreEOF
+const IgnoreEOFNotice = `Use "exit" to leave the terminal.`
+
+// ContinuationPrompt asks for the rest of a command line ending in a backslash
+const ContinuationPrompt = "> "
+
+// Run is the interactive loop: it prints the prompt, reads a command line from In and runs it,
+// printing its output on Out and any error on Err, until exit. A line ending in a backslash goes
+// on in the next. An end of input (Ctrl-D) prints a newline and ends the loop too, unless
+// IgnoreEOF is set: then that many in a row are ignored first.
+func (t *Terminal) Run() {
+	eofs := 0 // Ends of input ignored in a row
+	for t.Running {
+		fmt.Fprint(t.Out, t.RenderPrompt())
+
+		input, err := t.readCommand()
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			fmt.Fprintln(t.Err, "Error: syntax error: unexpected end of file")
+			err = io.EOF
+		}
+		if err == io.EOF {
+			fmt.Fprintln(t.Out)
+			if eofs < t.IgnoreEOF {
+				eofs++
+				fmt.Fprintln(t.Err, IgnoreEOFNotice)
+				continue
+			}
+			return
+		}
+		eofs = 0
+		if err != nil {
+			fmt.Fprintln(t.Err, "Error reading input:", err)
+			continue
+		}
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			continue
+		}
+
+		stdout, stderr, _ := t.RunCommand(input)
+		fmt.Fprint(t.Out, stdout)
+		fmt.Fprint(t.Err, stderr)
+	}
+}
+
+// readCommand reads a command line. While it ends in a backslash that is not itself escaped, the
+// backslash is dropped and the next line, asked for with ContinuationPrompt, is joined on; while
+// a quote is left open the next line is added after a newline, which becomes part of the quoted
+// text. Input ending before the last line returns io.ErrUnexpectedEOF.
+func (t *Terminal) readCommand() (string, error) {
+	line, err := t.ReadLine()
+	for err == nil {
+		quote, continued := lineState(line)
+		if quote == 0 && !continued {
+			break
+		}
+		fmt.Fprint(t.Out, ContinuationPrompt)
+		var next string
+		next, err = t.ReadLine()
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		if continued {
+			line = line[:len(line)-1] + next
+		} else {
+			line += "\n" + next
+		}
+	}
+	return line, err
+}
+
+// lineState returns the quote left open at the end of line, if any, and whether line ends in a
+// backslash escaping the end of the line, which single quotes keep literal. Quotes and
+// backslashes are read as ParseCommand reads them.
+func lineState(line string) (quote rune, continued bool) {
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			}
+		case r == '\\' && i+1 == len(runes):
+			return quote, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+			}
+		case r == '\\':
+			i++
+		case r == '"' || r == '\'':
+			quote = r
+		}
+	}
+	return quote, false
+}
@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// keystrokes is input typed at a terminal: each entry is read in turn, and an empty one is a
+// Ctrl-D, which ends the input once rather than for good
+type keystrokes []string
+
+func (k *keystrokes) Read(p []byte) (int, error) {
+	if len(*k) == 0 {
+		return 0, io.EOF
+	}
+	next := (*k)[0]
+	*k = (*k)[1:]
+	if next == "" {
+		return 0, io.EOF
+	}
+	return copy(p, next), nil
+}
+
+func TestRunExitsOnEOF(t *testing.T) {
+	var out, errs bytes.Buffer
+	term := NewTerminal()
+	term.In = &keystrokes{"pwd\n", ""}
+	term.Out, term.Err = &out, &errs
+	term.Run()
+
+	if out.String() != "/home/user$ /home/user\n/home/user$ \n" {
+		t.Errorf("Expected the output and a newline after Ctrl-D, got %q", out.String())
+	}
+	if errs.String() != "" {
+		t.Errorf("Expected nothing on stderr, got %q", errs.String())
+	}
+}
+
+func TestRunIgnoreEOF(t *testing.T) {
+	var out, errs bytes.Buffer
+	term := NewTerminal()
+	term.IgnoreEOF = 1
+	// A command in between starts the count again
+	term.In = &keystrokes{"", "echo hi\n", "", "", "echo unreachable\n"}
+	term.Out, term.Err = &out, &errs
+	term.Run()
+
+	if !strings.Contains(out.String(), "hi\n") || strings.Contains(out.String(), "unreachable") {
+		t.Errorf("Expected the loop to end at the second Ctrl-D in a row, got %q", out.String())
+	}
+	if n := strings.Count(errs.String(), IgnoreEOFNotice); n != 2 {
+		t.Errorf("Expected the notice for each ignored Ctrl-D, got %q", errs.String())
+	}
+}
+
+func TestRunContinuesLines(t *testing.T) {
+	var out, errs bytes.Buffer
+	term := NewTerminal()
+	term.In = strings.NewReader("echo one \\\ntwo \\\nthree\necho a\\\\\n")
+	term.Out, term.Err = &out, &errs
+	term.Run()
+
+	// An escaped backslash at the end does not continue the line
+	expected := "/home/user$ > > one two three\n/home/user$ a\\\n/home/user$ \n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	if errs.String() != "" {
+		t.Errorf("Expected nothing on stderr, got %q", errs.String())
+	}
+	if len(term.History) != 2 || term.History[0] != "echo one two three" {
+		t.Errorf("Expected the joined line in history, got %q", term.History)
+	}
+}
+
+func TestRunContinuationEOF(t *testing.T) {
+	var out, errs bytes.Buffer
+	term := NewTerminal()
+	term.In = strings.NewReader("echo one \\\n")
+	term.Out, term.Err = &out, &errs
+	term.Run()
+
+	if errs.String() != "Error: syntax error: unexpected end of file\n" {
+		t.Errorf("Expected a syntax error, got %q", errs.String())
+	}
+	if len(term.History) != 0 {
+		t.Errorf("The unfinished line should not run, got %q", term.History)
+	}
+}
+
+func TestRunContinuesQuotes(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal()
+	term.In = strings.NewReader("echo \"line1\nline2\"\necho 'a\\\n\nb' \"c\\\nd\"\n")
+	term.Out, term.Err = &out, io.Discard
+	term.Run()
+
+	// Single quotes keep a backslash at the end of a line; double quotes drop it like a bare one
+	expected := "/home/user$ > line1\nline2\n/home/user$ > > > a\\\n\nb cd\n/home/user$ \n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestLineState(t *testing.T) {
+	tests := []struct {
+		line      string
+		quote     rune
+		continued bool
+	}{
+		{`echo hi`, 0, false},
+		{`echo "hi`, '"', false},
+		{`echo 'hi`, '\'', false},
+		{`echo "it's"`, 0, false},
+		{`echo 'say "hi'`, 0, false},
+		{`echo \"hi`, 0, false},
+		{`echo "a\"b`, '"', false},
+		{`echo "a\\"`, 0, false},
+		{`echo hi \`, 0, true},
+		{`echo hi \\`, 0, false},
+		{`echo "hi \`, '"', true},
+		{`echo 'hi \`, '\'', false},
+	}
+	for _, tt := range tests {
+		quote, continued := lineState(tt.line)
+		if quote != tt.quote || continued != tt.continued {
+			t.Errorf("%s: expected %q %v, got %q %v", tt.line, tt.quote, tt.continued, quote, continued)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ParseSleep parses a sleep duration: a number of seconds such as 2 or 0.5, or a Go duration
+// such as 500ms or 2s
+func ParseSleep(arg string) (time.Duration, error) {
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		secs, serr := strconv.ParseFloat(arg, 64)
+		// Reject NaN, infinities and anything a Duration cannot hold rather than overflow
+		if serr != nil || math.IsNaN(secs) || math.IsInf(secs, 0) || secs >= math.MaxInt64/float64(time.Second) {
+			return 0, fmt.Errorf("invalid time interval '%s'", arg)
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid time interval '%s'", arg)
+	}
+	return d, nil
+}
+
+// sleep waits for d, returning ErrInterrupted as soon as ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ErrInterrupted
+	}
+}
@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSleep(t *testing.T) {
+	term := NewTerminal()
+	start := time.Now()
+	if _, _, err := term.RunCommand("sleep 100ms"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("sleep 100ms returned after %v", elapsed)
+	}
+
+	for _, arg := range []string{"soon", "-1", "5x", "", "nan", "NaN", "inf", "-Inf", "1e300", "9223372037", "-0.5"} {
+		if _, err := term.ExecuteCommand("sleep", []string{arg}); err == nil {
+			t.Errorf("sleep %q should be an invalid interval", arg)
+		}
+	}
+	for arg, want := range map[string]time.Duration{"2": 2 * time.Second, "0.5": 500 * time.Millisecond, "1m": time.Minute, "9223372036": 9223372036 * time.Second} {
+		if d, err := ParseSleep(arg); err != nil || d != want {
+			t.Errorf("%s: expected %v, got %v (%v)", arg, want, d, err)
+		}
+	}
+}
+
+func TestSleepInBackground(t *testing.T) {
+	term := NewTerminal()
+	term.Out = nil
+	if output, _ := term.Execute("sleep 300ms &"); output != "[1]" {
+		t.Fatalf("expected a job id, got %q", output)
+	}
+
+	// The sleeping job does not hold up the foreground
+	start := time.Now()
+	output, err := term.Execute("ps")
+	if err != nil || !strings.Contains(output, "sleep 300ms") {
+		t.Errorf("ps should list the sleeping job, got %q (%v)", output, err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("the foreground waited %v for the sleeping job", elapsed)
+	}
+
+	term.WaitJobs()
+	if output, _ := term.Execute("ps"); strings.Contains(output, "sleep") {
+		t.Errorf("the job should be gone once sleep returns, got %q", output)
+	}
+}
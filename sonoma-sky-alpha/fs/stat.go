@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// typeName returns the type field used by stat and porcelain output
+func typeName(file *VirtualFile) string {
+	if file.Type == Directory {
+		return "directory"
+	}
+	return "file"
+}
+
+// porcelainLine formats an entry as type, perms, size, mtime and name separated by tabs.
+// The layout is a stable contract for scripts and must not change with display options.
+func porcelainLine(file *VirtualFile, name string) string {
+	return fmt.Sprintf("%s\t%04o\t%d\t%d\t%s", typeName(file), file.Permissions, file.Size, file.ModTime.Unix(), name)
+}
+
+// Stat describes the file or directory at path
+func (fs *FileSystem) Stat(path string, porcelain bool) (string, error) {
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("stat: %s: %v", path, err)
+	}
+
+	if porcelain {
+		return porcelainLine(file, path), nil
+	}
+
+	lines := []string{
+		fmt.Sprintf("  File: %s", path),
+		fmt.Sprintf("  Size: %d\tType: %s", file.Size, typeName(file)),
+		fmt.Sprintf("Access: (%04o/%s)\tOwner: %s\tGroup: %s", file.Permissions, getPermString(file.Permissions, file.Type == Directory), file.Owner, file.Group),
+		fmt.Sprintf("Modify: %s", file.ModTime.Format("2006-01-02 15:04:05")),
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStat(t *testing.T) {
+	fs := NewFileSystem()
+	fs.EchoWrite("hello", "note.txt", false)
+	file, _ := fs.ResolvePath("note.txt")
+	file.ModTime = time.Unix(1700000000, 0)
+
+	output, err := fs.Stat("note.txt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "File: note.txt") || !strings.Contains(output, "(0644/-rw-r--r--)") {
+		t.Errorf("Unexpected stat output %q", output)
+	}
+
+	output, err = fs.Stat("note.txt", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "file\t0644\t6\t1700000000\tnote.txt" {
+		t.Errorf("Unexpected porcelain output %q", output)
+	}
+
+	if _, err := fs.Stat("missing", false); err == nil {
+		t.Error("stat of a missing path should error")
+	}
+}
+
+func TestLsPorcelain(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("src", false)
+	fs.EchoWrite("hello", "note.txt", false)
+	fs.Touch(".hidden")
+	for _, name := range []string{"src", "note.txt", ".hidden"} {
+		file, _ := fs.ResolvePath(name)
+		file.ModTime = time.Unix(1700000000, 0)
+	}
+
+	output, err := fs.LsWith(".", LsOptions{Long: true, Porcelain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "file\t0644\t6\t1700000000\tnote.txt\n" +
+		"directory\t0755\t0\t1700000000\tsrc"
+	if output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+
+	output, _ = fs.LsWith(".", LsOptions{All: true, Porcelain: true})
+	if !strings.HasPrefix(output, "file\t0644\t0\t1700000000\t.hidden\n") {
+		t.Errorf("ls -a --porcelain should include dotfiles first, got %q", output)
+	}
+}
@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"slices"
+	"sort"
+)
+
+// editDistance returns the Levenshtein distance between a and b, the number of single-rune
+// insertions, deletions and substitutions that turn one into the other
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+// sameRunes reports whether a and b are made of the same runes, possibly in another order
+func sameRunes(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	slices.Sort(ra)
+	slices.Sort(rb)
+	return slices.Equal(ra, rb)
+}
+
+// closest returns the candidate closest to name, or "" when none is close enough to be a
+// likely typo. A candidate with the same runes as name counts as one edit closer, so that
+// swapped letters such as "sl" for "ls" are suggested. Ties go to the candidate that comes
+// first.
+func closest(name string, candidates []string) string {
+	best, bestDist := "", len([]rune(name))/3+2
+	for _, candidate := range candidates {
+		dist := editDistance(name, candidate)
+		if dist > 0 && sameRunes(name, candidate) {
+			dist--
+		}
+		if dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// closestDir returns the name of the subdirectory of dir closest to name, or "" when none is
+// close enough. Ties go to the name that sorts first.
+func closestDir(dir *VirtualFile, name string) string {
+	names := make([]string, 0, len(dir.Children))
+	for child, node := range dir.Children {
+		if node.Type == Directory {
+			names = append(names, child)
+		}
+	}
+	sort.Strings(names)
+	return closest(name, names)
+}
+
+// closestCommand returns the name of the built-in command closest to name, or "" when none
+// is close enough
+func closestCommand(name string) string {
+	names := make([]string, 0, len(commandOrder))
+	for _, c := range commandOrder {
+		names = append(names, c.Name)
+	}
+	return closest(name, names)
+}
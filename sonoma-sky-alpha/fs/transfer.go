@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TransferSummary counts what happened to each source of a cp or mv with several sources
+type TransferSummary struct {
+	Done      int // Sources copied or moved to a new name
+	Skipped   int // Sources left alone because they failed
+	Overwrote int // Sources that replaced an existing destination
+}
+
+// Format returns the summary line, where verb is "copied" or "moved"
+func (s TransferSummary) Format(verb string) string {
+	noun := "files"
+	if s.Done == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("%s %d %s, skipped %d, overwrote %d", verb, s.Done, noun, s.Skipped, s.Overwrote)
+}
+
+// CpAll copies every source into the directory dest, going on past a source that fails
+func (fs *FileSystem) CpAll(sources []string, dest string, opts CopyOptions) (TransferSummary, error) {
+	return fs.transferAll("cp", sources, dest, opts, fs.CpWith)
+}
+
+// MvAll moves every source into the directory dest, going on past a source that fails
+func (fs *FileSystem) MvAll(sources []string, dest string, opts CopyOptions) (TransferSummary, error) {
+	return fs.transferAll("mv", sources, dest, opts, fs.MvWith)
+}
+
+// transferAll runs transfer for each source into dest and tallies the outcomes. The errors of
+// failed sources are joined into the returned error.
+func (fs *FileSystem) transferAll(cmd string, sources []string, dest string, opts CopyOptions,
+	transfer func(source, dest string, opts CopyOptions) error) (TransferSummary, error) {
+	var summary TransferSummary
+	if isDir, err := fs.IsDirectory(dest); err != nil || !isDir {
+		return summary, fmt.Errorf("%s: target '%s' is not a directory", cmd, dest)
+	}
+
+	var errs []error
+	for _, source := range sources {
+		existed := false
+		if srcFile, err := fs.ResolvePath(source); err == nil {
+			if parent, name, err := fs.destination(cmd, srcFile, dest); err == nil {
+				_, existed = parent.Children[name]
+			}
+		}
+
+		if err := transfer(source, dest, opts); err != nil {
+			errs = append(errs, err)
+			summary.Skipped++
+			continue
+		}
+		switch {
+		case existed:
+			summary.Overwrote++
+		default:
+			summary.Done++
+		}
+	}
+	return summary, errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package fs
+
+import "testing"
+
+func TestCpSummary(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("dest", false)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		term.FS.EchoWrite("new "+name, name, false)
+	}
+	term.FS.EchoWrite("old", "dest/b.txt", false)
+
+	output, err := term.Execute("cp a.txt b.txt c.txt dest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "copied 2 files, skipped 0, overwrote 1" {
+		t.Errorf("Unexpected summary %q", output)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		content, _ := term.FS.Cat("dest/" + name)
+		if content != "new "+name+"\n" {
+			t.Errorf("dest/%s: expected the copied content, got %q", name, content)
+		}
+	}
+
+	// A failed source is skipped, and the others still go through
+	term.FS.EchoWrite("old", "dest/a.txt", false)
+	term.FS.EchoWrite("new d.txt", "d.txt", false)
+	output, err = term.Execute("cp a.txt d.txt missing.txt dest")
+	if err == nil {
+		t.Error("Expected an error for the missing source")
+	}
+	if output != "copied 1 file, skipped 1, overwrote 1" {
+		t.Errorf("Unexpected summary %q", output)
+	}
+	if content, _ := term.FS.Cat("dest/a.txt"); content != "new a.txt\n" {
+		t.Errorf("cp should overwrite dest/a.txt, got %q", content)
+	}
+	if _, err := term.FS.ResolvePath("dest/d.txt"); err != nil {
+		t.Error("cp should still copy d.txt")
+	}
+
+	// A single source prints nothing, as before
+	if output, _ := term.Execute("cp a.txt e.txt"); output != "" {
+		t.Errorf("cp of one source should print nothing, got %q", output)
+	}
+	if _, err := term.Execute("cp a.txt b.txt e.txt"); err == nil {
+		t.Error("Several sources into a file should error")
+	}
+}
+
+func TestMvSummary(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("dest", false)
+	term.FS.Mkdir("dir", false)
+	term.FS.Touch("a.txt")
+	term.FS.Touch("b.txt")
+	term.FS.Touch("dest/a.txt")
+
+	output, err := term.Execute("mv a.txt b.txt dir dest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "moved 2 files, skipped 0, overwrote 1" {
+		t.Errorf("Unexpected summary %q", output)
+	}
+	for _, path := range []string{"a.txt", "b.txt", "dir"} {
+		if _, err := term.FS.ResolvePath(path); err == nil {
+			t.Errorf("%s should have been moved", path)
+		}
+		if _, err := term.FS.ResolvePath("dest/" + path); err != nil {
+			t.Errorf("dest/%s should exist", path)
+		}
+	}
+
+	// The whole command is undone as one step
+	term.Execute("undo")
+	if _, err := term.FS.ResolvePath("dir"); err != nil {
+		t.Error("undo should bring every moved source back")
+	}
+}
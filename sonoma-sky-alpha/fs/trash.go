@@ -0,0 +1,80 @@
+package fs
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTrashLimit is the number of removed nodes kept for restore
+const DefaultTrashLimit = 20
+
+// TrashEntry records a node removed by rm along with the path it was removed from
+type TrashEntry struct {
+	Path      string
+	Node      *VirtualFile
+	DeletedAt time.Time
+}
+
+// trash stores a removed node, evicting the oldest entries beyond TrashLimit
+func (fs *FileSystem) trash(path string, node *VirtualFile) {
+	if fs.TrashLimit <= 0 {
+		return
+	}
+	node.Parent = nil
+	fs.pathGen++
+	fs.invalidate()
+	fs.Trash = append(fs.Trash, TrashEntry{Path: path, Node: node, DeletedAt: time.Now()})
+	if over := len(fs.Trash) - fs.TrashLimit; over > 0 {
+		fs.Trash = fs.Trash[over:]
+	}
+}
+
+// Restore puts a removed node back where it was, recreating missing parent directories.
+// With an empty path the most recent removal is restored; otherwise the most recent
+// removal of that path. It returns the restored absolute path.
+func (fs *FileSystem) Restore(path string) (string, error) {
+	if len(fs.Trash) == 0 {
+		return "", fmt.Errorf("restore: trash is empty")
+	}
+
+	index := len(fs.Trash) - 1
+	if path != "" {
+		abs := fs.absPath(path)
+		for index >= 0 && fs.Trash[index].Path != abs {
+			index--
+		}
+		if index < 0 {
+			return "", fmt.Errorf("restore: %s: not in trash", path)
+		}
+	}
+	entry := fs.Trash[index]
+
+	dirPath, name := filepath.Split(entry.Path)
+	if err := fs.Mkdir(dirPath, true); err != nil && dirPath != "/" {
+		return "", fmt.Errorf("restore: %v", err)
+	}
+	dir, err := fs.ResolvePath(dirPath)
+	if err != nil {
+		return "", fmt.Errorf("restore: %v", err)
+	}
+	if _, exists := dir.Children[name]; exists {
+		return "", fmt.Errorf("restore: %s: file exists", entry.Path)
+	}
+
+	fs.link(dir, name, entry.Node)
+	fs.record(createOp{entry.Node})
+	fs.Trash = append(fs.Trash[:index], fs.Trash[index+1:]...)
+	return entry.Path, nil
+}
+
+// absPath returns the cleaned absolute form of path relative to the current directory
+func (fs *FileSystem) absPath(path string) string {
+	if expanded, err := fs.expandTilde(path); err == nil {
+		path = expanded
+	}
+	if !IsAbsolute(path) {
+		path = fs.Pwd() + "/" + path
+	}
+	return filepath.Clean(path)
+}
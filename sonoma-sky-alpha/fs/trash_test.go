@@ -0,0 +1,111 @@
+package fs
+
+import (
+	"testing"
+)
+
+func TestRestoreFile(t *testing.T) {
+	fs := NewFileSystem()
+	fs.EchoWrite("keep me", "notes.txt", false)
+
+	err := fs.Rm("notes.txt", false)
+	if err != nil {
+		t.Error(err)
+	}
+	if exists, _ := fs.Exists("notes.txt"); exists {
+		t.Fatal("rm should remove the file")
+	}
+
+	restored, err := fs.Restore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != "/home/user/notes.txt" {
+		t.Errorf("Expected /home/user/notes.txt, got %s", restored)
+	}
+	output, err := fs.Cat("notes.txt")
+	if err != nil {
+		t.Error(err)
+	}
+	if output != "keep me\n" {
+		t.Errorf("Restored file content mismatch: %q", output)
+	}
+	if len(fs.Trash) != 0 {
+		t.Errorf("Trash should be empty after restore, has %d entries", len(fs.Trash))
+	}
+
+	_, err = fs.Restore("")
+	if err == nil {
+		t.Error("Restore from an empty trash should error")
+	}
+}
+
+func TestRestoreDirectory(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("project/src", true)
+	fs.EchoWrite("package main", "project/src/main.go", false)
+	fs.Touch("project/README")
+
+	err := fs.Rm("project", true)
+	if err != nil {
+		t.Error(err)
+	}
+	if exists, _ := fs.Exists("project"); exists {
+		t.Fatal("rm -r should remove the directory")
+	}
+
+	_, err = fs.Restore("project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := fs.Cat("project/src/main.go")
+	if err != nil {
+		t.Error(err)
+	}
+	if output != "package main\n" {
+		t.Errorf("Restored nested file content mismatch: %q", output)
+	}
+	readme, err := fs.ResolvePath("project/README")
+	if err != nil {
+		t.Error(err)
+	}
+	if fs.GetPath(readme.Parent.Parent) != "/home/user" {
+		t.Errorf("Restored tree has wrong parent: %s", fs.GetPath(readme.Parent.Parent))
+	}
+}
+
+func TestRestoreRecreatesParents(t *testing.T) {
+	fs := NewFileSystem()
+	fs.Mkdir("a/b", true)
+	fs.Touch("a/b/file.txt")
+
+	fs.Rm("a/b/file.txt", false)
+	fs.Rm("a", true)
+
+	_, err := fs.Restore("a/b/file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := fs.Exists("a/b/file.txt"); !exists {
+		t.Error("Restore should recreate missing parent directories")
+	}
+}
+
+func TestTrashLimit(t *testing.T) {
+	fs := NewFileSystem()
+	fs.TrashLimit = 2
+	for _, name := range []string{"one", "two", "three"} {
+		fs.Touch(name)
+		fs.Rm(name, false)
+	}
+
+	if len(fs.Trash) != 2 {
+		t.Fatalf("Expected 2 trash entries, got %d", len(fs.Trash))
+	}
+	if _, err := fs.Restore("one"); err == nil {
+		t.Error("The oldest entry should have been evicted")
+	}
+	if _, err := fs.Restore("three"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TreeOptions controls what Tree lists and how it prints it
+type TreeOptions struct {
+	All  bool // Include entries whose names start with a dot
+	JSON bool // Print the tree as JSON rather than drawn with lines
+}
+
+// TreeNode is an entry of the tree Tree walks, and its form in JSON output
+type TreeNode struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`               // "file" or "directory", as in stat
+	Children []*TreeNode `json:"children,omitempty"` // Sorted by name; absent for files and empty directories
+}
+
+// Tree lists the directory at path and everything below it, drawn as a tree and followed by the
+// number of directories and files, or with opts.JSON as a TreeNode in JSON. The contents of
+// directories the user can't read are left out.
+func (fs *FileSystem) Tree(path string, opts TreeOptions) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("tree: %s: %v", path, err)
+	}
+	if file.Type == Directory {
+		if err := fs.checkAccess(file, AccessRead, path); err != nil {
+			return "", fmt.Errorf("tree: %v", err)
+		}
+	}
+
+	root := fs.treeNode(file, path, opts)
+	if opts.JSON {
+		data, err := json.Marshal(root)
+		if err != nil {
+			return "", fmt.Errorf("tree: %v", err)
+		}
+		return string(data), nil
+	}
+
+	lines := []string{root.Name}
+	dirs, files := drawTree(root.Children, "", &lines)
+	return strings.Join(append(lines, "", fmt.Sprintf("%d directories, %d files", dirs, files)), "\n"), nil
+}
+
+// treeNode walks file, named name, into a TreeNode
+func (fs *FileSystem) treeNode(file *VirtualFile, name string, opts TreeOptions) *TreeNode {
+	node := &TreeNode{Name: name, Type: typeName(file)}
+	if file.Type != Directory || !fs.CanAccess(file, AccessRead) {
+		return node
+	}
+	names := make([]string, 0, len(file.Children))
+	for child := range file.Children {
+		if opts.All || !strings.HasPrefix(child, ".") {
+			names = append(names, child)
+		}
+	}
+	sort.Strings(names)
+	for _, child := range names {
+		node.Children = append(node.Children, fs.treeNode(file.Children[child], child, opts))
+	}
+	return node
+}
+
+// drawTree appends a line for each node and its descendants to lines, each behind the branches
+// leading to it, and returns the number of directories and files drawn
+func drawTree(nodes []*TreeNode, indent string, lines *[]string) (dirs, files int) {
+	for i, node := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		*lines = append(*lines, indent+branch+node.Name)
+		if node.Type != "directory" {
+			files++
+			continue
+		}
+		d, f := drawTree(node.Children, indent+next, lines)
+		dirs, files = dirs+d+1, files+f
+	}
+	return dirs, files
+}
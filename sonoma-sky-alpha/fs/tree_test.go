@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("project/src", true)
+	term.FS.Mkdir("project/docs", true)
+	term.FS.Touch("project/src/main.go")
+	term.FS.Touch("project/README")
+	term.FS.Touch("project/.env")
+
+	output, err := term.Execute("tree project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "project\n" +
+		"├── README\n" +
+		"├── docs\n" +
+		"└── src\n" +
+		"    └── main.go\n" +
+		"\n" +
+		"2 directories, 2 files"
+	if output != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, output)
+	}
+	if output, _ := term.Execute("tree -a project"); !strings.HasPrefix(output, "project\n├── .env\n") {
+		t.Errorf("tree -a should include dotfiles, got %q", output)
+	}
+	if _, err := term.Execute("tree missing"); err == nil {
+		t.Error("tree of a missing path should error")
+	}
+}
+
+func TestTreeJSON(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("project/src", true)
+	term.FS.Mkdir("project/docs", true)
+	term.FS.Touch("project/src/main.go")
+	term.FS.Touch("project/README")
+
+	output, err := term.Execute("tree -J project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree TreeNode
+	if err := json.Unmarshal([]byte(output), &tree); err != nil {
+		t.Fatalf("tree -J printed invalid JSON %q: %v", output, err)
+	}
+	expected := TreeNode{Name: "project", Type: "directory", Children: []*TreeNode{
+		{Name: "README", Type: "file"},
+		{Name: "docs", Type: "directory"},
+		{Name: "src", Type: "directory", Children: []*TreeNode{
+			{Name: "main.go", Type: "file"},
+		}},
+	}}
+	if !reflect.DeepEqual(tree, expected) {
+		t.Errorf("Expected %+v, got %s", expected, output)
+	}
+}
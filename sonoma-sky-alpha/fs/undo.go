@@ -0,0 +1,150 @@
+package fs
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultUndoDepth is the number of commands that can be undone
+const DefaultUndoDepth = 50
+
+// Operation is a recorded file system change that can be reverted
+type Operation interface {
+	Undo() error
+}
+
+// opGroup is the set of changes made by a single command, reverted in reverse order
+type opGroup []Operation
+
+func (g opGroup) Undo() error {
+	for i := len(g) - 1; i >= 0; i-- {
+		if err := g[i].Undo(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createOp reverts the creation of a node by detaching it from its parent
+type createOp struct {
+	node *VirtualFile
+}
+
+func (op createOp) Undo() error {
+	detach(op.node)
+	return nil
+}
+
+// removeOp reverts the removal of a node by reattaching it where it was
+type removeOp struct {
+	fs     *FileSystem
+	node   *VirtualFile
+	parent *VirtualFile
+	name   string
+}
+
+func (op removeOp) Undo() error {
+	if _, exists := op.parent.Children[op.name]; exists {
+		return fmt.Errorf("undo: cannot restore '%s': file exists", op.name)
+	}
+	op.fs.link(op.parent, op.name, op.node)
+
+	// The node is back in the tree, so it must no longer be restorable from the trash
+	for i, entry := range op.fs.Trash {
+		if entry.Node == op.node {
+			op.fs.Trash = append(op.fs.Trash[:i], op.fs.Trash[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// moveOp reverts a move or rename by putting the node back under its old parent and name
+type moveOp struct {
+	fs       *FileSystem
+	node     *VirtualFile
+	from     *VirtualFile
+	fromName string
+}
+
+func (op moveOp) Undo() error {
+	if existing, exists := op.from.Children[op.fromName]; exists && existing != op.node {
+		return fmt.Errorf("undo: cannot move back to '%s': file exists", op.fromName)
+	}
+	detach(op.node)
+	op.fs.link(op.from, op.fromName, op.node)
+	return nil
+}
+
+// contentOp reverts a content change by restoring the previous content
+type contentOp struct {
+	node    *VirtualFile
+	content []byte
+	size    int64
+	modTime time.Time
+}
+
+func newContentOp(file *VirtualFile) contentOp {
+	return contentOp{node: file, content: file.Content, size: file.Size, modTime: file.ModTime}
+}
+
+func (op contentOp) Undo() error {
+	op.node.Content = op.content
+	op.node.Size = op.size
+	op.node.ModTime = op.modTime
+	return nil
+}
+
+// detach removes node from its parent's children if it is still linked there
+func detach(node *VirtualFile) {
+	if node.Parent != nil && node.Parent.Children[node.Name] == node {
+		delete(node.Parent.Children, node.Name)
+	}
+}
+
+// record adds an operation to the journal of the command being tracked, if any.
+// Every recorded operation changes the tree, so cached resolutions are dropped too.
+func (fs *FileSystem) record(op Operation) {
+	fs.invalidate()
+	if fs.journal != nil {
+		*fs.journal = append(*fs.journal, op)
+	}
+}
+
+// attach links node into parent under name, recording the creation and any node it replaces
+func (fs *FileSystem) attach(parent *VirtualFile, name string, node *VirtualFile) {
+	if replaced, exists := parent.Children[name]; exists && replaced != node {
+		fs.record(removeOp{fs: fs, node: replaced, parent: parent, name: name})
+	}
+	fs.link(parent, name, node)
+	fs.record(createOp{node})
+}
+
+// Track runs fn as a single undoable step, recording the file system changes it makes
+func (t *Terminal) Track(fn func() (string, error)) (string, error) {
+	var ops []Operation
+	t.FS.journal = &ops
+	defer func() {
+		t.FS.journal = nil
+		if len(ops) == 0 || t.UndoDepth <= 0 {
+			return
+		}
+		t.UndoStack = append(t.UndoStack, opGroup(ops))
+		if over := len(t.UndoStack) - t.UndoDepth; over > 0 {
+			t.UndoStack = t.UndoStack[over:]
+		}
+	}()
+	return fn()
+}
+
+// Undo reverts the most recent tracked command
+func (t *Terminal) Undo() error {
+	n := len(t.UndoStack)
+	if n == 0 {
+		return fmt.Errorf("undo: nothing to undo")
+	}
+	op := t.UndoStack[n-1]
+	t.UndoStack = t.UndoStack[:n-1]
+	defer t.FS.invalidate()
+	return op.Undo()
+}
@@ -0,0 +1,129 @@
+package fs
+
+import (
+	"testing"
+)
+
+// run tracks a file system change the way the command loop does
+func run(term *Terminal, fn func() error) error {
+	_, err := term.Track(func() (string, error) {
+		return "", fn()
+	})
+	return err
+}
+
+func TestUndoCreate(t *testing.T) {
+	term := NewTerminal()
+	run(term, func() error { return term.FS.Touch("new.txt") })
+	run(term, func() error { return term.FS.Mkdir("a/b/c", true) })
+
+	err := term.Undo()
+	if err != nil {
+		t.Error(err)
+	}
+	if exists, _ := term.FS.Exists("a"); exists {
+		t.Error("undo of mkdir -p should remove every created directory")
+	}
+
+	err = term.Undo()
+	if err != nil {
+		t.Error(err)
+	}
+	if exists, _ := term.FS.Exists("new.txt"); exists {
+		t.Error("undo of touch should remove the created file")
+	}
+
+	if term.Undo() == nil {
+		t.Error("undo with an empty stack should error")
+	}
+}
+
+func TestUndoMove(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("dir", false)
+	term.FS.EchoWrite("one", "a.txt", false)
+	term.FS.EchoWrite("two", "b.txt", false)
+
+	run(term, func() error { return term.FS.Mv("a.txt", "dir/renamed.txt") })
+	run(term, func() error { return term.FS.Mv("dir/renamed.txt", "b.txt") })
+
+	// Undo the overwriting move: both files come back
+	err := term.Undo()
+	if err != nil {
+		t.Error(err)
+	}
+	output, _ := term.FS.Cat("b.txt")
+	if output != "two\n" {
+		t.Errorf("Overwritten file should be restored, got %q", output)
+	}
+	output, _ = term.FS.Cat("dir/renamed.txt")
+	if output != "one\n" {
+		t.Errorf("Moved file should be back at dir/renamed.txt, got %q", output)
+	}
+
+	err = term.Undo()
+	if err != nil {
+		t.Error(err)
+	}
+	output, err = term.FS.Cat("a.txt")
+	if err != nil || output != "one\n" {
+		t.Errorf("Undo should move the file back to a.txt, got %q (%v)", output, err)
+	}
+	if exists, _ := term.FS.Exists("dir/renamed.txt"); exists {
+		t.Error("dir/renamed.txt should no longer exist")
+	}
+}
+
+func TestUndoContentOverwrite(t *testing.T) {
+	term := NewTerminal()
+	term.FS.EchoWrite("original", "file.txt", false)
+
+	run(term, func() error { return term.FS.EchoWrite("replaced", "file.txt", false) })
+	run(term, func() error { return term.FS.EchoWrite("appended", "file.txt", true) })
+
+	term.Undo()
+	output, _ := term.FS.Cat("file.txt")
+	if output != "replaced\n" {
+		t.Errorf("Expected append to be undone, got %q", output)
+	}
+
+	term.Undo()
+	output, _ = term.FS.Cat("file.txt")
+	if output != "original\n" {
+		t.Errorf("Expected overwrite to be undone, got %q", output)
+	}
+}
+
+func TestUndoRemove(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("dir", false)
+	term.FS.Touch("dir/file.txt")
+
+	run(term, func() error { return term.FS.Rm("dir", true) })
+	err := term.Undo()
+	if err != nil {
+		t.Error(err)
+	}
+	if exists, _ := term.FS.Exists("dir/file.txt"); !exists {
+		t.Error("undo of rm -r should bring the tree back")
+	}
+	if len(term.FS.Trash) != 0 {
+		t.Error("An undone removal should leave the trash")
+	}
+}
+
+func TestUndoDepthAndExit(t *testing.T) {
+	term := NewTerminal()
+	term.UndoDepth = 2
+	for _, name := range []string{"a", "b", "c"} {
+		run(term, func() error { return term.FS.Touch(name) })
+	}
+	if len(term.UndoStack) != 2 {
+		t.Errorf("Undo stack should be capped at 2, got %d", len(term.UndoStack))
+	}
+
+	term.Exit()
+	if len(term.UndoStack) != 0 {
+		t.Error("Exiting the session should clear the undo stack")
+	}
+}
@@ -0,0 +1,89 @@
+package fs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WcCounts are what wc reports for one file
+type WcCounts struct {
+	Lines   int // Newlines in the content
+	Words   int // Runs of non-space characters
+	Bytes   int
+	MaxLine int // Runes in the longest line, not counting its newline
+}
+
+// WcOptions select the counts wc prints; with none selected it prints lines, words and bytes
+type WcOptions struct {
+	Lines   bool
+	Words   bool
+	Bytes   bool
+	MaxLine bool
+}
+
+// Wc counts the lines, words, bytes and longest line of the file at path
+func (fs *FileSystem) Wc(path string) (WcCounts, error) {
+	file, err := fs.ResolvePath(path)
+	if err != nil {
+		return WcCounts{}, fmt.Errorf("wc: %s: %v", path, err)
+	}
+	if file.Type != RegularFile {
+		return WcCounts{}, fmt.Errorf("wc: %s: not a file", path)
+	}
+	if err := fs.checkAccess(file, AccessRead, path); err != nil {
+		return WcCounts{}, fmt.Errorf("wc: %v", err)
+	}
+	return countText(file.Content), nil
+}
+
+// countText computes the wc counts of content
+func countText(content []byte) WcCounts {
+	text := string(content)
+	counts := WcCounts{
+		Lines: strings.Count(text, "\n"),
+		Words: len(strings.Fields(text)),
+		Bytes: len(content),
+	}
+	for _, line := range strings.Split(text, "\n") {
+		counts.MaxLine = max(counts.MaxLine, utf8.RuneCountInString(line))
+	}
+	return counts
+}
+
+// Add returns the totals of c and other, where the longest line is the longer of the two
+func (c WcCounts) Add(other WcCounts) WcCounts {
+	return WcCounts{
+		Lines:   c.Lines + other.Lines,
+		Words:   c.Words + other.Words,
+		Bytes:   c.Bytes + other.Bytes,
+		MaxLine: max(c.MaxLine, other.MaxLine),
+	}
+}
+
+// Format returns the counts selected by opts, in the order lines, words, bytes and longest
+// line, followed by name unless it is empty
+func (c WcCounts) Format(opts WcOptions, name string) string {
+	if !opts.Lines && !opts.Words && !opts.Bytes && !opts.MaxLine {
+		opts = WcOptions{Lines: true, Words: true, Bytes: true}
+	}
+	var fields []string
+	for _, col := range []struct {
+		selected bool
+		value    int
+	}{
+		{opts.Lines, c.Lines},
+		{opts.Words, c.Words},
+		{opts.Bytes, c.Bytes},
+		{opts.MaxLine, c.MaxLine},
+	} {
+		if col.selected {
+			fields = append(fields, strconv.Itoa(col.value))
+		}
+	}
+	if name != "" {
+		fields = append(fields, name)
+	}
+	return strings.Join(fields, " ")
+}
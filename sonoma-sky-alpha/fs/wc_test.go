@@ -0,0 +1,54 @@
+package fs
+
+import "testing"
+
+func TestWc(t *testing.T) {
+	term := NewTerminal()
+	term.FS.EchoWrite("one two\nthree", "ascii.txt", false)
+
+	output, err := term.Execute("wc ascii.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != "2 3 14 ascii.txt" {
+		t.Errorf("Expected lines, words and bytes, got %q", output)
+	}
+	if output, _ := term.Execute("wc -l ascii.txt"); output != "2 ascii.txt" {
+		t.Errorf("wc -l: unexpected %q", output)
+	}
+	if _, err := term.Execute("wc"); err == nil {
+		t.Error("wc without a file should error")
+	}
+}
+
+func TestWcLongestLine(t *testing.T) {
+	term := NewTerminal()
+	term.FS.EchoWrite("short\na longer line\nmid", "ascii.txt", false)
+	term.FS.EchoWrite("héllo wörld\nこんにちは世界です", "multi.txt", false)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"wc -L ascii.txt", "13 ascii.txt"},
+		// The second line has more bytes (27) but fewer runes (9) than the first
+		{"wc -L multi.txt", "11 multi.txt"},
+		{"wc -c -L multi.txt", "42 11 multi.txt"},
+		{"wc -L ascii.txt multi.txt", "13 ascii.txt\n11 multi.txt\n13 total"},
+	}
+	for _, tt := range tests {
+		output, err := term.Execute(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if output != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, output)
+		}
+	}
+
+	counts := countText([]byte("日本語\nab\n"))
+	if counts.MaxLine != 3 || counts.Bytes != 13 {
+		t.Errorf("Expected a longest line of 3 runes in 13 bytes, got %+v", counts)
+	}
+}
@@ -0,0 +1,68 @@
+package fs
+
+import "testing"
+
+func TestPipelineXargs(t *testing.T) {
+	term := NewTerminal()
+	fs := term.FS
+	for _, name := range []string{"a.tmp", "b.tmp", "keep.txt"} {
+		fs.Touch(name)
+	}
+
+	if _, _, err := term.RunCommand("ls *.tmp | xargs rm"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.tmp", "b.tmp"} {
+		if exists, _ := fs.Exists(name); exists {
+			t.Errorf("xargs rm should have removed %s", name)
+		}
+	}
+	if exists, _ := fs.Exists("keep.txt"); !exists {
+		t.Error("xargs rm should only remove the piped names")
+	}
+
+	// The whole pipeline is one undo step
+	term.RunCommand("undo")
+	if exists, _ := fs.Exists("b.tmp"); !exists {
+		t.Error("undo should restore every file the pipeline removed")
+	}
+}
+
+func TestXargsBatches(t *testing.T) {
+	term := NewTerminal()
+	term.FS.Mkdir("d", false)
+
+	// echo prints its arguments, so each batch shows up as one line
+	stdout, _, err := term.RunCommand("echo a b c d e | xargs -n 2 echo run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "run a b\nrun c d\nrun e\n"; stdout != want {
+		t.Errorf("xargs -n 2: expected %q, got %q", want, stdout)
+	}
+
+	stdout, _, _ = term.RunCommand("echo a b c | xargs echo")
+	if stdout != "a b c\n" {
+		t.Errorf("xargs without -n should pass every word at once, got %q", stdout)
+	}
+
+	// A failing batch is reported without stopping the rest
+	_, _, err = term.RunCommand("echo new d/x | xargs -n 1 touch")
+	if err != nil {
+		t.Errorf("touch of both names should succeed, got %v", err)
+	}
+	_, _, err = term.RunCommand("echo nope d/x | xargs -n 1 rm")
+	if err == nil {
+		t.Error("xargs should report the failed rm of nope")
+	}
+	if exists, _ := term.FS.Exists("d/x"); exists {
+		t.Error("xargs should go on to remove d/x after the failed batch")
+	}
+
+	if _, _, err := term.RunCommand("ls | "); err == nil {
+		t.Error("an empty pipeline stage should be a syntax error")
+	}
+	if _, _, err := term.RunCommand("echo x | xargs -n 0 echo"); err == nil {
+		t.Error("-n 0 should be rejected")
+	}
+}
@@ -1,173 +1,89 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
-	"strings"
+	"os/signal"
+	"strconv"
 
 	"terminal-emulator/fs"
 )
 
 func main() {
+	user := flag.String("user", fs.DefaultUser, "name of the current user")
+	root := flag.Bool("root", false, "run as root, bypassing permission checks")
+	trashSize := flag.Int("trash-size", fs.DefaultTrashLimit, "number of rm'd entries kept for restore (0 disables)")
+	maxOutput := flag.Int("max-output", defaultMaxOutput(), "bytes cat prints before truncating, 0 disables (env TERM_MAX_OUTPUT)")
+	maxDepth := flag.Int("max-depth", fs.DefaultMaxDepth, "components a path may have before it is rejected, 0 disables")
+	resolveCache := flag.Int("resolve-cache", fs.DefaultResolveCacheSize, "number of resolved paths cached, 0 disables")
+	prompt := flag.String("prompt", fs.DefaultPrompt, `prompt format (\u = user, \w = working directory)`)
+	noANSI := flag.Bool("no-ansi", os.Getenv("TERM") == "dumb", "print no escape sequences (default when TERM=dumb)")
+	cdHint := flag.Bool("cd-hint", false, "make cd to a file suggest the directory holding it")
+	ignoreEOF := flag.Int("ignore-eof", defaultIgnoreEOF(), "number of Ctrl-Ds in a row ignored before one exits (env IGNOREEOF)")
+	flag.Parse()
+	if *root {
+		*user = fs.RootUser
+	}
+
 	t := fs.NewTerminal()
+	t.Prompt = *prompt
+	t.NoANSI = *noANSI
+	t.IgnoreEOF = *ignoreEOF
+	t.FS.TrashLimit = *trashSize
+	t.FS.MaxOutput = *maxOutput
+	t.FS.MaxDepth = *maxDepth
+	t.FS.CdHint = *cdHint
+	t.FS.SetResolveCache(*resolveCache)
+	if *user != t.User {
+		if err := t.SetUser(*user); err != nil {
+			fmt.Fprintln(t.Err, "Error:", err)
+			os.Exit(1)
+		}
+		t.FS.Cd("~")
+	}
 
-	for t.Running {
-		prompt := t.FS.GetPath(t.FS.CurrentDir) + "$ "
-		fmt.Print(prompt)
+	// Ctrl-C stops the running command rather than the terminal
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+			t.Interrupt()
+		}
+	}()
 
-		reader := bufio.NewReader(os.Stdin)
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			if err.Error() == "EOF" {
-				break
-			}
-			fmt.Println("Error reading input:", err)
-			continue
-		}
-		input = strings.TrimSpace(input)
-
-		if input == "" {
-			continue
+	// Typing at a terminal is read a keystroke at a time, so Ctrl-R can search the history
+	restore := func() {}
+	if !t.NoANSI {
+		if r, err := rawInput(); err == nil {
+			t.RawInput, restore = true, r
 		}
+	}
 
-		cmd, args, err := fs.ParseCommand(input)
-		if err != nil {
-			fmt.Println("Error parsing command:", err)
-			continue
-		}
+	t.Run()
+	restore()
+	os.Exit(t.Status)
+}
 
-		output, err := executeCommand(t, cmd, args)
-		if output != "" {
-			fmt.Println(output)
-		}
-		if err != nil {
-			fmt.Println("Error:", err.Error())
+// defaultMaxOutput reads the cat output limit from TERM_MAX_OUTPUT, falling back to fs.DefaultMaxOutput
+func defaultMaxOutput() int {
+	if value, ok := os.LookupEnv("TERM_MAX_OUTPUT"); ok {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			return n
 		}
 	}
+	return fs.DefaultMaxOutput
 }
 
-func executeCommand(t *fs.Terminal, cmd string, args []string) (string, error) {
-	switch cmd {
-	case "pwd":
-		return t.FS.Pwd(), nil
-	case "cd":
-		if len(args) == 0 {
-			return "", fmt.Errorf("cd: missing path")
-		}
-		return "", t.FS.Cd(args[0])
-	case "mkdir":
-		if len(args) == 0 {
-			return "", fmt.Errorf("mkdir: missing operand")
-		}
-		parents := false
-		path := args[0]
-		if path == "-p" && len(args) > 1 {
-			parents = true
-			path = args[1]
-		}
-		return "", t.FS.Mkdir(path, parents)
-	case "touch":
-		if len(args) == 0 {
-			return "", fmt.Errorf("touch: missing operand")
-		}
-		return "", t.FS.Touch(args[0])
-	case "ls":
-		path := "."
-		long := false
-		all := false
-		if len(args) > 0 {
-			path = args[0]
-		}
-		// Simple flag parsing, assume flags are separate args
-		for _, arg := range args {
-			if arg == "-l" {
-				long = true
-			}
-			if arg == "-a" {
-				all = true
-			}
-		}
-		return t.FS.Ls(path, long, all)
-	case "rm":
-		if len(args) == 0 {
-			return "", fmt.Errorf("rm: missing operand")
-		}
-		recursive := false
-		path := args[0]
-		if path == "-r" && len(args) > 1 {
-			recursive = true
-			path = args[1]
-		}
-		return "", t.FS.Rm(path, recursive)
-	case "rmdir":
-		if len(args) == 0 {
-			return "", fmt.Errorf("rmdir: missing operand")
-		}
-		return "", t.FS.Rmdir(args[0])
-	case "cp":
-		if len(args) < 2 {
-			return "", fmt.Errorf("cp: missing file operand")
-		}
-		recursive := false
-		src := args[0]
-		dest := args[1]
-		if src == "-r" && len(args) > 2 {
-			recursive = true
-			src = args[1]
-			dest = args[2]
-		}
-		return "", t.FS.Cp(src, dest, recursive)
-	case "mv":
-		if len(args) < 2 {
-			return "", fmt.Errorf("mv: missing file operand")
-		}
-		return "", t.FS.Mv(args[0], args[1])
-	case "cat":
-		if len(args) == 0 {
-			return "", fmt.Errorf("cat: missing operand")
-		}
-		return t.FS.Cat(args[0])
-	case "echo":
-		if len(args) == 0 {
-			fmt.Println("")
-			return "", nil
-		}
-		// Handle redirection
-		if len(args) == 1 {
-			fmt.Println(args[0])
-			return "", nil
-		}
-		// Assume last arg is filename with possible redirection
-		filename := args[len(args)-1]
-		text := strings.Join(args[:len(args)-1], " ")
-		appendMode := false
-		if strings.HasSuffix(filename, ">>") {
-			filename = strings.TrimSuffix(filename, ">>")
-			appendMode = true
-		} else if strings.HasSuffix(filename, ">") {
-			filename = strings.TrimSuffix(filename, ">")
-			appendMode = false
-		} else {
-			// No redirection, print
-			fmt.Println(text)
-			return "", nil
-		}
-		return "", t.FS.EchoWrite(text, filename, appendMode)
-	case "edit":
-		if len(args) == 0 {
-			return "", fmt.Errorf("edit: missing operand")
-		}
-		return "", t.Edit(args[0])
-	case "clear":
-		t.Clear()
-		return "", nil
-	case "exit", "quit":
-		t.Exit()
-		return "", nil
-	case "help":
-		return t.Help(), nil
-	default:
-		return "", fmt.Errorf("command not found: %s", cmd)
+// defaultIgnoreEOF reads the number of Ctrl-Ds to ignore from IGNOREEOF. As in bash, a value
+// that is not a number counts as 10.
+func defaultIgnoreEOF() int {
+	value, ok := os.LookupEnv("IGNOREEOF")
+	if !ok {
+		return 0
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+		return n
 	}
+	return 10
 }
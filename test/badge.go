@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Badge is a shields.io endpoint response, see https://shields.io/badges/endpoint-badge
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// NewBadge returns the badge for the overall pass rate of summary: green from 80%, yellow
+// from 50% and red below
+func NewBadge(summary TestSummary) Badge {
+	rate := 0.0
+	if summary.TotalTests > 0 {
+		rate = float64(summary.TotalPassed) / float64(summary.TotalTests) * 100
+	}
+	color := "red"
+	switch {
+	case rate >= 80:
+		color = "green"
+	case rate >= 50:
+		color = "yellow"
+	}
+	return Badge{
+		SchemaVersion: 1,
+		Label:         "tests",
+		Message:       fmt.Sprintf("%d%%", int(math.Floor(rate))),
+		Color:         color,
+	}
+}
+
+// GenerateBadgeJSON writes the badge for summary as shields.io endpoint JSON
+func GenerateBadgeJSON(summary TestSummary, outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %v", err)
+	}
+	data, err := json.Marshal(NewBadge(summary))
+	if err != nil {
+		return fmt.Errorf("failed to encode badge: %v", err)
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write badge file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateBadgeJSON(t *testing.T) {
+	tests := []struct {
+		passed, total int
+		expected      string
+	}{
+		{4, 4, `{"schemaVersion":1,"label":"tests","message":"100%","color":"green"}`},
+		{2, 4, `{"schemaVersion":1,"label":"tests","message":"50%","color":"yellow"}`},
+		{0, 4, `{"schemaVersion":1,"label":"tests","message":"0%","color":"red"}`},
+		{0, 0, `{"schemaVersion":1,"label":"tests","message":"0%","color":"red"}`},
+		{199, 200, `{"schemaVersion":1,"label":"tests","message":"99%","color":"green"}`},
+	}
+	for _, tt := range tests {
+		summary := TestSummary{TotalTests: tt.total, TotalPassed: tt.passed, TotalFailed: tt.total - tt.passed}
+		path := filepath.Join(t.TempDir(), "reports", "badge.json")
+		if err := GenerateBadgeJSON(summary, path); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); got != tt.expected+"\n" {
+			t.Errorf("%d/%d: expected %s, got %s", tt.passed, tt.total, tt.expected, got)
+		}
+	}
+}
@@ -14,11 +14,13 @@ type Config struct {
 	Variants     VariantConfig     `toml:"variants"`
 	TestSettings TestSettingsConfig `toml:"test_settings"`
 	Paths        PathsConfig       `toml:"paths"`
+	Output       OutputConfig      `toml:"output"`
 }
 
 // VariantConfig contains the list of variants to test
 type VariantConfig struct {
-	Names []string `toml:"names"`
+	Names        []string `toml:"names"`
+	AutoDiscover bool     `toml:"auto_discover"` // Test every variant directory next to the test directory instead of Names
 }
 
 // TestSettingsConfig contains test execution settings
@@ -30,8 +32,10 @@ type TestSettingsConfig struct {
 // PathsConfig contains directory paths
 type PathsConfig struct {
 	BinDir     string `toml:"bin_dir"`
+	SourceDir  string `toml:"source_dir"` // Directory holding the variants' source directories
 	TempDir    string `toml:"temp_dir"`
 	ReportsDir string `toml:"reports_dir"`
+	GoldenDir  string `toml:"golden_dir"`
 }
 
 // LoadConfig loads the configuration from config.toml
@@ -67,6 +71,23 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file %s: %v", configFile, err)
 	}
 
+	if err := config.Output.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid [output] in %s: %v", configFile, err)
+	}
+
+	if config.Variants.AutoDiscover {
+		// The variants live next to the test directory holding config.toml
+		configPath, err := filepath.Abs(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve config file %s: %v", configFile, err)
+		}
+		names, err := DiscoverVariants(filepath.Dir(filepath.Dir(configPath)))
+		if err != nil {
+			return nil, err
+		}
+		config.Variants.Names = names
+	}
+
 	return &config, nil
 }
 
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates the given files, with empty content, under dir
+func writeTree(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiscoverVariants(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir,
+		"alpha/go.mod",
+		"alpha/main.go",
+		"beta/main.go",
+		"docs/README.md",
+		"empty/.keep",
+		"nested/sub/main.go",
+		"test/go.mod",
+		"main.go",
+	)
+
+	names, err := DiscoverVariants(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alpha", "beta"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+
+	if _, err := DiscoverVariants(filepath.Join(dir, "docs")); err == nil {
+		t.Error("Expected an error when no variant is found")
+	}
+}
+
+func TestLoadConfigAutoDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "gamma/go.mod", "delta/main.go", "test/go.mod")
+	config := "[variants]\nnames = [\"listed\"]\nauto_discover = true\n"
+	if err := os.WriteFile(filepath.Join(dir, "test", "config.toml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "test")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"delta", "gamma"}; !reflect.DeepEqual(loaded.Variants.Names, want) {
+		t.Errorf("Expected discovered variants %v, got %v", want, loaded.Variants.Names)
+	}
+}
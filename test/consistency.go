@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ConsistencyReport compares the output of every command across variants
+type ConsistencyReport struct {
+	Variants []string
+	Rows     []CommandAgreement
+}
+
+// CommandAgreement is one row of the agreement matrix
+type CommandAgreement struct {
+	TestID  string
+	Command string
+	Outputs []string // Output of each variant, in ConsistencyReport.Variants order
+	Agree   bool
+}
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// normalizeOutput strips ANSI escapes and whitespace differences that aren't spec violations
+func normalizeOutput(output string) string {
+	output = ansiPattern.ReplaceAllString(output, "")
+	return strings.Join(strings.Fields(output), " ")
+}
+
+// BuildConsistency lines up the per-command output of tests that ran on every built variant
+// and marks the commands whose normalized output differs between variants
+func BuildConsistency(variants []VariantResults) *ConsistencyReport {
+	report := &ConsistencyReport{}
+	byVariant := make(map[string]map[string]TestResult)
+	for _, variant := range variants {
+		if !variant.BuildSuccess {
+			continue
+		}
+		report.Variants = append(report.Variants, variant.Name)
+		results := make(map[string]TestResult)
+		for _, result := range variant.TestResults {
+			results[result.TestCase.ID] = result
+		}
+		byVariant[variant.Name] = results
+	}
+	if len(report.Variants) < 2 {
+		return report
+	}
+
+	// Walk tests in the order the first variant ran them
+	var order []TestResult
+	for _, variant := range variants {
+		if variant.Name == report.Variants[0] {
+			order = variant.TestResults
+			break
+		}
+	}
+
+	for _, result := range order {
+		testCase := result.TestCase
+		for i, command := range testCase.Commands {
+			row := CommandAgreement{TestID: testCase.ID, Command: command, Agree: true}
+			complete := true
+			for _, name := range report.Variants {
+				other, ok := byVariant[name][testCase.ID]
+				if !ok {
+					complete = false
+					break
+				}
+				output := ""
+				if i < len(other.Output) {
+					output = other.Output[i]
+				}
+				row.Outputs = append(row.Outputs, output)
+				if normalizeOutput(output) != normalizeOutput(row.Outputs[0]) {
+					row.Agree = false
+				}
+			}
+			if complete {
+				report.Rows = append(report.Rows, row)
+			}
+		}
+	}
+	return report
+}
+
+// Disagreements returns the rows where variants produced different output
+func (r *ConsistencyReport) Disagreements() []CommandAgreement {
+	var rows []CommandAgreement
+	for _, row := range r.Rows {
+		if !row.Agree {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// Print writes the commands the variants disagree on to the console
+func (r *ConsistencyReport) Print() {
+	disagreements := r.Disagreements()
+	fmt.Printf(" CONSISTENCY: %d of %d commands agree across %d variants\n",
+		len(r.Rows)-len(disagreements), len(r.Rows), len(r.Variants))
+	for _, row := range disagreements {
+		fmt.Printf("  [DIFF] %s `%s`\n", row.TestID, row.Command)
+		for i, name := range r.Variants {
+			fmt.Printf("    %-24s %q\n", name, row.Outputs[i])
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// rootVariant behaves like echoVariant but disagrees on pwd
+var rootVariant = strings.Replace(echoVariant, "pwd) echo /home/user", "pwd) echo /root", 1)
+
+func TestConsistencyReportsDisagreement(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"home": echoVariant, "root": rootVariant})
+	config.Variants.Names = []string{"home", "root"}
+
+	results := RunSuite(config, RunOptions{Filter: regexp.MustCompile(`1\.1\.2`)})
+	report := BuildConsistency(results)
+
+	if len(report.Variants) != 2 || len(report.Rows) != 2 {
+		t.Fatalf("Expected 2 variants and 2 command rows, got %+v", report)
+	}
+	if cd := report.Rows[0]; cd.Command != "cd /home" || !cd.Agree {
+		t.Errorf("Variants should agree on cd, got %+v", cd)
+	}
+	if pwd := report.Rows[1]; pwd.Command != "pwd" || pwd.Agree {
+		t.Errorf("Variants should disagree on pwd, got %+v", pwd)
+	}
+
+	disagreements := report.Disagreements()
+	if len(disagreements) != 1 || disagreements[0].TestID != "1.1.2" {
+		t.Fatalf("Expected a single disagreement on 1.1.2, got %+v", disagreements)
+	}
+	if disagreements[0].Outputs[0] != "/home/user" || disagreements[0].Outputs[1] != "/root" {
+		t.Errorf("Disagreement should carry each variant's output, got %v", disagreements[0].Outputs)
+	}
+
+	summary := CalculateSummary(results)
+	summary.Consistency = report
+	path := filepath.Join(config.Paths.ReportsDir, "report.html")
+	if err := GenerateHTMLReport(summary, path); err != nil {
+		t.Fatal(err)
+	}
+	html, _ := os.ReadFile(path)
+	if !strings.Contains(string(html), "Cross-Variant Agreement") {
+		t.Error("Report should include the agreement matrix")
+	}
+}
+
+func TestNormalizeOutputIgnoresFormatting(t *testing.T) {
+	if normalizeOutput("\x1b[1;34mdocs\x1b[0m  notes.txt\n") != normalizeOutput("docs notes.txt") {
+		t.Error("ANSI colors and spacing should not count as disagreement")
+	}
+	if normalizeOutput("docs") == normalizeOutput("Docs") {
+		t.Error("Different text should still disagree")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// csvHeader matches the columns of the detailed results table in the HTML report
+var csvHeader = []string{"Test ID", "Category", "Description", "Commands", "Variant", "Status", "Duration", "Error"}
+
+// GenerateCSVReport writes every test result as one row of a CSV file, for CI jobs that
+// can't use the HTML report. A test's commands share one field, separated by newlines.
+func GenerateCSVReport(summary TestSummary, outputPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %v", err)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %v", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write(csvHeader)
+	for _, variant := range summary.Variants {
+		for _, result := range variant.TestResults {
+			status := "PASS"
+			if !result.Passed {
+				status = "FAIL"
+			}
+			w.Write([]string{
+				result.TestCase.ID,
+				result.TestCase.Category,
+				result.TestCase.Description,
+				strings.Join(result.TestCase.Commands, "\n"),
+				result.Variant,
+				status,
+				result.Duration.String(),
+				result.Error,
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write report file: %v", err)
+	}
+	return file.Close()
+}
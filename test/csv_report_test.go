@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenerateCSVReport(t *testing.T) {
+	passed := TestResult{
+		TestCase: TestCase{ID: "2.3.1", Category: "File Ops", Description: "Copy file, then list", Commands: []string{"cp a b", "ls"}},
+		Variant:  "alpha",
+		Passed:   true,
+		Duration: 12 * time.Millisecond,
+	}
+	failed := passed
+	failed.Variant = "beta"
+	failed.Passed = false
+	failed.Error = "Validation failed: expected 'a, b', got \"a\"\nand more"
+	summary := CalculateSummary([]VariantResults{
+		{Name: "alpha", TestResults: []TestResult{passed}, TotalTests: 1},
+		{Name: "beta", TestResults: []TestResult{failed, passed}, TotalTests: 2},
+	})
+
+	path := filepath.Join(t.TempDir(), "reports", "test_report.csv")
+	if err := GenerateCSVReport(summary, path); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("The report should parse as CSV: %v", err)
+	}
+
+	if len(records)-1 != summary.TotalTests {
+		t.Fatalf("Expected a header and %d rows, got %d records", summary.TotalTests, len(records))
+	}
+	if !reflect.DeepEqual(records[0], csvHeader) {
+		t.Errorf("Unexpected header %v", records[0])
+	}
+	want := []string{"2.3.1", "File Ops", "Copy file, then list", "cp a b\nls", "beta", "FAIL", "12ms", failed.Error}
+	if !reflect.DeepEqual(records[2], want) {
+		t.Errorf("Expected %q, got %q", want, records[2])
+	}
+}
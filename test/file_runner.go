@@ -1,10 +1,16 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,12 +24,19 @@ type FileBasedTerminal struct {
 	WorkDir        string
 	InputFile      string
 	OutputFile     string
+
+	filter *outputFilter // Tells command output apart from banners and prompts
 }
 
 // NewFileBasedTerminal creates a new file-based terminal tester
 func NewFileBasedTerminal(executablePath string) (*FileBasedTerminal, error) {
 	name := filepath.Base(strings.TrimSuffix(executablePath, ".exe"))
-	workDir := filepath.Join("temp", name)
+	return newFileBasedTerminalIn(executablePath, filepath.Join("temp", name))
+}
+
+// newFileBasedTerminalIn creates a file-based terminal tester that works in workDir
+func newFileBasedTerminalIn(executablePath, workDir string) (*FileBasedTerminal, error) {
+	name := filepath.Base(strings.TrimSuffix(executablePath, ".exe"))
 
 	// Create work directory
 	if err := os.MkdirAll(workDir, 0755); err != nil {
@@ -36,6 +49,7 @@ func NewFileBasedTerminal(executablePath string) (*FileBasedTerminal, error) {
 		WorkDir:        workDir,
 		InputFile:      filepath.Join(workDir, "input.txt"),
 		OutputFile:     filepath.Join(workDir, "output.txt"),
+		filter:         defaultFilter,
 	}, nil
 }
 
@@ -94,7 +108,7 @@ func (fbt *FileBasedTerminal) ExecuteCommand(command string, timeout time.Durati
 
 		cleaned := fbt.cleanOutput(string(output))
 		if err != nil {
-			return cleaned, fmt.Errorf("command failed: %v", err)
+			return cleaned, fmt.Errorf("command failed: %w", err)
 		}
 
 		return cleaned, nil
@@ -183,13 +197,41 @@ func transformForSky(commands []string) []string {
 
 // ExecuteCommands executes multiple commands in sequence
 func (fbt *FileBasedTerminal) ExecuteCommands(commands []string, timeout time.Duration) ([]string, error) {
-	// Transform commands based on variant capabilities
+	// Transform commands based on variant capabilities, echoing a delimiter between commands
+	// so each command's output can be told apart
 	variantName := filepath.Base(strings.TrimSuffix(fbt.ExecutablePath, ".exe"))
-	transformedCommands := transformCommandsForVariant(variantName, commands)
+	var transformedCommands []string
+	for i, command := range commands {
+		transformedCommands = append(transformedCommands, transformCommandsForVariant(variantName, []string{command})...)
+		if i < len(commands)-1 {
+			transformedCommands = append(transformedCommands, "echo "+commandDelimiter(i))
+		}
+	}
 
 	// Generate input with special handling for edit commands
 	input := fbt.generateInputForCommands(transformedCommands)
 
+	output, err := fbt.runSession(input, timeout)
+	if output == nil {
+		return make([]string, len(commands)), err
+	}
+
+	// Parse output into individual command responses
+	return fbt.parseMultiCommandOutput(string(output), len(commands)), err
+}
+
+// ExecuteSession runs commands in one terminal session and returns the cleaned transcript
+func (fbt *FileBasedTerminal) ExecuteSession(commands []string, timeout time.Duration) (string, error) {
+	variantName := filepath.Base(strings.TrimSuffix(fbt.ExecutablePath, ".exe"))
+	input := fbt.generateInputForCommands(transformCommandsForVariant(variantName, commands))
+
+	output, err := fbt.runSession(input, timeout)
+	return fbt.cleanOutput(string(output)), err
+}
+
+// runSession pipes input into a fresh terminal process and returns everything it printed.
+// The output is nil if nothing could be read.
+func (fbt *FileBasedTerminal) runSession(input string, timeout time.Duration) ([]byte, error) {
 	// Clean output file
 	os.Remove(fbt.OutputFile)
 
@@ -227,14 +269,11 @@ func (fbt *FileBasedTerminal) ExecuteCommands(commands []string, timeout time.Du
 			return nil, fmt.Errorf("failed to read output: %v", readErr)
 		}
 
-		// Parse output into individual command responses
-		results := fbt.parseMultiCommandOutput(string(output), len(commands))
-
 		if err != nil {
-			return results, fmt.Errorf("command failed: %v", err)
+			return output, fmt.Errorf("command failed: %w", err)
 		}
 
-		return results, nil
+		return output, nil
 
 	case <-time.After(timeout):
 		if cmd.Process != nil {
@@ -243,10 +282,9 @@ func (fbt *FileBasedTerminal) ExecuteCommands(commands []string, timeout time.Du
 
 		// Try to read partial output
 		if output, err := os.ReadFile(absOutput); err == nil {
-			results := fbt.parseMultiCommandOutput(string(output), len(commands))
-			return results, fmt.Errorf("timeout after %v", timeout)
+			return output, fmt.Errorf("timeout after %v", timeout)
 		}
-		return make([]string, len(commands)), fmt.Errorf("timeout after %v", timeout)
+		return nil, fmt.Errorf("timeout after %v", timeout)
 	}
 }
 
@@ -352,53 +390,67 @@ func (fbt *FileBasedTerminal) parseDefaultOutput(output string, numCommands int)
 	// Split raw output into lines first without cleaning
 	lines := strings.Split(output, "\n")
 
-	// Process each line and separate prompts from actual output
-	var actualOutput []string
+	// Sort the lines into one segment per command, starting a new one at each delimiter
+	segments := make([][]string, numCommands)
+	current := 0
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+		for {
+			loc := commandDelimiterPattern.FindStringSubmatchIndex(line)
+			if loc == nil {
+				break
+			}
+			segments[current] = fbt.appendOutputLine(segments[current], line[:loc[0]])
+			if n, err := strconv.Atoi(line[loc[2]:loc[3]]); err == nil && n > current && n < numCommands {
+				current = n
+			}
+			line = line[loc[1]:]
 		}
+		segments[current] = fbt.appendOutputLine(segments[current], line)
+	}
 
-		// Skip startup messages and terminal noise
-		if strings.Contains(line, "Welcome to") ||
-			strings.Contains(line, "Type 'help'") ||
-			strings.Contains(line, "Terminal Emulator") ||
-			strings.Contains(line, "session ended") ||
-			strings.Contains(line, "available commands") ||
-			strings.Contains(line, "Virtual Terminal") {
-			continue
-		}
+	return fbt.distributeOutput(segments, numCommands)
+}
 
-		// Skip prompts - identify by pattern: "/home/user$" with no spaces after $
-		if strings.HasSuffix(line, "$") && strings.Contains(line, "/home/user") && !strings.Contains(strings.TrimPrefix(line, "/home/user"), " ") {
-			continue // This is just a prompt
-		}
+// appendOutputLine adds what line holds of a command's output to output, separating prompts
+// and terminal noise from actual output
+func (fbt *FileBasedTerminal) appendOutputLine(output []string, line string) []string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return output
+	}
 
-		// Handle lines that contain prompts followed by actual output
-		if strings.Contains(line, "/home/user$") {
-			// Split by prompt and extract the last non-empty part as actual output
-			parts := strings.Split(line, "/home/user$")
-			var extractedOutput string
-			for i := len(parts) - 1; i >= 0; i-- {
-				trimmed := strings.TrimSpace(parts[i])
-				if trimmed != "" {
-					extractedOutput = trimmed
-					break
-				}
-			}
-			// Only add to output if it's not a command echo
-			// Command echoes typically contain command names like "touch", "ls", "mkdir", etc.
-			if extractedOutput != "" && !isCommandEcho(extractedOutput) {
-				actualOutput = append(actualOutput, extractedOutput)
+	// Skip startup messages and terminal noise
+	if fbt.filter.isNoise(line) {
+		return output
+	}
+
+	// Skip lines holding nothing but prompts
+	if fbt.filter.isPromptOnly(line) {
+		return output
+	}
+
+	// Handle lines that contain prompts followed by actual output
+	if fbt.filter.hasPrompt(line) {
+		// Split by prompt and extract the last non-empty part as actual output
+		parts := fbt.filter.splitPrompts(line)
+		var extractedOutput string
+		for i := len(parts) - 1; i >= 0; i-- {
+			trimmed := strings.TrimSpace(parts[i])
+			if trimmed != "" {
+				extractedOutput = trimmed
+				break
 			}
-		} else {
-			// This is actual command output without prompts
-			actualOutput = append(actualOutput, line)
 		}
+		// Only add to output if it's not a command echo
+		// Command echoes typically contain command names like "touch", "ls", "mkdir", etc.
+		if extractedOutput != "" && !isCommandEcho(extractedOutput) {
+			output = append(output, extractedOutput)
+		}
+		return output
 	}
 
-	return fbt.distributeOutput(actualOutput, numCommands)
+	// This is actual command output without prompts
+	return append(output, line)
 }
 
 // parseDusk1Output handles dusk1-specific output format
@@ -416,30 +468,24 @@ func (fbt *FileBasedTerminal) parseSkyOutput(output string, numCommands int) []s
 	return fbt.parseDefaultOutput(output, numCommands)
 }
 
-// distributeOutput distributes parsed output across commands
-func (fbt *FileBasedTerminal) distributeOutput(actualOutput []string, numCommands int) []string {
+// distributeOutput maps each command's segment of output lines to its output
+func (fbt *FileBasedTerminal) distributeOutput(segments [][]string, numCommands int) []string {
 	results := make([]string, numCommands)
-
-	// Distribute the actual output across commands
-	// For simple cases where we have one output per command
-	if len(actualOutput) == numCommands {
-		for i := 0; i < numCommands && i < len(actualOutput); i++ {
-			results[i] = actualOutput[i]
-		}
-	} else if len(actualOutput) == 1 && numCommands > 1 {
-		// If we have one output but multiple commands, assume it belongs to the last command
-		// This handles cases like: touch file.txt; ls -> "file.txt" output belongs to ls
-		results[numCommands-1] = actualOutput[0]
-	} else {
-		// Default distribution
-		for i := 0; i < numCommands && i < len(actualOutput); i++ {
-			results[i] = actualOutput[i]
-		}
+	for i := 0; i < numCommands && i < len(segments); i++ {
+		results[i] = strings.Join(segments[i], "\n")
 	}
-
 	return results
 }
 
+// commandDelimiterPattern matches a delimiter echoed between commands, capturing the number
+// of the command that follows it
+var commandDelimiterPattern = regexp.MustCompile(`__end_of_command_(\d+)__`)
+
+// commandDelimiter returns the delimiter echoed after command i and before command i+1
+func commandDelimiter(i int) string {
+	return fmt.Sprintf("__end_of_command_%d__", i+1)
+}
+
 // cleanOutput cleans terminal output
 func (fbt *FileBasedTerminal) cleanOutput(output string) string {
 	lines := strings.Split(output, "\n")
@@ -448,18 +494,13 @@ func (fbt *FileBasedTerminal) cleanOutput(output string) string {
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 
-		// Skip empty lines and terminal noise
+		// Skip empty lines, terminal noise and prompts
 		if line == "" ||
-			strings.Contains(line, "Terminal Emulator") ||
-			strings.Contains(line, "Type 'help'") ||
-			strings.Contains(line, "Welcome to") ||
-			strings.Contains(line, "Goodbye") ||
-			strings.Contains(line, "session ended") ||
-			strings.Contains(line, "available commands") ||
-			strings.Contains(line, "Virtual Terminal") ||
+			fbt.filter.isNoise(line) ||
 			strings.HasSuffix(line, "$ ") ||
 			strings.HasSuffix(line, "$") ||
-			(strings.Contains(line, "/home/user") && strings.Contains(line, "$") && !strings.Contains(line, " ")) {
+			fbt.filter.isPromptOnly(line) ||
+			(fbt.filter.hasPrompt(line) && !strings.Contains(line, " ")) {
 			continue
 		}
 
@@ -471,16 +512,14 @@ func (fbt *FileBasedTerminal) cleanOutput(output string) string {
 
 // Close cleans up temporary files
 func (fbt *FileBasedTerminal) Close() error {
-	// Clean up temp files
-	os.Remove(fbt.InputFile)
-	os.Remove(fbt.OutputFile)
-	os.Remove(fbt.WorkDir)
-	return nil
+	// The variant runs in the work dir and may leave files of its own there
+	return os.RemoveAll(fbt.WorkDir)
 }
 
 // RunFileBasedTest runs a test case using file-based communication
-func RunFileBasedTest(executablePath string, testCase TestCase) TestResult {
-	result := TestResult{
+func RunFileBasedTest(executablePath string, testCase TestCase, opts RunOptions) (result TestResult) {
+	// result is a named return so the deferred duration is recorded on the returned value
+	result = TestResult{
 		TestCase:  testCase,
 		Variant:   filepath.Base(strings.TrimSuffix(executablePath, ".exe")),
 		Timestamp: time.Now(),
@@ -491,14 +530,32 @@ func RunFileBasedTest(executablePath string, testCase TestCase) TestResult {
 		result.Duration = time.Since(startTime)
 	}()
 
-	// Create file-based terminal
-	fbt, err := NewFileBasedTerminal(executablePath)
+	// Create file-based terminal. Kept work dirs are per test, so later tests can't overwrite them.
+	var fbt *FileBasedTerminal
+	var err error
+	if opts.KeepTemp {
+		fbt, err = newFileBasedTerminalIn(executablePath, filepath.Join("temp", result.Variant, testCase.ID))
+	} else {
+		fbt, err = NewFileBasedTerminal(executablePath)
+	}
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to create file-based terminal: %v", err)
 		result.Passed = false
 		return result
 	}
-	defer fbt.Close()
+	if fbt.filter, err = newOutputFilter(opts.Output.RulesFor(result.Variant)); err != nil {
+		result.Error = err.Error()
+		result.Passed = false
+		fbt.Close()
+		return result
+	}
+	defer func() {
+		if opts.KeepTemp && !result.Passed {
+			result.TempDir, _ = filepath.Abs(fbt.WorkDir)
+			return
+		}
+		fbt.Close()
+	}()
 
 	// Execute setup commands
 	for _, setupCmd := range testCase.Setup {
@@ -510,6 +567,15 @@ func RunFileBasedTest(executablePath string, testCase TestCase) TestResult {
 		}
 	}
 
+	// Golden tests compare the whole session transcript instead of per-command output
+	if testCase.Golden != "" {
+		runGoldenTest(fbt, testCase, opts, &result)
+		for _, cleanupCmd := range testCase.Cleanup {
+			fbt.ExecuteCommand(cleanupCmd, testCase.Timeout)
+		}
+		return result
+	}
+
 	// Execute test commands
 	if len(testCase.Commands) == 1 {
 		// Single command
@@ -520,6 +586,7 @@ func RunFileBasedTest(executablePath string, testCase TestCase) TestResult {
 			return result
 		}
 		result.Output = []string{output}
+		result.ExitCode = exitStatus(err)
 	} else {
 		// Multiple commands
 		outputs, err := fbt.ExecuteCommands(testCase.Commands, testCase.Timeout)
@@ -529,6 +596,7 @@ func RunFileBasedTest(executablePath string, testCase TestCase) TestResult {
 			return result
 		}
 		result.Output = outputs
+		result.ExitCode = exitStatus(err)
 	}
 
 	result.Expected = testCase.Expected
@@ -549,6 +617,7 @@ func RunFileBasedTest(executablePath string, testCase TestCase) TestResult {
 			}
 		}
 	}
+	validateExit(testCase, &result)
 
 	// Execute cleanup commands (ignore errors)
 	for _, cleanupCmd := range testCase.Cleanup {
@@ -558,14 +627,173 @@ func RunFileBasedTest(executablePath string, testCase TestCase) TestResult {
 	return result
 }
 
+// exitStatus returns the exit status of a finished session from the error it returned:
+// 0 on success, the process's code if it exited non-zero, or -1 if it never exited
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// validateExit fails a passing result whose exit status differs from the test's ExpectedExit
+func validateExit(testCase TestCase, result *TestResult) {
+	if testCase.ExpectedExit == nil || !result.Passed {
+		return
+	}
+	if result.ExitCode != *testCase.ExpectedExit {
+		result.Passed = false
+		result.Error = fmt.Sprintf("Expected exit status %d, got %d", *testCase.ExpectedExit, result.ExitCode)
+	}
+}
+
+// RunOptions controls which tests the runner executes and what it records
+type RunOptions struct {
+	Profile    bool           // Record a timing breakdown of the run
+	SlowestN   int            // Number of slowest tests kept in the profile
+	Filter     *regexp.Regexp // Only run tests whose ID (from the start) or Description matches
+	Categories []string       // Only run tests in these categories
+
+	Consistency  bool   // Compare each command's output across variants
+	FailFast     bool   // Stop a variant's remaining tests after its first failure
+	FailFastAll  bool   // Stop the whole run after the first failure
+	InProcess    bool   // Run the embedded variant in-process instead of the configured executables
+	NoBuild      bool   // Use the executables in the bin directory as they are, never building them
+	KeepTemp     bool   // Keep the work dirs of failing tests instead of removing them
+	Verbose      bool   // Print each test's captured output next to what it expected
+	Repeat       int    // Run each test this many times and report the ones whose result changes
+	Seed         int64  // Shuffle the test order with this seed; 0 keeps the suite order
+	GoldenDir    string // Directory holding golden transcripts, one subdirectory per variant
+	UpdateGolden bool   // Rewrite golden files from the captured output instead of comparing
+
+	Output OutputConfig // Banner and prompt patterns used to pick out each command's output
+}
+
+// testGroup is a named batch of tests run together for a variant
+type testGroup struct {
+	name  string
+	tests []TestCase
+}
+
+// selectTests picks the tests to run. Without a filter or categories it runs the first few
+// tests of the main categories; otherwise it searches the whole suite.
+func selectTests(suite TestSuite, opts RunOptions) []testGroup {
+	if opts.Filter == nil && len(opts.Categories) == 0 {
+		return []testGroup{
+			{"Navigation", suite.Navigation[:min(3, len(suite.Navigation))]},
+			{"File Operations", suite.FileOps[:min(3, len(suite.FileOps))]},
+			{"Directory Operations", suite.DirOps[:min(2, len(suite.DirOps))]},
+			{"Content Operations", suite.Content[:min(2, len(suite.Content))]},
+			{"System Commands", suite.System},
+		}
+	}
+
+	all := []testGroup{
+		{"Navigation", suite.Navigation},
+		{"File Operations", suite.FileOps},
+		{"Directory Operations", suite.DirOps},
+		{"Content Operations", suite.Content},
+		{"System Commands", suite.System},
+		{"Edge Cases", suite.EdgeCases},
+		{"Integration", suite.Integration},
+		{"Performance", suite.Performance},
+	}
+
+	var idFilter *regexp.Regexp
+	if opts.Filter != nil {
+		idFilter = regexp.MustCompile("^(?:" + opts.Filter.String() + ")")
+	}
+
+	var groups []testGroup
+	for _, group := range all {
+		var tests []TestCase
+		for _, testCase := range group.tests {
+			if len(opts.Categories) > 0 && !matchesCategory(testCase.Category, opts.Categories) {
+				continue
+			}
+			if opts.Filter != nil && !idFilter.MatchString(testCase.ID) && !opts.Filter.MatchString(testCase.Description) {
+				continue
+			}
+			tests = append(tests, testCase)
+		}
+		if len(tests) > 0 {
+			groups = append(groups, testGroup{group.name, tests})
+		}
+	}
+	return groups
+}
+
+// testOrder returns the position of each test in groups by test ID
+func testOrder(groups []testGroup) map[string]int {
+	order := make(map[string]int)
+	for _, group := range groups {
+		for _, testCase := range group.tests {
+			order[testCase.ID] = len(order)
+		}
+	}
+	return order
+}
+
+// shuffleTests returns groups with the groups and the tests within each group in an order
+// picked by seed. The same seed always gives the same order.
+func shuffleTests(groups []testGroup, seed int64) []testGroup {
+	rng := rand.New(rand.NewSource(seed))
+	shuffled := make([]testGroup, len(groups))
+	for i, group := range groups {
+		tests := append([]TestCase(nil), group.tests...)
+		rng.Shuffle(len(tests), func(a, b int) { tests[a], tests[b] = tests[b], tests[a] })
+		shuffled[i] = testGroup{group.name, tests}
+	}
+	rng.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+	return shuffled
+}
+
+// matchesCategory reports whether category is one of names, ignoring case, spaces, dashes and underscores
+func matchesCategory(category string, names []string) bool {
+	normalize := strings.NewReplacer(" ", "", "-", "", "_", "")
+	category = strings.ToLower(normalize.Replace(category))
+	for _, name := range names {
+		if strings.ToLower(normalize.Replace(name)) == category {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run runs the suite as the command line asks and returns the exit code. Returning rather than
+// calling os.Exit lets the deferred cleanup of the temp dir happen.
+func run() int {
+	profile := flag.Bool("profile", false, "print and report a timing breakdown of the run")
+	slowest := flag.Int("profile-top", 10, "number of slowest tests listed by --profile")
+	filter := flag.String("filter", "", "only run tests whose ID or description matches this regex")
+	inProcess := flag.Bool("in-process", false, "run the embedded "+InProcessVariant+" in-process instead of executables")
+	failFast := flag.Bool("fail-fast", false, "stop a variant's remaining tests at its first failure")
+	failFastAll := flag.Bool("fail-fast-all", false, "stop the whole run at the first failure")
+	consistency := flag.Bool("consistency", false, "report commands whose output differs between variants")
+	noBuild := flag.Bool("no-build", false, "use the executables in bin as they are instead of building missing or stale ones")
+	updateGolden := flag.Bool("update-golden", false, "regenerate golden transcripts from the current output")
+	seed := flag.Int64("seed", 0, "shuffle the test order with this seed to find order-dependent failures (0 keeps the suite order)")
+	repeat := flag.Int("repeat", 1, "run each test this many times and report tests whose result changes between runs")
+	verbose := flag.Bool("verbose", false, "print each test's captured output next to the expected values")
+	keepTemp := flag.Bool("keep-temp", false, "keep the input and output files of failing tests in the temp dir")
+	categories := flag.String("categories", "", "comma-separated list of categories to run (e.g. \"file-ops,content\")")
+	flag.Parse()
+
 	fmt.Printf(" Terminal Emulator Test Suite (File-Based)\n")
 
 	// Load configuration
 	config, err := LoadConfig()
 	if err != nil {
 		fmt.Printf("[ERROR] Failed to load configuration: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
 	variants := config.Variants.Names
@@ -574,11 +802,150 @@ func main() {
 	// Create temp and reports directories
 	os.MkdirAll(config.Paths.TempDir, 0755)
 	os.MkdirAll(config.Paths.ReportsDir, 0755)
-	defer os.RemoveAll(config.Paths.TempDir) // Clean up temp dir at the end
+	if !*keepTemp {
+		defer cleanupTempDir(config.Paths.TempDir) // Clean up temp dir at the end, even on a panic
+	}
+
+	opts := RunOptions{
+		Profile:      *profile,
+		SlowestN:     *slowest,
+		Consistency:  *consistency,
+		FailFast:     *failFast,
+		FailFastAll:  *failFastAll,
+		InProcess:    *inProcess,
+		NoBuild:      *noBuild,
+		KeepTemp:     *keepTemp,
+		Verbose:      *verbose,
+		Repeat:       *repeat,
+		Seed:         *seed,
+		GoldenDir:    config.Paths.GoldenDir,
+		UpdateGolden: *updateGolden,
+		Output:       config.Output,
+	}
+	if *filter != "" {
+		opts.Filter, err = regexp.Compile(*filter)
+		if err != nil {
+			fmt.Printf("[ERROR] Invalid --filter: %v\n", err)
+			return 1
+		}
+	}
+	if *categories != "" {
+		opts.Categories = strings.Split(*categories, ",")
+	}
+	allResults := RunSuite(config, opts)
+
+	// Generate summary
+	summary := CalculateSummary(allResults)
+	if opts.Profile {
+		summary.Profile = BuildProfile(allResults, opts.SlowestN)
+	}
+	if opts.Consistency {
+		summary.Consistency = BuildConsistency(allResults)
+	}
+	if opts.Repeat > 1 {
+		summary.Flaky = BuildFlaky(allResults, opts.Repeat)
+	}
+
+	// Print summary
+	fmt.Print("\n" + strings.Repeat("=", 60) + "\n")
+	fmt.Printf(" TEST SUMMARY (FILE-BASED)\n")
+	fmt.Print(strings.Repeat("=", 60) + "\n\n")
+
+	if summary.Profile != nil {
+		summary.Profile.Print()
+	}
+	if summary.Consistency != nil {
+		summary.Consistency.Print()
+	}
+	if summary.Flaky != nil {
+		summary.Flaky.Print()
+	}
 
+	// Generate HTML report
+	fmt.Printf(" Generating HTML report...\n")
+	reportPath := filepath.Join(config.Paths.ReportsDir, "test_report.html")
+	reportErr := GenerateHTMLReport(summary, reportPath)
+	if reportErr != nil {
+		color.Red("[ERROR] Failed to generate HTML report: %v\n", reportErr)
+	} else {
+		color.Green("[OK] HTML report generated successfully!\n")
+	}
+
+	fmt.Printf(" Open %s in your browser to view the detailed report\n", reportPath)
+
+	csvPath := filepath.Join(config.Paths.ReportsDir, "test_report.csv")
+	if err := GenerateCSVReport(summary, csvPath); err != nil {
+		color.Red("[ERROR] Failed to generate CSV report: %v\n", err)
+	} else {
+		color.Green("[OK] CSV report written to %s\n", csvPath)
+	}
+
+	badgePath := filepath.Join(config.Paths.ReportsDir, "badge.json")
+	if err := GenerateBadgeJSON(summary, badgePath); err != nil {
+		color.Red("[ERROR] Failed to generate badge: %v\n", err)
+	} else {
+		color.Green("[OK] Badge written to %s\n", badgePath)
+	}
+	return 0
+}
+
+// ensureExecutable builds the variant in sourceDir into executablePath when the executable is
+// missing or older than the sources, and returns how long the build took. A variant without
+// sources keeps whatever executable it has.
+func ensureExecutable(sourceDir, executablePath string) (time.Duration, error) {
+	if !hasGoFiles(sourceDir) || !isStale(sourceDir, executablePath) {
+		return 0, nil
+	}
+	color.Yellow("[BUILD] Building %s from %s\n", filepath.Base(executablePath), sourceDir)
+	startTime := time.Now()
+	_, err := BuildVariant(sourceDir, filepath.Dir(executablePath))
+	return time.Since(startTime), err
+}
+
+// isStale reports whether the executable is missing or older than go.mod or any Go file in
+// sourceDir, including the packages in its subdirectories
+func isStale(sourceDir, executablePath string) bool {
+	binary, err := os.Stat(executablePath)
+	if err != nil {
+		return true
+	}
+	stale := false
+	filepath.WalkDir(sourceDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || (entry.Name() != "go.mod" && !strings.HasSuffix(entry.Name(), ".go")) {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().After(binary.ModTime()) {
+			stale = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return stale
+}
+
+// cleanupTempDir removes dir and everything in it. It is meant to be deferred: a panic is
+// recovered long enough to remove the directory and then raised again.
+func cleanupTempDir(dir string) {
+	r := recover()
+	os.RemoveAll(dir)
+	if r != nil {
+		panic(r)
+	}
+}
+
+// RunSuite runs the test suite against every configured variant
+func RunSuite(config *Config, opts RunOptions) []VariantResults {
 	testSuite := GetAllTestCases(config.GetTimeout())
 	var allResults []VariantResults
 
+	variants := config.Variants.Names
+	if opts.InProcess {
+		variants = []string{InProcessVariant}
+	}
+	if opts.Seed != 0 {
+		fmt.Printf("Shuffling test order with seed %d\n", opts.Seed)
+	}
+
 	// Test each variant
 	for _, variantPath := range variants {
 		variantName := filepath.Base(variantPath)
@@ -589,33 +956,53 @@ func main() {
 			TestResults: []TestResult{},
 		}
 
-		// Use pre-built executable from bin directory
-		executablePath := filepath.Join(config.Paths.BinDir, variantName+".exe")
-		absExecPath, _ := filepath.Abs(executablePath)
-		if _, err := os.Stat(absExecPath); err != nil {
-			result.BuildSuccess = false
-			result.BuildError = fmt.Sprintf("Executable not found: %s (abs: %s)", executablePath, absExecPath)
-			color.Red("[ERROR] Executable not found for %s: %s\n", variantName, absExecPath)
-			allResults = append(allResults, result)
-			continue
-		}
+		var runTest func(TestCase) TestResult
+		if opts.InProcess {
+			runner := NewInProcessRunner()
+			runTest = runner.Run
+			result.BuildSuccess = true
+			color.Green("[OK] Running %s in-process\n", variantName)
+		} else {
+			// Use the executable from the bin directory, building it first when needed
+			executablePath := filepath.Join(config.Paths.BinDir, variantName+".exe")
+			if !opts.NoBuild {
+				sourceDir := filepath.Join(config.Paths.SourceDir, variantPath)
+				duration, err := ensureExecutable(sourceDir, executablePath)
+				result.BuildDuration = duration
+				if err != nil {
+					result.BuildSuccess = false
+					result.BuildError = err.Error()
+					color.Red("[ERROR] Failed to build %s: %v\n", variantName, err)
+					allResults = append(allResults, result)
+					continue
+				}
+			}
+			absExecPath, _ := filepath.Abs(executablePath)
+			_, statErr := os.Stat(absExecPath)
+			if statErr != nil {
+				result.BuildSuccess = false
+				result.BuildError = fmt.Sprintf("Executable not found: %s (abs: %s)", executablePath, absExecPath)
+				color.Red("[ERROR] Executable not found for %s: %s\n", variantName, absExecPath)
+				allResults = append(allResults, result)
+				continue
+			}
 
-		result.BuildSuccess = true
-		color.Green("[OK] Found executable for %s\n", variantName)
+			result.BuildSuccess = true
+			color.Green("[OK] Found executable for %s\n", variantName)
+			runTest = func(testCase TestCase) TestResult {
+				return RunFileBasedTest(absExecPath, testCase, opts)
+			}
+		}
 
-		// Run a subset of tests (first few from each category for demo)
-		categories := []struct {
-			name  string
-			tests []TestCase
-		}{
-			{"Navigation", testSuite.Navigation[:min(3, len(testSuite.Navigation))]},
-			{"File Operations", testSuite.FileOps[:min(3, len(testSuite.FileOps))]},
-			{"Directory Operations", testSuite.DirOps[:min(2, len(testSuite.DirOps))]},
-			{"Content Operations", testSuite.Content[:min(2, len(testSuite.Content))]},
-			{"System Commands", testSuite.System},
+		groups := selectTests(testSuite, opts)
+		order := testOrder(groups)
+		if opts.Seed != 0 {
+			groups = shuffleTests(groups, opts.Seed)
 		}
 
-		for _, category := range categories {
+		failed := false
+	categories:
+		for _, category := range groups {
 			if len(category.tests) == 0 {
 				continue
 			}
@@ -623,7 +1010,12 @@ func main() {
 			fmt.Printf("\n* Running %s tests for %s...\n", category.name, variantName)
 
 			for _, testCase := range category.tests {
-				testResult := RunFileBasedTest(absExecPath, testCase)
+				var testResult TestResult
+				if opts.Repeat > 1 {
+					testResult = runRepeated(runTest, testCase, opts.Repeat)
+				} else {
+					testResult = runTest(testCase)
+				}
 				result.TestResults = append(result.TestResults, testResult)
 				result.TotalTests++
 
@@ -634,35 +1026,53 @@ func main() {
 				}
 
 				LogTestProgress(variantName, testCase, testResult)
+				if opts.Verbose {
+					LogTestDetails(testCase, testResult)
+				}
+
+				if !testResult.Passed && (opts.FailFast || opts.FailFastAll) {
+					failed = true
+					reportFailFast(variantName, testResult)
+					break categories
+				}
 			}
 		}
 
+		// Report the tests in suite order, however they were run
+		sort.SliceStable(result.TestResults, func(i, j int) bool {
+			return order[result.TestResults[i].TestCase.ID] < order[result.TestResults[j].TestCase.ID]
+		})
+
 		result.TotalDuration = time.Since(startTime)
 		if result.TotalTests > 0 {
 			result.PassRate = float64(result.PassedTests) / float64(result.TotalTests) * 100
 		}
 		allResults = append(allResults, result)
-	}
 
-	// Generate summary
-	summary := CalculateSummary(allResults)
+		if failed && opts.FailFastAll {
+			color.Red("[FAIL-FAST] Skipping remaining variants\n")
+			break
+		}
+	}
 
-	// Print summary
-	fmt.Printf("\n" + strings.Repeat("=", 60) + "\n")
-	fmt.Printf(" TEST SUMMARY (FILE-BASED)\n")
-	fmt.Printf(strings.Repeat("=", 60) + "\n\n")
+	return allResults
+}
 
-	// Generate HTML report
-	fmt.Printf(" Generating HTML report...\n")
-	reportPath := filepath.Join(config.Paths.ReportsDir, "test_report.html")
-	reportErr := GenerateHTMLReport(summary, reportPath)
-	if reportErr != nil {
-		color.Red("[ERROR] Failed to generate HTML report: %v\n", reportErr)
-	} else {
-		color.Green("[OK] HTML report generated successfully!\n")
+// reportFailFast prints the test that stopped a --fail-fast run
+func reportFailFast(variant string, result TestResult) {
+	color.Red("\n" + strings.Repeat("!", 60) + "\n")
+	color.Red("[FAIL-FAST] %s stopped at %s.%s - %s\n", variant,
+		result.TestCase.Category, result.TestCase.ID, result.TestCase.Description)
+	for i, command := range result.TestCase.Commands {
+		fmt.Printf("    $ %s\n", command)
+		if i < len(result.Output) && result.Output[i] != "" {
+			fmt.Printf("      %s\n", strings.ReplaceAll(result.Output[i], "\n", "\n      "))
+		}
 	}
-
-	fmt.Printf(" Open %s in your browser to view the detailed report\n", reportPath)
+	if result.Error != "" {
+		color.Red("    %s\n", result.Error)
+	}
+	color.Red(strings.Repeat("!", 60) + "\n")
 }
 
 // Helper function for min
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoVariant is a stand-in terminal that answers pwd and echoes every other command
+const echoVariant = `#!/bin/sh
+while read line; do
+	case "$line" in
+	exit) exit 0 ;;
+	pwd) echo /home/user ;;
+	*) echo "$line" ;;
+	esac
+done
+`
+
+// newFakeConfig writes the given variant scripts into a temporary bin directory, switches to a
+// temporary working directory and returns a config that runs them
+func newFakeConfig(t *testing.T, variants map[string]string) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{}
+	config.TestSettings.TimeoutSeconds = 5
+	config.Paths.BinDir = binDir
+	config.Paths.SourceDir = filepath.Join(dir, "src")
+	config.Paths.TempDir = filepath.Join(dir, "temp")
+	config.Paths.ReportsDir = filepath.Join(dir, "reports")
+	for name, script := range variants {
+		if err := os.WriteFile(filepath.Join(binDir, name+".exe"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+		config.Variants.Names = append(config.Variants.Names, name)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return config
+}
+
+func TestRunSuiteMissingExecutable(t *testing.T) {
+	config := newFakeConfig(t, nil)
+	config.Variants.Names = []string{"missing"}
+
+	results := RunSuite(config, RunOptions{})
+	if len(results) != 1 || results[0].BuildSuccess {
+		t.Fatalf("Expected a failed build for a missing executable, got %+v", results)
+	}
+	if results[0].TotalTests != 0 {
+		t.Errorf("No tests should run without an executable, got %d", results[0].TotalTests)
+	}
+}
+
+func TestRunSuiteRecordsDurations(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"fake": echoVariant})
+
+	results := RunSuite(config, RunOptions{})
+	if len(results) != 1 || !results[0].BuildSuccess {
+		t.Fatalf("Expected the fake variant to be found, got %+v", results)
+	}
+	if results[0].TotalTests == 0 {
+		t.Fatal("Expected tests to run against the fake variant")
+	}
+	for _, result := range results[0].TestResults {
+		if result.Duration <= 0 || result.Duration > 5*time.Second {
+			t.Errorf("%s: unexpected duration %v", result.TestCase.ID, result.Duration)
+		}
+	}
+}
+
+func TestFilterRunsOnlyMatchingTests(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"fake": echoVariant})
+
+	results := RunSuite(config, RunOptions{Filter: regexp.MustCompile("2.3")})
+	ran := results[0].TestResults
+	if len(ran) != 6 {
+		t.Fatalf("Expected the 6 copy tests, got %d", len(ran))
+	}
+	for _, result := range ran {
+		if !strings.HasPrefix(result.TestCase.ID, "2.3.") || !strings.HasPrefix(result.TestCase.Description, "Copy") {
+			t.Errorf("Unexpected test %s (%s) selected by --filter 2.3", result.TestCase.ID, result.TestCase.Description)
+		}
+	}
+}
+
+func TestFilterCombinesWithCategories(t *testing.T) {
+	suite := GetAllTestCases(time.Second)
+
+	groups := selectTests(suite, RunOptions{Filter: regexp.MustCompile("(?i)non-existent")})
+	var ids []string
+	for _, group := range groups {
+		for _, testCase := range group.tests {
+			ids = append(ids, testCase.ID)
+		}
+	}
+	if len(ids) < 2 {
+		t.Fatalf("Expected description matches across categories, got %v", ids)
+	}
+
+	groups = selectTests(suite, RunOptions{Filter: regexp.MustCompile("(?i)non-existent"), Categories: []string{"file-ops"}})
+	if len(groups) != 1 || groups[0].name != "File Operations" {
+		t.Fatalf("Expected only File Operations, got %+v", groups)
+	}
+	for _, testCase := range groups[0].tests {
+		if testCase.Category != "File Ops" || !strings.Contains(strings.ToLower(testCase.Description), "non-existent") {
+			t.Errorf("Unexpected test %s selected", testCase.ID)
+		}
+	}
+}
+
+func TestFailFastStopsAtFirstFailure(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"root": rootVariant, "home": echoVariant})
+	config.Variants.Names = []string{"root", "home"}
+
+	results := RunSuite(config, RunOptions{FailFast: true})
+	if len(results) != 2 {
+		t.Fatalf("--fail-fast should still run every variant, got %d", len(results))
+	}
+	root := results[0]
+	if root.TotalTests != 1 || root.FailedTests != 1 || root.TestResults[0].TestCase.ID != "1.1.1" {
+		t.Errorf("Expected root to stop after failing 1.1.1, ran %d tests", root.TotalTests)
+	}
+	if results[1].TotalTests <= 1 {
+		t.Errorf("The next variant should run normally, ran %d tests", results[1].TotalTests)
+	}
+
+	results = RunSuite(config, RunOptions{FailFastAll: true})
+	if len(results) != 1 || results[0].TotalTests != 1 {
+		t.Errorf("--fail-fast-all should stop the run at the first failure, got %d variants", len(results))
+	}
+}
+
+// statusVariant is a stand-in terminal whose exit status is that of the last command,
+// where cat fails and everything else succeeds
+const statusVariant = `#!/bin/sh
+status=0
+while read line; do
+	case "$line" in
+	exit) exit $status ;;
+	cat*) echo "Error: no such file"; status=1 ;;
+	*) echo "$line"; status=0 ;;
+	esac
+done
+`
+
+func TestRunFileBasedTestExpectedExit(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"status": statusVariant})
+	executable := filepath.Join(config.Paths.BinDir, "status.exe")
+
+	tests := []struct {
+		name     string
+		commands []string
+		expected int
+		passed   bool
+	}{
+		{"success", []string{"touch a.txt"}, 0, true},
+		{"failure", []string{"cat missing"}, 1, true},
+		{"failure after success", []string{"touch a.txt", "cat missing"}, 1, true},
+		{"unexpected failure", []string{"cat missing"}, 0, false},
+		{"unexpected success", []string{"cat missing", "touch a.txt"}, 1, false},
+	}
+	for _, tt := range tests {
+		testCase := TestCase{
+			ID:           "9.3.1",
+			Commands:     tt.commands,
+			Timeout:      5 * time.Second,
+			ExpectedExit: ExitStatus(tt.expected),
+		}
+		result := RunFileBasedTest(executable, testCase, RunOptions{})
+		if result.Passed != tt.passed {
+			t.Errorf("%s: expected passed=%v, got %v (exit %d, error %q)", tt.name, tt.passed, result.Passed, result.ExitCode, result.Error)
+		}
+		if !tt.passed && !strings.Contains(result.Error, "exit status") {
+			t.Errorf("%s: expected an exit status error, got %q", tt.name, result.Error)
+		}
+	}
+}
+
+// litterVariant is a stand-in terminal that leaves a file behind in its working directory
+const litterVariant = `#!/bin/sh
+echo history > .history
+while read line; do
+	case "$line" in
+	exit) exit 0 ;;
+	*) echo "$line" ;;
+	esac
+done
+`
+
+func TestRunSuiteRemovesWorkDirs(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"litter": litterVariant})
+
+	results := RunSuite(config, RunOptions{Filter: regexp.MustCompile("^1\\.1\\.")})
+	if len(results) != 1 || results[0].TotalTests == 0 {
+		t.Fatalf("Expected tests to run against the fake variant, got %+v", results)
+	}
+	entries, err := os.ReadDir(config.Paths.TempDir)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			t.Errorf("Work dir %s was left behind in the temp dir", entry.Name())
+		}
+	}
+}
+
+func TestCleanupTempDirOnPanic(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "temp")
+	if err := os.MkdirAll(filepath.Join(dir, "variant"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "variant", "output.txt"), []byte("out"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the panic to be raised again, got %v", r)
+			}
+		}()
+		defer cleanupTempDir(dir)
+		panic("boom")
+	}()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed after a panic, got %v", dir, err)
+	}
+}
+
+// writeVariantSource writes a Go variant with the given main.go into the config's source directory
+func writeVariantSource(t *testing.T, config *Config, name, mainGo string) {
+	t.Helper()
+	dir := filepath.Join(config.Paths.SourceDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	goMod := "module " + name + "\n\ngo 1.21\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(mainGo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config.Variants.Names = append(config.Variants.Names, name)
+}
+
+func TestRunSuiteBuildsVariants(t *testing.T) {
+	config := newFakeConfig(t, nil)
+	writeVariantSource(t, config, "echo", `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if scanner.Text() == "exit" {
+			return
+		}
+		fmt.Println(scanner.Text())
+	}
+}
+`)
+	writeVariantSource(t, config, "broken", "package main\n\nfunc main() { undefined() }\n")
+
+	results := RunSuite(config, RunOptions{Filter: regexp.MustCompile("^1\\.1\\.1$")})
+	if len(results) != 2 {
+		t.Fatalf("Expected both variants to be reported, got %+v", results)
+	}
+	if !results[0].BuildSuccess || results[0].TotalTests != 1 {
+		t.Errorf("Expected echo to be built and tested, got %+v", results[0])
+	}
+	if _, err := os.Stat(filepath.Join(config.Paths.BinDir, "echo.exe")); err != nil {
+		t.Errorf("Expected the built executable in the bin directory: %v", err)
+	}
+	if results[1].BuildSuccess || !strings.Contains(results[1].BuildError, "build failed") || results[1].TotalTests != 0 {
+		t.Errorf("Expected broken to be reported as a build failure, got %+v", results[1])
+	}
+
+	results = RunSuite(config, RunOptions{NoBuild: true})
+	if results[1].BuildSuccess || !strings.Contains(results[1].BuildError, "not found") {
+		t.Errorf("--no-build should not build broken, got %+v", results[1])
+	}
+}
+
+func TestEnsureExecutableRebuildsStaleVariants(t *testing.T) {
+	config := newFakeConfig(t, nil)
+	writeVariantSource(t, config, "stale", "package main\n\nfunc main() {}\n")
+	sourceDir := filepath.Join(config.Paths.SourceDir, "stale")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "pkg", "pkg.go"), []byte("package pkg\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	executable := filepath.Join(config.Paths.BinDir, "stale.exe")
+	if _, err := ensureExecutable(sourceDir, executable); err != nil {
+		t.Fatal(err)
+	}
+
+	// Date the sources an hour before the binary so only a touched file makes it stale
+	built := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, path := range []string{"go.mod", "main.go", "pkg/pkg.go"} {
+		if err := os.Chtimes(filepath.Join(sourceDir, path), built.Add(-time.Hour), built.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(executable, built, built); err != nil {
+		t.Fatal(err)
+	}
+	modTime := func() time.Time {
+		info, err := os.Stat(executable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.ModTime()
+	}
+
+	if _, err := ensureExecutable(sourceDir, executable); err != nil {
+		t.Fatal(err)
+	}
+	if !modTime().Equal(built) {
+		t.Errorf("Expected the binary to be reused for unchanged sources")
+	}
+
+	touched := built.Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(sourceDir, "pkg", "pkg.go"), touched, touched); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ensureExecutable(sourceDir, executable); err != nil {
+		t.Fatal(err)
+	}
+	if !modTime().After(touched) {
+		t.Errorf("Expected touching a source in a subdirectory to rebuild the binary")
+	}
+}
+
+func TestKeepTempKeepsFailingTestFiles(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"status": statusVariant})
+	executable := filepath.Join(config.Paths.BinDir, "status.exe")
+	failing := TestCase{
+		ID:         "9.4.1",
+		Commands:   []string{"cat missing"},
+		Expected:   []string{"hello"},
+		Validation: []ValidationMode{ExactMatch},
+		Timeout:    5 * time.Second,
+	}
+
+	result := RunFileBasedTest(executable, failing, RunOptions{KeepTemp: true})
+	if result.Passed || result.TempDir == "" {
+		t.Fatalf("Expected a failing test with its temp dir kept, got passed=%v dir=%q", result.Passed, result.TempDir)
+	}
+	input, err := os.ReadFile(filepath.Join(result.TempDir, "input.txt"))
+	if err != nil || !strings.Contains(string(input), "cat missing") {
+		t.Errorf("Expected input.txt to hold the command, got %q (%v)", input, err)
+	}
+	output, err := os.ReadFile(filepath.Join(result.TempDir, "output.txt"))
+	if err != nil || !strings.Contains(string(output), "no such file") {
+		t.Errorf("Expected output.txt to hold the error, got %q (%v)", output, err)
+	}
+
+	passing := failing
+	passing.ID = "9.4.2"
+	passing.Commands = []string{"hello"}
+	if result := RunFileBasedTest(executable, passing, RunOptions{KeepTemp: true}); !result.Passed || result.TempDir != "" {
+		t.Errorf("Expected a passing test to be cleaned up, got passed=%v dir=%q", result.Passed, result.TempDir)
+	}
+
+	failing.ID = "9.4.3"
+	if result := RunFileBasedTest(executable, failing, RunOptions{}); result.TempDir != "" {
+		t.Errorf("Expected no temp dir to be kept without --keep-temp, got %q", result.TempDir)
+	}
+	if _, err := os.Stat(filepath.Join("temp", "status", "9.4.3")); !os.IsNotExist(err) {
+		t.Errorf("Expected no per-test work dir without --keep-temp, got %v", err)
+	}
+}
+
+// linesVariant is a stand-in terminal with a prompt whose commands print no, one or several lines
+const linesVariant = `#!/bin/sh
+printf '/home/user$ '
+while read line; do
+	case "$line" in
+	exit) exit 0 ;;
+	"echo "*) echo "${line#echo }" ;;
+	one) echo one ;;
+	many) echo first; echo second; echo third ;;
+	esac
+	printf '/home/user$ '
+done
+`
+
+func TestExecuteCommandsAttributesOutput(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"lines": linesVariant})
+	fbt, err := NewFileBasedTerminal(filepath.Join(config.Paths.BinDir, "lines.exe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fbt.Close()
+
+	tests := []struct {
+		commands []string
+		expected []string
+	}{
+		{[]string{"silent", "one"}, []string{"", "one"}},
+		{[]string{"one", "silent"}, []string{"one", ""}},
+		{[]string{"silent", "many", "silent", "one"}, []string{"", "first\nsecond\nthird", "", "one"}},
+		{[]string{"many", "one", "many"}, []string{"first\nsecond\nthird", "one", "first\nsecond\nthird"}},
+		{[]string{"silent", "silent", "silent"}, []string{"", "", ""}},
+	}
+	for _, tt := range tests {
+		outputs, err := fbt.ExecuteCommands(tt.commands, 5*time.Second)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.commands, err)
+		}
+		if !reflect.DeepEqual(outputs, tt.expected) {
+			t.Errorf("%v: expected %q, got %q", tt.commands, tt.expected, outputs)
+		}
+	}
+}
+
+func TestShuffleTestsIsDeterministic(t *testing.T) {
+	groups := selectTests(GetAllTestCases(time.Second), RunOptions{Filter: regexp.MustCompile(".")})
+	ids := func(groups []testGroup) []string {
+		var ids []string
+		for _, group := range groups {
+			for _, testCase := range group.tests {
+				ids = append(ids, testCase.ID)
+			}
+		}
+		return ids
+	}
+
+	first := ids(shuffleTests(groups, 42))
+	if second := ids(shuffleTests(groups, 42)); !reflect.DeepEqual(first, second) {
+		t.Error("The same seed should give the same order")
+	}
+	if reflect.DeepEqual(first, ids(groups)) {
+		t.Error("Expected seed 42 to change the order")
+	}
+	if reflect.DeepEqual(first, ids(shuffleTests(groups, 43))) {
+		t.Error("Expected different seeds to give different orders")
+	}
+	if len(first) != len(ids(groups)) {
+		t.Errorf("Shuffling should keep every test, got %d of %d", len(first), len(ids(groups)))
+	}
+}
+
+func TestSeededRunsReportInSuiteOrder(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"fake": echoVariant})
+	ids := func(opts RunOptions) []string {
+		opts.Filter = regexp.MustCompile(`^[23]\.`)
+		var ids []string
+		for _, result := range RunSuite(config, opts)[0].TestResults {
+			ids = append(ids, result.TestCase.ID)
+		}
+		return ids
+	}
+
+	first := ids(RunOptions{Seed: 7})
+	if second := ids(RunOptions{Seed: 7}); !reflect.DeepEqual(first, second) {
+		t.Errorf("Runs with the same seed should report in the same order:\n%v\n%v", first, second)
+	}
+	if unseeded := ids(RunOptions{}); !reflect.DeepEqual(first, unseeded) {
+		t.Errorf("A seeded run should report in suite order:\n%v\n%v", first, unseeded)
+	}
+}
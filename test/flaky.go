@@ -0,0 +1,68 @@
+package main
+
+import "fmt"
+
+// FlakyReport lists the tests whose result changed between the runs of a --repeat run
+type FlakyReport struct {
+	Runs   int // Times each test was run
+	Tests  int // Tests run, counted once per variant
+	Flakes []FlakyTest
+}
+
+// FlakyTest is a test that both passed and failed on the same variant
+type FlakyTest struct {
+	Variant   string
+	TestCase  TestCase
+	Passed    int     // Runs that passed
+	Failed    int     // Runs that failed
+	FlakeRate float64 // Percentage of runs that failed
+}
+
+// runRepeated runs testCase n times and records every outcome in Runs. It returns the first
+// failing run, so failures can be inspected, or the first run when all of them passed.
+func runRepeated(runTest func(TestCase) TestResult, testCase TestCase, n int) TestResult {
+	var result TestResult
+	runs := make([]bool, n)
+	for i := range runs {
+		run := runTest(testCase)
+		runs[i] = run.Passed
+		if i == 0 || (result.Passed && !run.Passed) {
+			result = run
+		}
+	}
+	result.Runs = runs
+	return result
+}
+
+// BuildFlaky finds the tests whose runs did not all agree
+func BuildFlaky(variants []VariantResults, runs int) *FlakyReport {
+	report := &FlakyReport{Runs: runs}
+	for _, variant := range variants {
+		for _, result := range variant.TestResults {
+			report.Tests++
+			flake := FlakyTest{Variant: variant.Name, TestCase: result.TestCase}
+			for _, passed := range result.Runs {
+				if passed {
+					flake.Passed++
+				} else {
+					flake.Failed++
+				}
+			}
+			if flake.Passed > 0 && flake.Failed > 0 {
+				flake.FlakeRate = float64(flake.Failed) / float64(len(result.Runs)) * 100
+				report.Flakes = append(report.Flakes, flake)
+			}
+		}
+	}
+	return report
+}
+
+// Print writes the flaky tests to the console
+func (r *FlakyReport) Print() {
+	fmt.Printf(" FLAKY: %d of %d tests changed result across %d runs\n", len(r.Flakes), r.Tests, r.Runs)
+	for _, flake := range r.Flakes {
+		fmt.Printf("  [FLAKY] %-20s %s - %s: failed %d of %d runs (%.0f%%)\n", flake.Variant,
+			flake.TestCase.ID, flake.TestCase.Description, flake.Failed, flake.Passed+flake.Failed, flake.FlakeRate)
+	}
+	fmt.Println()
+}
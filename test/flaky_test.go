@@ -0,0 +1,47 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// flakyVariant is a stand-in terminal that answers pwd correctly only every other session,
+// counting sessions in a file next to itself
+const flakyVariant = `#!/bin/sh
+counter="$(dirname "$0")/sessions"
+count=$(cat "$counter" 2>/dev/null || echo 0)
+echo $((count + 1)) > "$counter"
+while read line; do
+	case "$line" in
+	exit) exit 0 ;;
+	pwd) if [ $((count % 2)) -eq 0 ]; then echo /home/user; else echo /elsewhere; fi ;;
+	*) echo "$line" ;;
+	esac
+done
+`
+
+func TestRepeatFlagsFlakyTests(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"flaky": flakyVariant, "steady": echoVariant})
+	config.Variants.Names = []string{"flaky", "steady"}
+
+	results := RunSuite(config, RunOptions{Filter: regexp.MustCompile(`^1\.1\.1$`), Repeat: 4})
+	if len(results) != 2 || results[0].TotalTests != 1 || results[1].TotalTests != 1 {
+		t.Fatalf("Expected one test per variant, got %+v", results)
+	}
+	flaky := results[0].TestResults[0]
+	if len(flaky.Runs) != 4 || flaky.Passed {
+		t.Errorf("Expected 4 runs with the failing one reported, got runs %v passed=%v", flaky.Runs, flaky.Passed)
+	}
+
+	report := BuildFlaky(results, 4)
+	if report.Tests != 2 || len(report.Flakes) != 1 {
+		t.Fatalf("Expected 1 of 2 tests flagged flaky, got %d of %d", len(report.Flakes), report.Tests)
+	}
+	flake := report.Flakes[0]
+	if flake.Variant != "flaky" || flake.TestCase.ID != "1.1.1" {
+		t.Errorf("Expected flaky 1.1.1 to be flagged, got %s %s", flake.Variant, flake.TestCase.ID)
+	}
+	if flake.Passed != 2 || flake.Failed != 2 || flake.FlakeRate != 50 {
+		t.Errorf("Expected 2 passes, 2 failures and a 50%% flake rate, got %d, %d and %.1f", flake.Passed, flake.Failed, flake.FlakeRate)
+	}
+}
@@ -1,12 +1,18 @@
 module terminal-emulator-tests
 
-go 1.21
+go 1.24.4
 
-require github.com/fatih/color v1.16.0
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/fatih/color v1.16.0
+	terminal-emulator v0.0.0
+)
 
 require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	golang.org/x/sys v0.14.0 // indirect
 )
+
+// The in-process harness embeds the sky variant directly
+replace terminal-emulator => ../sonoma-sky-alpha
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// goldenPath returns where a variant's golden transcript for a test case is stored
+func goldenPath(dir, variant, name string) string {
+	if dir == "" {
+		dir = "golden"
+	}
+	return filepath.Join(dir, variant, name)
+}
+
+// runGoldenTest runs the test's commands as one session and compares the transcript with its
+// golden file, or rewrites the golden file when opts.UpdateGolden is set
+func runGoldenTest(fbt *FileBasedTerminal, testCase TestCase, opts RunOptions, result *TestResult) {
+	transcript, err := fbt.ExecuteSession(testCase.Commands, testCase.Timeout)
+	if err != nil && !strings.Contains(err.Error(), "command failed") {
+		result.Error = err.Error()
+		result.Passed = false
+		return
+	}
+	result.Output = []string{transcript}
+
+	path := goldenPath(opts.GoldenDir, result.Variant, testCase.Golden)
+	if opts.UpdateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			result.Error = fmt.Sprintf("Failed to create golden directory: %v", err)
+			result.Passed = false
+			return
+		}
+		if err := os.WriteFile(path, []byte(transcript+"\n"), 0644); err != nil {
+			result.Error = fmt.Sprintf("Failed to write golden file: %v", err)
+			result.Passed = false
+			return
+		}
+		result.Expected = []string{transcript}
+		result.Passed = true
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		result.Error = fmt.Sprintf("Missing golden file %s (run with --update-golden to create it)", path)
+		result.Passed = false
+		return
+	}
+	expected := strings.TrimSuffix(string(golden), "\n")
+	result.Expected = []string{expected}
+
+	if diff := diffLines(expected, transcript); diff != "" {
+		result.Error = fmt.Sprintf("Transcript differs from %s:\n%s", path, diff)
+		result.Passed = false
+		return
+	}
+	result.Passed = true
+}
+
+// diffLines describes the first line where actual departs from expected, or returns "" if they match
+func diffLines(expected, actual string) string {
+	if expected == actual {
+		return ""
+	}
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var want, got string
+		if i < len(expectedLines) {
+			want = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			got = actualLines[i]
+		}
+		if i >= len(expectedLines) || i >= len(actualLines) || want != got {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, want, got)
+		}
+	}
+	return ""
+}
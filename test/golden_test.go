@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoldenTranscript(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"fake": echoVariant})
+	executable, _ := filepath.Abs(filepath.Join(config.Paths.BinDir, "fake.exe"))
+	goldenDir := filepath.Join(t.TempDir(), "golden")
+
+	testCase := TestCase{
+		ID:          "9.1.1",
+		Category:    "Golden",
+		Description: "Session transcript",
+		Commands:    []string{"pwd", "mkdir docs", "ls"},
+		Golden:      "session.golden",
+		Timeout:     5 * time.Second,
+	}
+
+	// Recording writes the golden file and passes
+	result := RunFileBasedTest(executable, testCase, RunOptions{GoldenDir: goldenDir, UpdateGolden: true})
+	if !result.Passed {
+		t.Fatalf("Recording should pass, got error: %s", result.Error)
+	}
+	path := filepath.Join(goldenDir, "fake", "session.golden")
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(golden), "/home/user") || !strings.Contains(string(golden), "mkdir docs") {
+		t.Errorf("Golden file should hold the session transcript, got %q", golden)
+	}
+
+	// Re-running against the recorded golden passes
+	result = RunFileBasedTest(executable, testCase, RunOptions{GoldenDir: goldenDir})
+	if !result.Passed {
+		t.Fatalf("Re-run should match the golden file, got error: %s", result.Error)
+	}
+
+	// A transcript that drifts from the golden file fails with a diff
+	os.WriteFile(path, []byte(strings.Replace(string(golden), "/home/user", "/root", 1)), 0644)
+	result = RunFileBasedTest(executable, testCase, RunOptions{GoldenDir: goldenDir})
+	if result.Passed {
+		t.Fatal("A changed golden file should fail the test")
+	}
+	if !strings.Contains(result.Error, "- /root") || !strings.Contains(result.Error, "+ /home/user") {
+		t.Errorf("Error should show the differing line, got %s", result.Error)
+	}
+}
+
+func TestGoldenMissingFile(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"fake": echoVariant})
+	executable, _ := filepath.Abs(filepath.Join(config.Paths.BinDir, "fake.exe"))
+
+	testCase := TestCase{ID: "9.1.2", Commands: []string{"pwd"}, Golden: "missing.golden", Timeout: 5 * time.Second}
+	result := RunFileBasedTest(executable, testCase, RunOptions{GoldenDir: t.TempDir()})
+	if result.Passed || !strings.Contains(result.Error, "--update-golden") {
+		t.Errorf("A missing golden file should fail with a hint, got %+v", result.Error)
+	}
+}
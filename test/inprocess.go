@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"terminal-emulator/fs"
+)
+
+// InProcessVariant is the variant the in-process harness embeds (see the replace in go.mod)
+const InProcessVariant = "sonoma-sky-alpha"
+
+// InProcessRunner runs test cases by calling a variant's Terminal directly instead of
+// spawning an executable, so outputs are exact and need no prompt stripping
+type InProcessRunner struct {
+	Terminal *fs.Terminal
+
+	out bytes.Buffer // What the terminal printed while the current command ran
+}
+
+// NewInProcessRunner creates a runner around a fresh terminal
+func NewInProcessRunner() *InProcessRunner {
+	r := &InProcessRunner{Terminal: fs.NewTerminal()}
+	r.Terminal.In = strings.NewReader("") // commands that read input see end of input, never the runner's stdin
+	r.Terminal.Out = &r.out
+	r.Terminal.Err = &r.out
+	return r
+}
+
+// Execute runs one command line and returns what it printed while running, its output
+// and any error, as the interactive loop prints them, so the usual validation modes apply
+func (r *InProcessRunner) Execute(command string) string {
+	r.out.Reset()
+	stdout, stderr, _ := r.Terminal.RunCommand(command)
+	return strings.TrimSuffix(r.out.String()+stdout+stderr, "\n")
+}
+
+// Run executes a test case against a reset terminal, so no state leaks between cases
+func (r *InProcessRunner) Run(testCase TestCase) (result TestResult) {
+	result = TestResult{
+		TestCase:  testCase,
+		Variant:   InProcessVariant,
+		Timestamp: time.Now(),
+	}
+	startTime := time.Now()
+	defer func() {
+		result.Duration = time.Since(startTime)
+	}()
+
+	r.Terminal.Reset()
+	for _, setupCmd := range testCase.Setup {
+		r.Execute(setupCmd)
+	}
+
+	result.Output = make([]string, len(testCase.Commands))
+	for i, command := range testCase.Commands {
+		result.Output[i] = r.Execute(command)
+	}
+	result.Expected = testCase.Expected
+
+	result.Passed = true
+	for i, output := range result.Output {
+		if i < len(testCase.Expected) && i < len(testCase.Validation) {
+			if !ValidateOutput(output, testCase.Expected[i], testCase.Validation[i]) {
+				result.Passed = false
+				result.Error = fmt.Sprintf("Validation failed for command %d: expected '%s', got '%s'", i+1, testCase.Expected[i], output)
+				break
+			}
+		}
+	}
+	result.ExitCode = r.Terminal.Status
+	validateExit(testCase, &result)
+
+	for _, cleanupCmd := range testCase.Cleanup {
+		r.Execute(cleanupCmd)
+	}
+	return result
+}
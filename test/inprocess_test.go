@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInProcessExactOutput(t *testing.T) {
+	runner := NewInProcessRunner()
+
+	steps := []struct {
+		command  string
+		expected string
+	}{
+		{"pwd", "/home/user"},
+		{"mkdir docs", ""},
+		{"touch docs/a.txt", ""},
+		{"cd docs", ""},
+		{"pwd", "/home/user/docs"},
+		{"ls", "a.txt"},
+		{"cat missing.txt", "Error: cat: missing.txt: no such file or directory: missing.txt"},
+		{"bogus", "Error: command not found: bogus"},
+	}
+	for _, step := range steps {
+		if got := runner.Execute(step.command); got != step.expected {
+			t.Errorf("%s: expected %q, got %q", step.command, step.expected, got)
+		}
+	}
+}
+
+func TestInProcessRunResetsBetweenCases(t *testing.T) {
+	runner := NewInProcessRunner()
+
+	first := runner.Run(TestCase{
+		ID:         "9.2.1",
+		Commands:   []string{"touch leftover.txt", "ls"},
+		Expected:   []string{"", "leftover.txt"},
+		Validation: []ValidationMode{NoError, ExactMatch},
+		Timeout:    time.Second,
+	})
+	if !first.Passed {
+		t.Fatalf("First case should pass: %s", first.Error)
+	}
+
+	second := runner.Run(TestCase{
+		ID:         "9.2.2",
+		Commands:   []string{"ls", "pwd"},
+		Expected:   []string{"", "/home/user"},
+		Validation: []ValidationMode{ExactMatch, ExactMatch},
+		Timeout:    time.Second,
+	})
+	if !second.Passed {
+		t.Errorf("Second case should start from a clean tree: %s (output %q)", second.Error, second.Output)
+	}
+}
+
+func TestRunSuiteInProcess(t *testing.T) {
+	config := newFakeConfig(t, nil)
+	results := RunSuite(config, RunOptions{InProcess: true})
+	if len(results) != 1 || results[0].Name != InProcessVariant || !results[0].BuildSuccess {
+		t.Fatalf("Expected a single in-process variant, got %+v", results)
+	}
+	if results[0].TotalTests == 0 {
+		t.Fatal("Expected tests to run in-process")
+	}
+	for _, result := range results[0].TestResults {
+		if result.TestCase.ID == "1.1.1" && !result.Passed {
+			t.Errorf("Initial pwd should pass in-process: %s", result.Error)
+		}
+	}
+}
+
+func TestInProcessExpectedExit(t *testing.T) {
+	runner := NewInProcessRunner()
+
+	success := runner.Run(TestCase{
+		ID:           "9.3.2",
+		Commands:     []string{"touch a.txt"},
+		Timeout:      time.Second,
+		ExpectedExit: ExitStatus(0),
+	})
+	if !success.Passed || success.ExitCode != 0 {
+		t.Errorf("Expected touch to exit 0: %s", success.Error)
+	}
+
+	failure := runner.Run(TestCase{
+		ID:           "9.3.3",
+		Commands:     []string{"cat missing"},
+		Timeout:      time.Second,
+		ExpectedExit: ExitStatus(1),
+	})
+	if !failure.Passed || failure.ExitCode != 1 {
+		t.Errorf("Expected cat missing to exit 1, got %d: %s", failure.ExitCode, failure.Error)
+	}
+
+	mismatch := runner.Run(TestCase{
+		ID:           "9.3.4",
+		Commands:     []string{"cat missing"},
+		Timeout:      time.Second,
+		ExpectedExit: ExitStatus(0),
+	})
+	if mismatch.Passed {
+		t.Error("Expected a failing command to fail an ExpectedExit of 0")
+	}
+}
+
+func TestInProcessCapturesPrintedOutput(t *testing.T) {
+	runner := NewInProcessRunner()
+	if got := runner.Execute("clear"); got != "\033[2J\033[H" {
+		t.Errorf("Expected clear's escape sequence, got %q", got)
+	}
+	if got := runner.Execute("pwd"); got != "/home/user" {
+		t.Errorf("Output printed by earlier commands should not leak, got %q", got)
+	}
+}
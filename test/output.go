@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultNoise matches the banners and sign-offs of the known variants
+var defaultNoise = []string{
+	"Welcome to",
+	"Type 'help'",
+	"Terminal Emulator",
+	"Goodbye",
+	"session ended",
+	"available commands",
+	"Virtual Terminal",
+}
+
+// defaultPrompts matches the "<cwd>$" prompt of the known variants while in the home directory
+var defaultPrompts = []string{`/home/user[^\s$]*\$`}
+
+// OutputRules are regular expressions telling a variant's command output apart from the rest
+// of what it prints
+type OutputRules struct {
+	Noise   []string `toml:"noise"`   // Banner and sign-off lines, matched with any prompts taken out
+	Prompts []string `toml:"prompts"` // The prompt printed before each command
+}
+
+// OutputConfig holds the output rules for every variant and the extra rules of single variants
+type OutputConfig struct {
+	Noise    []string               `toml:"noise"`
+	Prompts  []string               `toml:"prompts"`
+	Variants map[string]OutputRules `toml:"variants"`
+}
+
+// RulesFor returns the rules for variant: the shared rules, or the defaults when none are
+// configured, followed by the variant's own
+func (c OutputConfig) RulesFor(variant string) OutputRules {
+	rules := OutputRules{Noise: c.Noise, Prompts: c.Prompts}
+	if len(rules.Noise) == 0 {
+		rules.Noise = defaultNoise
+	}
+	if len(rules.Prompts) == 0 {
+		rules.Prompts = defaultPrompts
+	}
+	own := c.Variants[variant]
+	rules.Noise = append(append([]string(nil), rules.Noise...), own.Noise...)
+	rules.Prompts = append(append([]string(nil), rules.Prompts...), own.Prompts...)
+	return rules
+}
+
+// Validate checks that every pattern compiles
+func (c OutputConfig) Validate() error {
+	variants := []string{""}
+	for variant := range c.Variants {
+		variants = append(variants, variant)
+	}
+	for _, variant := range variants {
+		if _, err := newOutputFilter(c.RulesFor(variant)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputFilter picks command output out of a variant's session using its OutputRules
+type outputFilter struct {
+	noise  []*regexp.Regexp
+	prompt *regexp.Regexp // Any of the prompts, nil without prompts
+}
+
+// newOutputFilter compiles rules
+func newOutputFilter(rules OutputRules) (*outputFilter, error) {
+	filter := &outputFilter{}
+	for _, pattern := range rules.Noise {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid noise pattern '%s': %v", pattern, err)
+		}
+		filter.noise = append(filter.noise, re)
+	}
+	var prompts []string
+	for _, pattern := range rules.Prompts {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid prompt pattern '%s': %v", pattern, err)
+		}
+		prompts = append(prompts, "(?:"+pattern+")")
+	}
+	if len(prompts) > 0 {
+		filter.prompt = regexp.MustCompile(strings.Join(prompts, "|"))
+	}
+	return filter, nil
+}
+
+// defaultFilter applies the default rules
+var defaultFilter, _ = newOutputFilter(OutputConfig{}.RulesFor(""))
+
+// isNoise reports whether line is a banner or sign-off, possibly printed after a prompt
+func (f *outputFilter) isNoise(line string) bool {
+	if f.prompt != nil {
+		line = strings.TrimSpace(f.prompt.ReplaceAllString(line, ""))
+	}
+	for _, re := range f.noise {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrompt reports whether line contains a prompt
+func (f *outputFilter) hasPrompt(line string) bool {
+	return f.prompt != nil && f.prompt.MatchString(line)
+}
+
+// isPromptOnly reports whether line holds nothing but prompts
+func (f *outputFilter) isPromptOnly(line string) bool {
+	return f.hasPrompt(line) && strings.TrimSpace(f.prompt.ReplaceAllString(line, "")) == ""
+}
+
+// splitPrompts splits line at each prompt
+func (f *outputFilter) splitPrompts(line string) []string {
+	if f.prompt == nil {
+		return []string{line}
+	}
+	return f.prompt.Split(line, -1)
+}
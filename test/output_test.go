@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bannerVariant is a stand-in terminal with its own banner and a "fake> " prompt
+const bannerVariant = `#!/bin/sh
+echo "Booting fake shell v2"
+printf 'fake> '
+while read line; do
+	case "$line" in
+	exit) echo "Shutting down fake shell"; exit 0 ;;
+	*) echo "$line"; printf 'fake> ' ;;
+	esac
+done
+`
+
+func TestOutputRulesFor(t *testing.T) {
+	config := OutputConfig{Variants: map[string]OutputRules{"fake": {Noise: []string{"^Booting"}}}}
+
+	rules := config.RulesFor("fake")
+	if want := append(append([]string(nil), defaultNoise...), "^Booting"); !reflect.DeepEqual(rules.Noise, want) {
+		t.Errorf("Expected the defaults followed by the variant's noise, got %v", rules.Noise)
+	}
+	if !reflect.DeepEqual(rules.Prompts, defaultPrompts) {
+		t.Errorf("Expected the default prompts, got %v", rules.Prompts)
+	}
+	if rules := config.RulesFor("other"); !reflect.DeepEqual(rules.Noise, defaultNoise) {
+		t.Errorf("Another variant should only get the defaults, got %v", rules.Noise)
+	}
+
+	config.Noise = []string{"^Hello"}
+	if rules := config.RulesFor("other"); !reflect.DeepEqual(rules.Noise, []string{"^Hello"}) {
+		t.Errorf("Configured noise should replace the defaults, got %v", rules.Noise)
+	}
+
+	config.Variants["bad"] = OutputRules{Prompts: []string{"("}}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "invalid prompt pattern") {
+		t.Errorf("Expected an invalid prompt pattern error, got %v", err)
+	}
+}
+
+func TestCustomNoiseIsStripped(t *testing.T) {
+	filter, err := newOutputFilter(OutputConfig{
+		Variants: map[string]OutputRules{"fake": {Noise: []string{"^Booting", "^Shutting down"}, Prompts: []string{`fake>`}}},
+	}.RulesFor("fake"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fbt := &FileBasedTerminal{ExecutablePath: "fake.exe", filter: filter}
+	output := "Booting fake shell v2\nfake> hello\nfake> " + commandDelimiter(0) + "\nfake> world\nfake> Shutting down fake shell\n"
+
+	if got := fbt.cleanOutput(output); got != "fake> hello\nfake> "+commandDelimiter(0)+"\nfake> world" {
+		t.Errorf("cleanOutput: expected the banner to be stripped, got %q", got)
+	}
+	if got := fbt.parseMultiCommandOutput(output, 2); !reflect.DeepEqual(got, []string{"hello", "world"}) {
+		t.Errorf("parseMultiCommandOutput: expected [hello world], got %q", got)
+	}
+}
+
+func TestRunFileBasedTestUsesOutputConfig(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"banner": bannerVariant})
+	config.Output.Variants = map[string]OutputRules{"banner": {Noise: []string{"^Booting", "^Shutting down"}, Prompts: []string{`fake>`}}}
+	executable := filepath.Join(config.Paths.BinDir, "banner.exe")
+	testCase := TestCase{
+		ID:         "9.5.1",
+		Commands:   []string{"one", "two"},
+		Expected:   []string{"one", "two"},
+		Validation: []ValidationMode{ExactMatch, ExactMatch},
+		Timeout:    5 * time.Second,
+	}
+
+	if result := RunFileBasedTest(executable, testCase, RunOptions{}); result.Passed {
+		t.Errorf("Expected the unknown banner to spoil the output without config, got %q", result.Output)
+	}
+	result := RunFileBasedTest(executable, testCase, RunOptions{Output: config.Output})
+	if !result.Passed {
+		t.Errorf("Expected the configured patterns to strip the banner, got %q (%s)", result.Output, result.Error)
+	}
+
+	results := RunSuite(config, RunOptions{Filter: regexp.MustCompile(`^1\.1\.1$`), Output: config.Output})
+	if len(results) != 1 || results[0].TotalTests != 1 {
+		t.Fatalf("Expected one test to run, got %+v", results)
+	}
+	if output := results[0].TestResults[0].Output; strings.Contains(strings.Join(output, "\n"), "Booting") {
+		t.Errorf("Expected no banner in the captured output, got %q", output)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Profile is a timing breakdown of a test run
+type Profile struct {
+	BuildDurations    []NamedDuration // Time spent building each variant's executable, 0 when it was up to date
+	CategoryDurations []NamedDuration // Time spent in each category, summed across variants
+	Slowest           []TestResult    // Slowest individual tests across all variants
+}
+
+// NamedDuration pairs a label with the time attributed to it
+type NamedDuration struct {
+	Name     string
+	Duration time.Duration
+}
+
+// BuildProfile collects build times, per-category times and the slowest n tests from a run
+func BuildProfile(variants []VariantResults, n int) *Profile {
+	profile := &Profile{}
+	categoryTimes := make(map[string]time.Duration)
+	var categoryOrder []string
+	var all []TestResult
+
+	for _, variant := range variants {
+		profile.BuildDurations = append(profile.BuildDurations, NamedDuration{variant.Name, variant.BuildDuration})
+		for _, result := range variant.TestResults {
+			category := result.TestCase.Category
+			if _, seen := categoryTimes[category]; !seen {
+				categoryOrder = append(categoryOrder, category)
+			}
+			categoryTimes[category] += result.Duration
+			all = append(all, result)
+		}
+	}
+
+	for _, category := range categoryOrder {
+		profile.CategoryDurations = append(profile.CategoryDurations, NamedDuration{category, categoryTimes[category]})
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Duration > all[j].Duration
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	profile.Slowest = all
+
+	return profile
+}
+
+// Print writes the profile to the console
+func (p *Profile) Print() {
+	fmt.Printf(" PROFILE\n")
+	fmt.Printf("  Build:\n")
+	for _, build := range p.BuildDurations {
+		fmt.Printf("    %-24s %v\n", build.Name, build.Duration.Truncate(time.Microsecond))
+	}
+	fmt.Printf("  Categories:\n")
+	for _, category := range p.CategoryDurations {
+		fmt.Printf("    %-24s %v\n", category.Name, category.Duration.Truncate(time.Millisecond))
+	}
+	fmt.Printf("  Slowest tests:\n")
+	for _, result := range p.Slowest {
+		fmt.Printf("    %-24s %s.%s - %s (%v)\n", result.Variant, result.TestCase.Category, result.TestCase.ID,
+			result.TestCase.Description, result.Duration.Truncate(time.Millisecond))
+	}
+	fmt.Println()
+}
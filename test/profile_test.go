@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestBuildProfile(t *testing.T) {
+	config := newFakeConfig(t, map[string]string{"fake": echoVariant})
+	writeVariantSource(t, config, "built", "package main\n\nfunc main() {}\n")
+	results := RunSuite(config, RunOptions{Profile: true, SlowestN: 3})
+	profile := BuildProfile(results, 3)
+
+	if len(profile.BuildDurations) != 2 || profile.BuildDurations[0].Name != "fake" || profile.BuildDurations[1].Name != "built" {
+		t.Fatalf("Expected a build entry for each variant, got %+v", profile.BuildDurations)
+	}
+	if profile.BuildDurations[0].Duration != 0 {
+		t.Errorf("A prebuilt variant should take no build time, got %v", profile.BuildDurations[0].Duration)
+	}
+	if profile.BuildDurations[1].Duration <= 0 {
+		t.Error("Build duration should be nonzero for a variant built from source")
+	}
+
+	if len(profile.CategoryDurations) == 0 {
+		t.Fatal("Expected per-category durations")
+	}
+	for _, category := range profile.CategoryDurations {
+		if category.Duration <= 0 {
+			t.Errorf("Category %s has no recorded time", category.Name)
+		}
+	}
+
+	if len(profile.Slowest) != 3 {
+		t.Fatalf("Expected the 3 slowest tests, got %d", len(profile.Slowest))
+	}
+	for i := 1; i < len(profile.Slowest); i++ {
+		if profile.Slowest[i].Duration > profile.Slowest[i-1].Duration {
+			t.Error("Slowest tests should be ordered by descending duration")
+		}
+	}
+	if profile.Slowest[2].Duration <= 0 {
+		t.Error("Slowest test durations should be nonzero")
+	}
+}
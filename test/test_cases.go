@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 	"time"
 )
@@ -16,17 +17,42 @@ const (
 	HasError
 )
 
+// String returns the name of the validation mode
+func (m ValidationMode) String() string {
+	switch m {
+	case ExactMatch:
+		return "exact"
+	case Contains:
+		return "contains"
+	case RegexMatch:
+		return "regex"
+	case NoError:
+		return "no error"
+	case HasError:
+		return "error"
+	default:
+		return fmt.Sprintf("ValidationMode(%d)", int(m))
+	}
+}
+
 // TestCase represents a single test case
 type TestCase struct {
-	ID          string
-	Category    string
-	Description string
-	Commands    []string
-	Expected    []string
-	Validation  []ValidationMode
-	Setup       []string // Commands to run before test
-	Cleanup     []string // Commands to run after test
-	Timeout     time.Duration
+	ID           string
+	Category     string
+	Description  string
+	Commands     []string
+	Expected     []string
+	Validation   []ValidationMode
+	Setup        []string // Commands to run before test
+	Cleanup      []string // Commands to run after test
+	Timeout      time.Duration
+	Golden       string // Golden transcript file; when set it replaces Expected/Validation
+	ExpectedExit *int   // Exit status of the last command; nil skips the check
+}
+
+// ExitStatus returns a pointer for TestCase.ExpectedExit
+func ExitStatus(code int) *int {
+	return &code
 }
 
 // TestSuite contains all test cases organized by category
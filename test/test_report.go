@@ -27,10 +27,12 @@ type CategorySummary struct {
 
 // VariantCategoryStats contains category statistics for a specific variant
 type VariantCategoryStats struct {
-	VariantName string
-	Passed      int
-	Failed      int
-	Total       int
+	VariantName   string
+	Passed        int
+	Failed        int
+	Total         int
+	TotalDuration time.Duration // Time spent on the variant's tests in the category
+	AvgDuration   time.Duration // TotalDuration per test
 }
 
 const htmlTemplate = `<!DOCTYPE html>
@@ -496,6 +498,95 @@ const htmlTemplate = `<!DOCTYPE html>
                         {{end}}
                     </tbody>
                 </table>
+
+                {{with .Summary.Profile}}
+                <h2>Profile</h2>
+                <table>
+                    <thead>
+                        <tr>
+                            <th>Phase</th>
+                            <th>Name</th>
+                            <th>Duration</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range .BuildDurations}}
+                        <tr>
+                            <td>Build</td>
+                            <td><strong>{{.Name}}</strong></td>
+                            <td class="duration">{{.Duration}}</td>
+                        </tr>
+                        {{end}}
+                        {{range .CategoryDurations}}
+                        <tr>
+                            <td>Category</td>
+                            <td><strong>{{.Name}}</strong></td>
+                            <td class="duration">{{.Duration}}</td>
+                        </tr>
+                        {{end}}
+                        {{range .Slowest}}
+                        <tr>
+                            <td>Slow test</td>
+                            <td><strong>{{.Variant}}</strong> {{.TestCase.ID}} - {{.TestCase.Description}}</td>
+                            <td class="duration">{{.Duration}}</td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+                {{end}}
+
+                {{with .Summary.Consistency}}
+                <h2>Cross-Variant Agreement</h2>
+                <table>
+                    <thead>
+                        <tr>
+                            <th>Test ID</th>
+                            <th>Command</th>
+                            {{range .Variants}}<th>{{.}}</th>{{end}}
+                            <th>Agree</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range .Rows}}
+                        <tr>
+                            <td class="test-id">{{.TestID}}</td>
+                            <td class="commands"><code>{{.Command}}</code></td>
+                            {{range .Outputs}}<td><code>{{.}}</code></td>{{end}}
+                            <td class="{{if .Agree}}test-passed{{else}}test-failed{{end}}">{{if .Agree}}Yes{{else}}No{{end}}</td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+                {{end}}
+
+                {{with .Summary.Flaky}}
+                <h2>Flaky Tests</h2>
+                <p>{{len .Flakes}} of {{.Tests}} tests changed result across {{.Runs}} runs</p>
+                <table>
+                    <thead>
+                        <tr>
+                            <th>Variant</th>
+                            <th>Test ID</th>
+                            <th>Description</th>
+                            <th>Passed</th>
+                            <th>Failed</th>
+                            <th>Flake Rate</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {{range .Flakes}}
+                        <tr>
+                            <td><strong>{{.Variant}}</strong></td>
+                            <td class="test-id">{{.TestCase.ID}}</td>
+                            <td>{{.TestCase.Description}}</td>
+                            <td>{{.Passed}}</td>
+                            <td>{{.Failed}}</td>
+                            <td class="test-failed">{{printf "%.0f%%" .FlakeRate}}</td>
+                        </tr>
+                        {{end}}
+                    </tbody>
+                </table>
+                {{end}}
             </div>
             
             <div id="detailed" class="tab-content">
@@ -577,6 +668,7 @@ const htmlTemplate = `<!DOCTYPE html>
                             <div class="variant-stat">
                                 <div><strong>{{.VariantName}}</strong></div>
                                 <div>{{.Passed}}/{{.Total}} passed</div>
+                                <div class="duration">{{.TotalDuration}} total, {{.AvgDuration}} avg</div>
                             </div>
                             {{end}}
                         </div>
@@ -861,6 +953,7 @@ func prepareCategorySummaries(summary TestSummary) []CategorySummary {
 			for i := range cat.VariantStats {
 				if cat.VariantStats[i].VariantName == variant.Name {
 					cat.VariantStats[i].Total++
+					cat.VariantStats[i].TotalDuration += result.Duration
 					if result.Passed {
 						cat.VariantStats[i].Passed++
 					} else {
@@ -873,8 +966,9 @@ func prepareCategorySummaries(summary TestSummary) []CategorySummary {
 
 			if !found {
 				stat := VariantCategoryStats{
-					VariantName: variant.Name,
-					Total:       1,
+					VariantName:   variant.Name,
+					Total:         1,
+					TotalDuration: result.Duration,
 				}
 				if result.Passed {
 					stat.Passed = 1
@@ -892,6 +986,11 @@ func prepareCategorySummaries(summary TestSummary) []CategorySummary {
 		if cat.TotalTests > 0 {
 			cat.PassRate = float64(cat.PassedTests) / float64(cat.TotalTests) * 100
 		}
+		for i := range cat.VariantStats {
+			stat := &cat.VariantStats[i]
+			stat.AvgDuration = (stat.TotalDuration / time.Duration(stat.Total)).Round(time.Microsecond)
+			stat.TotalDuration = stat.TotalDuration.Round(time.Microsecond)
+		}
 		categories = append(categories, *cat)
 	}
 
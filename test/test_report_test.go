@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCategoryTimings(t *testing.T) {
+	result := func(variant, category string, duration time.Duration) TestResult {
+		return TestResult{TestCase: TestCase{Category: category}, Variant: variant, Passed: true, Duration: duration}
+	}
+	summary := CalculateSummary([]VariantResults{
+		{Name: "alpha", TestResults: []TestResult{
+			result("alpha", "Navigation", 10*time.Millisecond),
+			result("alpha", "Navigation", 20*time.Millisecond),
+			result("alpha", "File Ops", 5*time.Millisecond),
+		}},
+		{Name: "beta", TestResults: []TestResult{
+			result("beta", "Navigation", 3*time.Millisecond),
+			result("beta", "Navigation", 4*time.Millisecond),
+			result("beta", "Navigation", 5*time.Millisecond),
+		}},
+	})
+
+	stats := make(map[string]VariantCategoryStats)
+	for _, category := range prepareCategorySummaries(summary) {
+		for _, stat := range category.VariantStats {
+			stats[category.Name+"/"+stat.VariantName] = stat
+		}
+	}
+	tests := []struct {
+		key        string
+		total, avg time.Duration
+	}{
+		{"Navigation/alpha", 30 * time.Millisecond, 15 * time.Millisecond},
+		{"File Ops/alpha", 5 * time.Millisecond, 5 * time.Millisecond},
+		{"Navigation/beta", 12 * time.Millisecond, 4 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		stat, ok := stats[tt.key]
+		if !ok {
+			t.Errorf("%s: missing stats", tt.key)
+			continue
+		}
+		if stat.TotalDuration != tt.total || stat.AvgDuration != tt.avg {
+			t.Errorf("%s: expected %v total and %v avg, got %v and %v", tt.key, tt.total, tt.avg, stat.TotalDuration, stat.AvgDuration)
+		}
+	}
+	if _, ok := stats["File Ops/beta"]; ok {
+		t.Error("beta ran no File Ops tests and should have no stats there")
+	}
+
+	path := filepath.Join(t.TempDir(), "test_report.html")
+	if err := GenerateHTMLReport(summary, path); err != nil {
+		t.Fatal(err)
+	}
+	html, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "30ms total, 15ms avg") {
+		t.Error("Expected the category timings in the categories tab")
+	}
+}
@@ -27,6 +27,9 @@ type TestResult struct {
 	Output      []string
 	Expected    []string
 	Error       string
+	ExitCode    int // Exit status the session ended with, -1 if unknown
+	TempDir     string // Work dir kept for inspection by --keep-temp, empty once removed
+	Runs        []bool // Whether each run passed when the test was run repeatedly
 	Duration    time.Duration
 	Timestamp   time.Time
 }
@@ -36,6 +39,7 @@ type VariantResults struct {
 	Name          string
 	BuildSuccess  bool
 	BuildError    string
+	BuildDuration time.Duration
 	TestResults   []TestResult
 	TotalTests    int
 	PassedTests   int
@@ -53,42 +57,47 @@ type TestSummary struct {
 	TotalDuration time.Duration
 	Timestamp     time.Time
 	PassRate      float64
+	Profile       *Profile           // Timing breakdown, nil unless the run was profiled
+	Consistency   *ConsistencyReport // Cross-variant output comparison, nil unless requested
+	Flaky         *FlakyReport       // Tests whose result changed between repeated runs, nil unless repeated
 }
 
-// BuildVariant builds a specific variant and returns the executable path
-func BuildVariant(variantPath string) (string, error) {
+// BuildVariant builds a specific variant into outputDir and returns the executable path
+func BuildVariant(variantPath, outputDir string) (string, error) {
 	variantName := filepath.Base(variantPath)
-	
+
 	// Get absolute paths to avoid confusion
 	absVariantPath, err := filepath.Abs(variantPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get absolute path: %v", err)
 	}
-	
-	// Build in the current directory (test/)
-	outputPath := variantName + ".exe"
+
+	outputPath := filepath.Join(outputDir, variantName+".exe")
 	absOutputPath, err := filepath.Abs(outputPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get absolute output path: %v", err)
 	}
-	
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %v", err)
+	}
+
 	// Clean previous build
 	os.Remove(outputPath)
-	
+
 	// Build the variant
 	cmd := exec.Command("go", "build", "-o", absOutputPath)
 	cmd.Dir = absVariantPath
-	
+
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("build failed: %v\nOutput: %s", err, string(output))
 	}
-	
+
 	// Verify the executable exists
 	if _, err := os.Stat(outputPath); err != nil {
 		return "", fmt.Errorf("executable not found after build: %v", err)
 	}
-	
+
 	return outputPath, nil
 }
 
@@ -309,35 +318,39 @@ func RunTestCase(tp *TerminalProcess, testCase TestCase) TestResult {
 // GetVariantPaths returns the paths to all variant directories
 func GetVariantPaths() ([]string, error) {
 	baseDir := ".."
-	variants := []string{}
-	
-	// Read directory entries
+	names, err := DiscoverVariants(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]string, len(names))
+	for i, name := range names {
+		variants[i] = filepath.Join(baseDir, name)
+	}
+	return variants, nil
+}
+
+// DiscoverVariants returns the names of the directories in baseDir that hold a Go project,
+// skipping the test directory itself
+func DiscoverVariants(baseDir string) ([]string, error) {
 	entries, err := os.ReadDir(baseDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read base directory: %v", err)
 	}
-	
-	// Look for directories that contain go.mod and main.go
+
+	var variants []string
 	for _, entry := range entries {
-		if entry.IsDir() {
-			dirPath := filepath.Join(baseDir, entry.Name())
-			
-			// Skip the test directory itself
-			if entry.Name() == "test" {
-				continue
-			}
-			
-			// Check if it has go.mod and main.go (or any .go file)
-			if hasGoFiles(dirPath) {
-				variants = append(variants, dirPath)
-			}
+		if !entry.IsDir() || entry.Name() == "test" {
+			continue
+		}
+		if hasGoFiles(filepath.Join(baseDir, entry.Name())) {
+			variants = append(variants, entry.Name())
 		}
 	}
-	
+
 	if len(variants) == 0 {
-		return nil, fmt.Errorf("no valid Go projects found in parent directories")
+		return nil, fmt.Errorf("no valid Go projects found in %s", baseDir)
 	}
-	
 	return variants, nil
 }
 
@@ -405,6 +418,64 @@ func LogTestProgress(variant string, testCase TestCase, result TestResult) {
 	if !result.Passed && result.Error != "" {
 		fmt.Printf("    Error: %s\n", result.Error)
 	}
+	if result.TempDir != "" {
+		fmt.Printf("    Temp files: %s\n", result.TempDir)
+	}
+}
+
+// LogTestDetails prints each command of a test with its expected and captured output side by
+// side, for --verbose runs
+func LogTestDetails(testCase TestCase, result TestResult) {
+	writeTestDetails(os.Stdout, testCase, result)
+}
+
+// writeTestDetails writes the details printed by LogTestDetails to w
+func writeTestDetails(w io.Writer, testCase TestCase, result TestResult) {
+	if testCase.Golden != "" {
+		fmt.Fprintf(w, "    Golden: %s\n", testCase.Golden)
+		for _, output := range result.Output {
+			for _, line := range strings.Split(output, "\n") {
+				fmt.Fprintf(w, "      %s\n", line)
+			}
+		}
+		return
+	}
+
+	for i, command := range testCase.Commands {
+		fmt.Fprintf(w, "    $ %s\n", command)
+
+		header := "expected"
+		var expected, actual []string
+		if i < len(testCase.Expected) {
+			expected = strings.Split(testCase.Expected[i], "\n")
+			if i < len(testCase.Validation) {
+				header += " (" + testCase.Validation[i].String() + ")"
+			}
+		}
+		if i < len(result.Output) {
+			actual = strings.Split(result.Output[i], "\n")
+		}
+
+		width := len(header)
+		for _, line := range expected {
+			width = max(width, len(line))
+		}
+		fmt.Fprintf(w, "      %-*s | %s\n", width, header, "actual")
+		for j := 0; j < max(len(expected), len(actual)); j++ {
+			var left, right string
+			if j < len(expected) {
+				left = expected[j]
+			}
+			if j < len(actual) {
+				right = actual[j]
+			}
+			fmt.Fprintf(w, "      %-*s | %s\n", width, left, right)
+		}
+	}
+
+	if testCase.ExpectedExit != nil {
+		fmt.Fprintf(w, "    Exit: expected %d, got %d\n", *testCase.ExpectedExit, result.ExitCode)
+	}
 }
 
 // CalculateSummary calculates the test summary from variant results
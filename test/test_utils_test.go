@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteTestDetails(t *testing.T) {
+	testCase := TestCase{
+		ID:           "9.6.1",
+		Commands:     []string{"touch a.txt", "ls"},
+		Expected:     []string{"", "b.txt"},
+		Validation:   []ValidationMode{NoError, Contains},
+		ExpectedExit: ExitStatus(0),
+	}
+	result := TestResult{Output: []string{"", "a.txt\nc.txt"}, ExitCode: 1}
+
+	var buf bytes.Buffer
+	writeTestDetails(&buf, testCase, result)
+	log := buf.String()
+	for _, want := range []string{
+		"$ touch a.txt",
+		"$ ls",
+		"expected (contains) | actual",
+		"b.txt               | a.txt",
+		"                    | c.txt",
+		"Exit: expected 0, got 1",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("Expected %q in the verbose log:\n%s", want, log)
+		}
+	}
+
+	buf.Reset()
+	writeTestDetails(&buf, TestCase{Golden: "session.txt", Commands: []string{"pwd"}}, TestResult{Output: []string{"/home/user"}})
+	if log := buf.String(); !strings.Contains(log, "Golden: session.txt") || !strings.Contains(log, "/home/user") {
+		t.Errorf("Expected the golden file and transcript in the verbose log:\n%s", log)
+	}
+}